@@ -0,0 +1,104 @@
+// Command addresscheck re-validates every stored address against the
+// country rules used by the API and lists the ones that fail. With -fix it
+// also writes back addresses that are valid but not in canonical form.
+//
+// It exits with status 1 when invalid addresses were found.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"multi-processing-backend/internal/configs"
+	"multi-processing-backend/internal/core"
+	"multi-processing-backend/internal/db"
+	"multi-processing-backend/internal/services"
+)
+
+const pageSize = 500
+
+func main() {
+	fix := flag.Bool("fix", false, "write normalized values back for addresses that pass validation")
+	flag.Parse()
+
+	cfg := configs.Load()
+	ctx := context.Background()
+
+	pool := db.ConnectDatabase(ctx, cfg.DatabaseURL)
+	defer pool.Close()
+
+	repo := db.NewAddressRepository(pool)
+	service := services.NewAddressService(repo)
+
+	addresses, err := loadAll(ctx, repo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "addresscheck: loading addresses:", err)
+		os.Exit(2)
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "ADDRESS\tUSER\tFIELD\tPROBLEM")
+
+	var invalid, unnormalized, fixed int
+	for _, a := range addresses {
+		normalized, err := services.NormalizeAddress(a)
+		var verr *core.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			invalid++
+			for _, f := range verr.Fields {
+				fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", a.ID, a.UserID, f.Field, f.Message)
+			}
+			continue
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "addresscheck:", err)
+			os.Exit(2)
+		}
+
+		if sameAddress(a, normalized) {
+			continue
+		}
+		unnormalized++
+		if !*fix {
+			fmt.Fprintf(out, "%s\t%s\t-\tnot normalized: %s, %s %s, %s\n",
+				a.ID, a.UserID, normalized.Street, normalized.ZipCode, normalized.City, normalized.Country)
+			continue
+		}
+		// An empty update makes the service normalize and store the row.
+		if _, err := service.Update(ctx, a.ID, core.AddressUpdate{}); err != nil {
+			fmt.Fprintf(out, "%s\t%s\t-\tfix failed: %v\n", a.ID, a.UserID, err)
+			continue
+		}
+		fixed++
+	}
+	out.Flush()
+
+	fmt.Printf("\nchecked %d addresses: %d invalid, %d not normalized, %d fixed\n",
+		len(addresses), invalid, unnormalized, fixed)
+	if invalid > 0 {
+		os.Exit(1)
+	}
+}
+
+func loadAll(ctx context.Context, repo *db.AddressRepository) ([]core.Address, error) {
+	var all []core.Address
+	for page := 1; ; page++ {
+		addresses, total, err := repo.List(ctx, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, addresses...)
+		if len(addresses) < pageSize || int64(len(all)) >= total {
+			return all, nil
+		}
+	}
+}
+
+func sameAddress(a, b core.Address) bool {
+	return a.Type == b.Type && a.Street == b.Street && a.City == b.City &&
+		a.ZipCode == b.ZipCode && a.Country == b.Country
+}
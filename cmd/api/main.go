@@ -15,12 +15,16 @@ import (
 	"multi-processing-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"golang.org/x/exp/slog"
 )
 
 func main() {
 	cfg := configs.Load()
 
+	// Money is decimal end to end; keep it a JSON number on the wire.
+	decimal.MarshalJSONWithoutQuotes = true
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -34,40 +38,75 @@ func main() {
 		os.Exit(100)
 	}
 
+	addressRepo := db.NewAddressRepository(pool)
+	addressService := services.NewAddressService(addressRepo)
+	addressHandler := api.NewAddressHandler(addressService)
+
 	userRepo := db.NewUserRepository(pool)
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, addressRepo)
 	userHandler := api.NewUserHandler(userService)
 
 	departmentRepo := db.NewDepartmentRepository(pool)
-	departmentService := services.NewDepartmentService(departmentRepo)
+	departmentService := services.NewDepartmentService(departmentRepo, cfg.BaseCurrency)
 	departmentHandler := api.NewDepartmentHandler(departmentService)
 
+	exchangeRateRepo := db.NewExchangeRateRepository(pool)
+	exchangeRateService := services.NewExchangeRateService(exchangeRateRepo, cfg.ExchangeRates)
+	if loaded, err := exchangeRateService.Reload(ctx); err != nil {
+		slog.Warn("could not load exchange rates", "file", cfg.ExchangeRates, "error", err)
+	} else {
+		slog.Info("loaded exchange rates", "count", loaded)
+	}
+	exchangeRateHandler := api.NewExchangeRateHandler(exchangeRateService)
+
 	salaryRepo := db.NewSalaryRepository(pool)
-	salaryService := services.NewSalaryService(salaryRepo)
+	salaryService := services.NewSalaryService(salaryRepo, exchangeRateService)
 	salaryHandler := api.NewSalaryHandler(salaryService)
 
-	addressRepo := db.NewAddressRepository(pool)
-	addressService := services.NewAddressService(addressRepo)
-	addressHandler := api.NewAddressHandler(addressService)
+	officeRepo := db.NewOfficeRepository(pool)
+	officeService := services.NewOfficeService(officeRepo)
+	officeHandler := api.NewOfficeHandler(officeService)
 
 	skillRepo := db.NewSkillRepository(pool)
 	skillService := services.NewSkillService(skillRepo)
 	skillHandler := api.NewSkillHandler(skillService)
 
+	payrollRepo := db.NewPayrollRepository(pool)
+	payrollService := services.NewPayrollService(payrollRepo, exchangeRateService, cfg.BaseCurrency)
+	payrollHandler := api.NewPayrollHandler(payrollService)
+
+	leaveRepo := db.NewLeaveRepository(pool)
+	leaveService := services.NewLeaveService(leaveRepo)
+	leaveHandler := api.NewLeaveHandler(leaveService)
+
 	positionRepo := db.NewPositionRepository(pool)
-	positionService := services.NewPositionService(positionRepo)
+	positionService := services.NewPositionService(positionRepo, skillRepo)
 	positionHandler := api.NewPositionHandler(positionService)
 
+	auditRepo := db.NewAuditRepository(pool)
+	auditService := services.NewAuditService(auditRepo)
+	auditHandler := api.NewAuditHandler(auditService)
+
+	exportService := services.NewExportService(userRepo, salaryRepo, departmentRepo)
+	exportHandler := api.NewExportHandler(exportService)
+
 	cryptoRepo := db.NewCryptoRepository(pool)
 	cryptoRepo.SeedCryptosIfEmpty(ctx, "migrations/json/crypto/generated_cryptos.json")
 	cryptoService := services.NewCryptoService(cryptoRepo)
 
 	forumRepo := db.NewForumUserRepository(pool)
 	forumRepo.CreatePublicChannel(ctx)
-	forumService := services.NewForumUserService(forumRepo)
+	avatarStore := services.NewAvatarStore(cfg.AvatarDir, "/api/forum/avatars")
+	var mailer services.Mailer = services.NoMailer{}
+	if cfg.MailDropDir != "" {
+		mailer = services.NewFileMailer(cfg.MailDropDir)
+	}
+	forumService := services.NewForumUserService(forumRepo, cryptoRepo, avatarStore, mailer)
 	forumHandler := api.NewForumUserHandler(forumService)
 
 	go cryptoService.StartPriceTicker(ctx)
+	go forumService.StartReminderTicker(ctx)
+	go forumService.StartDepartmentChannelSync(ctx)
 
 	cryptoHandler := api.NewCryptoHandler(cryptoService)
 
@@ -77,6 +116,8 @@ func main() {
 		gin.Recovery(),
 		// api.LoggingMiddleware(slog.Default()),
 		api.CORSMiddleware(cfg.AllowedOrigins),
+		api.AuditMiddleware(cfg.ActorTokens),
+		api.SalaryAccessMiddleware(cfg.SalaryAccessToken),
 	)
 
 	v1 := router.Group("/api")
@@ -84,11 +125,18 @@ func main() {
 		api.RegisterUserRoutes(v1.Group("/user"), userHandler)
 		api.RegisterCryptoRoutes(v1.Group("/crypto"), cryptoHandler)
 		api.RegisterDepartmentRoutes(v1.Group("/department"), departmentHandler)
-		api.RegisterSalaryRoutes(v1.Group("/salary"), salaryHandler)
+		api.RegisterSalaryRoutes(v1.Group("/salary", api.RequireSalaryAccess()), salaryHandler)
 		api.RegisterSkillRoutes(v1.Group("/skill"), skillHandler)
 		api.RegisterPositionRoutes(v1.Group("/position"), positionHandler)
 		api.RegisterAddressRoutes(v1.Group("/address"), addressHandler)
+		api.RegisterOfficeRoutes(v1.Group("/office"), officeHandler)
+		api.RegisterPayrollRoutes(v1.Group("/payroll", api.RequireSalaryAccess()), payrollHandler)
+		api.RegisterLeaveRoutes(v1.Group("/leave"), leaveHandler)
+		api.RegisterAuditRoutes(v1.Group("/audit"), auditHandler)
+		api.RegisterExportRoutes(v1, exportHandler)
+		api.RegisterExchangeRateRoutes(v1.Group("/exchange-rate"), exchangeRateHandler)
 		api.RegisterForumUserRoutes(v1.Group("/forum"), forumHandler)
+		v1.Static("/forum/avatars", avatarStore.Dir())
 	}
 
 	srv := &http.Server{
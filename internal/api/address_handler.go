@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"multi-processing-backend/internal/core"
 	"net/http"
 	"strconv"
@@ -17,6 +18,7 @@ type AddressService interface {
 	Get(ctx context.Context, id string) (core.Address, error)
 	Update(ctx context.Context, id string, updates core.AddressUpdate) (core.Address, error)
 	Delete(ctx context.Context, id string) error
+	MakePrimary(ctx context.Context, id string) (core.Address, error)
 }
 
 type AddressHandler struct {
@@ -35,6 +37,7 @@ func RegisterAddressRoutes(rg *gin.RouterGroup, h *AddressHandler) {
 		pos.GET("/:id", h.Get)
 		pos.PATCH("/:id", h.Update)
 		pos.DELETE("/:id", h.Delete)
+		pos.POST("/:id/primary", h.MakePrimary)
 	}
 }
 
@@ -67,7 +70,7 @@ func (h *AddressHandler) Create(c *gin.Context) {
 
 	user, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeAddressError(c, err)
 		return
 	}
 
@@ -78,7 +81,7 @@ func (h *AddressHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 	user, err := h.service.Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "position not found"})
+		writeAddressError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, user)
@@ -93,11 +96,7 @@ func (h *AddressHandler) Update(c *gin.Context) {
 	}
 	updated, err := h.service.Update(c.Request.Context(), id, req)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "position not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeAddressError(c, err)
 		return
 	}
 
@@ -107,8 +106,32 @@ func (h *AddressHandler) Update(c *gin.Context) {
 func (h *AddressHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeAddressError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// MakePrimary makes the address the primary one of its user.
+func (h *AddressHandler) MakePrimary(c *gin.Context) {
+	updated, err := h.service.MakePrimary(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeAddressError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func writeAddressError(c *gin.Context, err error) {
+	var verr *core.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "fields": verr.Fields})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditService interface {
+	List(ctx context.Context, f core.AuditFilter, page, limit int) ([]core.AuditEntry, int64, error)
+}
+
+type AuditHandler struct {
+	service AuditService
+}
+
+func NewAuditHandler(service AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+func RegisterAuditRoutes(rg *gin.RouterGroup, h *AuditHandler) {
+	audit := rg.Group("")
+	{
+		audit.GET("", h.List)
+	}
+}
+
+// List returns audit entries, newest first. ?entity=, ?entity_id=, ?actor=,
+// ?action= and ?request_id= filter by exact match; ?from= and ?to= take a
+// date (YYYY-MM-DD, to is inclusive) or an RFC 3339 timestamp.
+func (h *AuditHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	f := core.AuditFilter{
+		Entity:    c.Query("entity"),
+		EntityID:  c.Query("entity_id"),
+		Actor:     c.Query("actor"),
+		Action:    c.Query("action"),
+		RequestID: c.Query("request_id"),
+	}
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if f.From, err = parseAuditTime(v, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2025-03-01 or an RFC 3339 timestamp"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if f.To, err = parseAuditTime(v, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2025-03-31 or an RFC 3339 timestamp"})
+			return
+		}
+	}
+
+	entries, total, err := h.service.List(c.Request.Context(), f, page, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "must") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, core.AuditPagination{Data: entries, Total: total})
+}
+
+// parseAuditTime reads a timestamp or a date. A date used as the end of a
+// range covers that whole day.
+func parseAuditTime(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
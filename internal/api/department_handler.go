@@ -16,6 +16,9 @@ type DepartmentService interface {
 	Get(ctx context.Context, id string) (core.Departments, error)
 	Update(ctx context.Context, id string, updates core.DepartmentUpdate) (core.Departments, error)
 	Delete(ctx context.Context, id string) error
+
+	Stats(ctx context.Context, departmentID, currency string) (core.DepartmentStats, error)
+	SkillGaps(ctx context.Context, departmentID string) (core.DepartmentSkillGapReport, error)
 }
 
 type DepartmentHandler struct {
@@ -31,6 +34,10 @@ func RegisterDepartmentRoutes(rg *gin.RouterGroup, h *DepartmentHandler) {
 	{
 		deps.GET("", h.List)
 		deps.POST("", h.Create)
+		deps.GET("/stats", h.Stats)
+		deps.GET("/:id/stats", h.Stats)
+		deps.GET("/skill-gaps", h.SkillGaps)
+		deps.GET("/:id/skill-gaps", h.SkillGaps)
 		deps.GET("/:id", h.Get)
 		deps.PATCH("/:id", h.Update)
 		deps.DELETE("/:id", h.Delete)
@@ -111,3 +118,38 @@ func (h *DepartmentHandler) Delete(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// Stats serves both the org-wide and the per-department analytics; the
+// former has no :id.
+func (h *DepartmentHandler) Stats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context(), c.Param("id"), c.Query("currency"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "must be"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// SkillGaps serves the org-wide and the per-department training needs,
+// like Stats.
+func (h *DepartmentHandler) SkillGaps(c *gin.Context) {
+	report, err := h.service.SkillGaps(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
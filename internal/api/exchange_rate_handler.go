@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"multi-processing-backend/internal/core"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateService interface {
+	List(ctx context.Context, date time.Time) ([]core.ExchangeRate, error)
+	Reload(ctx context.Context) (int, error)
+}
+
+type ExchangeRateHandler struct {
+	service ExchangeRateService
+}
+
+func NewExchangeRateHandler(service ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{service: service}
+}
+
+func RegisterExchangeRateRoutes(rg *gin.RouterGroup, h *ExchangeRateHandler) {
+	rates := rg.Group("")
+	{
+		rates.GET("", h.List)
+		rates.POST("/reload", h.Reload)
+	}
+}
+
+// List returns the rates in effect on ?date=YYYY-MM-DD, today by default.
+func (h *ExchangeRateHandler) List(c *gin.Context) {
+	date := time.Now()
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date query parameter must be YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	rates, err := h.service.List(c.Request.Context(), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"base": core.PivotCurrency, "date": date.Format(time.DateOnly), "rates": rates})
+}
+
+func (h *ExchangeRateHandler) Reload(c *gin.Context) {
+	loaded, err := h.service.Reload(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"loaded": loaded})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slog"
+)
+
+type ExportService interface {
+	ExportUsers(ctx context.Context, w io.Writer, opts core.ExportOptions, filter core.UserFilter) error
+	ExportSalaries(ctx context.Context, w io.Writer, opts core.ExportOptions) error
+	ExportDepartments(ctx context.Context, w io.Writer, opts core.ExportOptions, searchName string) error
+}
+
+type ExportHandler struct {
+	service ExportService
+}
+
+func NewExportHandler(service ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// RegisterExportRoutes registers the export endpoints next to the lists
+// they mirror, so rg is the API root rather than a group of its own.
+func RegisterExportRoutes(rg *gin.RouterGroup, h *ExportHandler) {
+	exports := rg.Group("")
+	{
+		exports.GET("/user/export", h.Users)
+		exports.GET("/salary/export", h.Salaries)
+		exports.GET("/department/export", h.Departments)
+	}
+}
+
+var exportContentTypes = map[string]string{
+	core.ExportCSV:   "text/csv; charset=utf-8",
+	core.ExportJSONL: "application/x-ndjson",
+	core.ExportXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Users exports the employees List returns for the same filters and sort.
+// ?format= is csv (default), jsonl or xlsx; ?columns= is a comma-separated
+// list of columns.
+func (h *ExportHandler) Users(c *gin.Context) {
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.export(c, "users", func(w io.Writer, opts core.ExportOptions) error {
+		return h.service.ExportUsers(c.Request.Context(), w, opts, filter)
+	})
+}
+
+// Salaries exports the full salary history. All amount columns need
+// elevated permission.
+func (h *ExportHandler) Salaries(c *gin.Context) {
+	h.export(c, "salaries", func(w io.Writer, opts core.ExportOptions) error {
+		return h.service.ExportSalaries(c.Request.Context(), w, opts)
+	})
+}
+
+// Departments exports the departments List returns for ?searchName=.
+func (h *ExportHandler) Departments(c *gin.Context) {
+	h.export(c, "departments", func(w io.Writer, opts core.ExportOptions) error {
+		return h.service.ExportDepartments(c.Request.Context(), w, opts, c.Query("searchName"))
+	})
+}
+
+func (h *ExportHandler) export(c *gin.Context, name string, run func(io.Writer, core.ExportOptions) error) {
+	opts := core.ExportOptions{Format: strings.ToLower(c.DefaultQuery("format", core.ExportCSV))}
+	for _, col := range strings.Split(c.Query("columns"), ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			opts.Columns = append(opts.Columns, col)
+		}
+	}
+
+	// Large exports outlast the server's write timeout.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Export | could not clear write deadline", "error", err)
+	}
+
+	w := &exportResponse{c: c, filename: name + "-" + time.Now().Format(time.DateOnly) + "." + opts.Format}
+	if err := run(w, opts); err != nil {
+		if w.started {
+			// The status line is gone, the file just ends early.
+			slog.Error("Export | failed after the response started", "export", name, "error", err)
+			c.Abort()
+			return
+		}
+		switch {
+		case errors.Is(err, core.ErrSalaryForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "must"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if !w.started {
+		w.start()
+	}
+}
+
+// exportResponse sends the download headers with the first write, so an
+// export that fails validation can still answer with a JSON error.
+type exportResponse struct {
+	c        *gin.Context
+	filename string
+	started  bool
+}
+
+func (w *exportResponse) start() {
+	w.started = true
+	ext := w.filename[strings.LastIndexByte(w.filename, '.')+1:]
+	w.c.Header("Content-Type", exportContentTypes[ext])
+	w.c.Header("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+	w.c.Status(http.StatusOK)
+	w.c.Writer.WriteHeaderNow()
+}
+
+func (w *exportResponse) Write(p []byte) (int, error) {
+	if !w.started {
+		w.start()
+	}
+	return w.c.Writer.Write(p)
+}
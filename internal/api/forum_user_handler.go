@@ -2,11 +2,17 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"multi-processing-backend/internal/core"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/exp/slog"
 )
@@ -25,15 +31,36 @@ type ForumUserService interface {
 	GetPublicChannelMessages(ctx context.Context, page, limit int) (*core.ForumChannelMessages, error)
 
 	GetOrCreateDirectMessageChannel(ctx context.Context, user1ID, user2ID string) (string, error)
-	GetOnlineUsers(ctx context.Context, userID string) ([]core.ForumUser, error)
-	SearchUsers(ctx context.Context, query string, currentUserID string) ([]core.ForumUser, error)
+	GetOnlineUsers(ctx context.Context, userID string) ([]core.ForumMember, error)
+	SearchUsers(ctx context.Context, query string, currentUserID string) ([]core.ForumMember, error)
 	GetUnreadCount(ctx context.Context, userID string) (map[string]int, error)
 	UpdateUserPresence(ctx context.Context, userID string, isOnline bool) error
-	GetChannelMembers(ctx context.Context, channelID string) ([]core.ForumUser, error)
+	GetChannelMembers(ctx context.Context, channelID string) ([]core.ForumMember, error)
 	EditMessage(ctx context.Context, messageID, userID, newContent string) error
 	DeleteMessage(ctx context.Context, messageID, userID string) error
 	AddReaction(ctx context.Context, messageID, userID, emoji string) error
 	RemoveReaction(ctx context.Context, messageID, userID, emoji string) error
+
+	ListCommands() []core.ForumCommandSpec
+
+	RenameChannel(ctx context.Context, channelID, userID, name string) (core.ForumChannel, error)
+	PinMessage(ctx context.Context, messageID, userID string) error
+	UnpinMessage(ctx context.Context, messageID, userID string) error
+	GetChannelPins(ctx context.Context, channelID, userID string) ([]core.PinnedMessage, error)
+	SubscribeEvents(ctx context.Context, userID, lastEventID string) (*core.ForumEventSubscription, error)
+
+	GetProfile(ctx context.Context, userID string) (core.ForumUserProfile, error)
+	UpdateProfile(ctx context.Context, userID string, update core.ForumUserProfileUpdate) (core.ForumUserProfile, error)
+	SetStatus(ctx context.Context, userID string, update core.ForumUserStatusUpdate) (core.ForumUserProfile, error)
+	ClearStatus(ctx context.Context, userID string) error
+	UploadAvatar(ctx context.Context, userID string, r io.Reader) (core.ForumUserProfile, error)
+
+	RequestEmailVerification(ctx context.Context, userID string) error
+	ConfirmEmail(ctx context.Context, userID, code string) (core.ForumUserProfile, error)
+	LinkHRUser(ctx context.Context, userID string) (core.ForumUserProfile, error)
+	UnlinkHRUser(ctx context.Context, userID string) error
+
+	ReconcileDepartmentChannels(ctx context.Context) (core.DepartmentChannelSync, error)
 }
 
 type ForumUserHandler struct {
@@ -45,11 +72,15 @@ func NewForumUserHandler(service ForumUserService) *ForumUserHandler {
 }
 
 func RegisterForumUserRoutes(rg *gin.RouterGroup, h *ForumUserHandler) {
+	rg.GET("/commands", h.ListCommands)
+
 	users := rg.Group("/users")
 	{
 		users.GET("/search", h.SearchUsers)
 		users.GET("/online", h.GetOnlineUsers)
 		users.GET("/:id", h.GetByID)
+		users.GET("/:id/events", h.StreamEvents)
+		users.GET("/:id/profile", h.GetProfile)
 		users.GET("/email", h.GetByEmail)
 
 		users.POST("/login", h.RegisterOrLogin)
@@ -57,6 +88,14 @@ func RegisterForumUserRoutes(rg *gin.RouterGroup, h *ForumUserHandler) {
 
 		users.PATCH("/:id", h.Update)
 		users.PATCH("/:id/presence", h.UpdateUserPresence)
+		users.PATCH("/:id/profile", h.UpdateProfile)
+		users.PUT("/:id/status", h.SetStatus)
+		users.DELETE("/:id/status", h.ClearStatus)
+		users.POST("/:id/avatar", h.UploadAvatar)
+		users.POST("/:id/verify-email", h.RequestEmailVerification)
+		users.POST("/:id/verify-email/confirm", h.ConfirmEmail)
+		users.POST("/:id/hr-link", h.LinkHRUser)
+		users.DELETE("/:id/hr-link", h.UnlinkHRUser)
 	}
 
 	channels := rg.Group("channels")
@@ -65,11 +104,14 @@ func RegisterForumUserRoutes(rg *gin.RouterGroup, h *ForumUserHandler) {
 		channels.GET("/:id/members", h.GetChannelMembers)
 		channels.GET("/:id/messages", h.GetChannelMessages)
 		channels.GET("/:id/unread", h.GetUnreadCount)
+		channels.GET("/:id/pins", h.GetChannelPins)
 		channels.GET("/user/:userID", h.GetUserChannels)
 		channels.GET("/direct", h.GetOrCreateDirectMessageChannel)
 
 		channels.POST("/:id/messages", h.CreateMessage)
 		channels.PATCH("/:id/read", h.MarkMessagesAsRead)
+		channels.PATCH("/:id", h.RenameChannel)
+		channels.POST("/departments/sync", h.ReconcileDepartmentChannels)
 	}
 
 	messages := rg.Group("/messages")
@@ -78,6 +120,8 @@ func RegisterForumUserRoutes(rg *gin.RouterGroup, h *ForumUserHandler) {
 		messages.DELETE("/:id", h.DeleteMessage)
 		messages.POST("/:id/reactions", h.AddReaction)
 		messages.DELETE("/:id/reactions", h.RemoveReaction)
+		messages.POST("/:id/pin", h.PinMessage)
+		messages.DELETE("/:id/pin", h.UnpinMessage)
 	}
 }
 
@@ -159,6 +203,10 @@ func (h *ForumUserHandler) RegisterOrLogin(c *gin.Context) {
 
 	user, err := h.service.RegisterOrLogin(c.Request.Context(), req.Username, req.Email)
 	if err != nil {
+		if strings.Contains(err.Error(), "deactivated") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -337,7 +385,7 @@ func (h *ForumUserHandler) UpdateUserPresence(c *gin.Context) {
 }
 
 func (h *ForumUserHandler) GetChannelMembers(c *gin.Context) {
-	channelID := c.Param("channelID")
+	channelID := c.Param("id")
 
 	users, err := h.service.GetChannelMembers(c.Request.Context(), channelID)
 	if err != nil {
@@ -424,3 +472,305 @@ func (h *ForumUserHandler) RemoveReaction(c *gin.Context) {
 
 	c.JSON(http.StatusAccepted, err)
 }
+
+func (h *ForumUserHandler) ListCommands(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ListCommands())
+}
+
+func (h *ForumUserHandler) RenameChannel(c *gin.Context) {
+	channelID := c.Param("id")
+	var req struct {
+		UserID string `json:"userId"`
+		Name   string `json:"name"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	channel, err := h.service.RenameChannel(c.Request.Context(), channelID, req.UserID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+func (h *ForumUserHandler) GetChannelPins(c *gin.Context) {
+	channelID := c.Param("id")
+	userID := c.Query("userID")
+
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userID query parameter required"})
+		return
+	}
+
+	pins, err := h.service.GetChannelPins(c.Request.Context(), channelID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "access denied") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pins)
+}
+
+func (h *ForumUserHandler) PinMessage(c *gin.Context) {
+	messageID := c.Param("id")
+	var req struct {
+		UserID string `json:"userId"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := h.service.PinMessage(c.Request.Context(), messageID, req.UserID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "message pinned"})
+}
+
+func (h *ForumUserHandler) UnpinMessage(c *gin.Context) {
+	messageID := c.Param("id")
+	userID := c.Query("userId")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId query parameter required"})
+		return
+	}
+
+	if err := h.service.UnpinMessage(c.Request.Context(), messageID, userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "message unpinned"})
+}
+
+func (h *ForumUserHandler) StreamEvents(c *gin.Context) {
+	userID := c.Param("id")
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	ctx := c.Request.Context()
+	sub, err := h.service.SubscribeEvents(ctx, userID, lastEventID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer sub.Close()
+
+	// The server-wide WriteTimeout would otherwise cut the stream.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("ForumHandler | StreamEvents | could not clear write deadline", "error", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for i, event := range sub.Replay {
+		ev := sse.Event{Id: event.ID, Event: event.Type, Data: event.Data}
+		if i == 0 {
+			ev.Retry = 3000
+		}
+		c.Render(-1, ev)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			c.Render(-1, sse.Event{Id: event.ID, Event: event.Type, Data: event.Data})
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func (h *ForumUserHandler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ForumUserHandler) UpdateProfile(c *gin.Context) {
+	var req core.ForumUserProfileUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ForumUserHandler) SetStatus(c *gin.Context) {
+	var req core.ForumUserStatusUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.service.SetStatus(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ForumUserHandler) ClearStatus(c *gin.Context) {
+	if err := h.service.ClearStatus(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ForumUserHandler) UploadAvatar(c *gin.Context) {
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar file required"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	profile, err := h.service.UploadAvatar(c.Request.Context(), c.Param("id"), f)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ForumUserHandler) RequestEmailVerification(c *gin.Context) {
+	if err := h.service.RequestEmailVerification(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, core.ErrMailUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification code sent"})
+}
+
+func (h *ForumUserHandler) ConfirmEmail(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.service.ConfirmEmail(c.Request.Context(), c.Param("id"), req.Code)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "too many") {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ForumUserHandler) LinkHRUser(c *gin.Context) {
+	profile, err := h.service.LinkHRUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not verified"), strings.Contains(err.Error(), "deactivated"):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ForumUserHandler) UnlinkHRUser(c *gin.Context) {
+	if err := h.service.UnlinkHRUser(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ForumUserHandler) ReconcileDepartmentChannels(c *gin.Context) {
+	result, err := h.service.ReconcileDepartmentChannels(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaveService interface {
+	ListTypes(ctx context.Context) ([]core.LeaveType, error)
+	CreateRequest(ctx context.Context, in core.LeaveRequestCreate) (core.LeaveRequest, error)
+	GetRequest(ctx context.Context, id string) (core.LeaveRequest, error)
+	ListRequests(ctx context.Context, userID, status string, page, limit int) ([]core.LeaveRequest, int64, error)
+	Approve(ctx context.Context, id string, decision core.LeaveDecision) (core.LeaveRequest, error)
+	Reject(ctx context.Context, id string, decision core.LeaveDecision) (core.LeaveRequest, error)
+	Cancel(ctx context.Context, id string, decision core.LeaveDecision) (core.LeaveRequest, error)
+	SetEntitlement(ctx context.Context, userID, typeCode string, in core.LeaveEntitlementUpdate) (core.LeaveBalances, error)
+	Balances(ctx context.Context, userID string, year int) (core.LeaveBalances, error)
+	Calendar(ctx context.Context, departmentID string, from, to time.Time, includePending bool) (core.AbsenceCalendar, error)
+}
+
+type LeaveHandler struct {
+	service LeaveService
+}
+
+func NewLeaveHandler(service LeaveService) *LeaveHandler {
+	return &LeaveHandler{service: service}
+}
+
+func RegisterLeaveRoutes(rg *gin.RouterGroup, h *LeaveHandler) {
+	leave := rg.Group("")
+	{
+		leave.GET("/types", h.ListTypes)
+		leave.GET("/requests", h.ListRequests)
+		leave.POST("/requests", h.CreateRequest)
+		leave.GET("/requests/:id", h.GetRequest)
+		leave.POST("/requests/:id/approve", h.Approve)
+		leave.POST("/requests/:id/reject", h.Reject)
+		leave.POST("/requests/:id/cancel", h.Cancel)
+		leave.GET("/balance/:user_id", h.Balances)
+		leave.PUT("/entitlement/:user_id/:type", h.SetEntitlement)
+		leave.GET("/calendar/:department_id", h.Calendar)
+	}
+}
+
+func (h *LeaveHandler) ListTypes(c *gin.Context) {
+	types, err := h.service.ListTypes(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, types)
+}
+
+func (h *LeaveHandler) ListRequests(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	requests, total, err := h.service.ListRequests(c.Request.Context(), c.Query("user_id"), c.Query("status"), page, limit)
+	if err != nil {
+		writeLeaveError(c, err)
+		return
+	}
+
+	response := core.LeaveRequestPagination{
+		Data:  requests,
+		Total: total,
+		Error: nil,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *LeaveHandler) CreateRequest(c *gin.Context) {
+	var req core.LeaveRequestCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.CreateRequest(c.Request.Context(), req)
+	if err != nil {
+		writeLeaveError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *LeaveHandler) GetRequest(c *gin.Context) {
+	req, err := h.service.GetRequest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeLeaveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+func (h *LeaveHandler) Approve(c *gin.Context) {
+	h.decide(c, h.service.Approve)
+}
+
+func (h *LeaveHandler) Reject(c *gin.Context) {
+	h.decide(c, h.service.Reject)
+}
+
+func (h *LeaveHandler) Cancel(c *gin.Context) {
+	h.decide(c, h.service.Cancel)
+}
+
+// decide runs a workflow step. The decision body is optional.
+func (h *LeaveHandler) decide(
+	c *gin.Context,
+	step func(ctx context.Context, id string, decision core.LeaveDecision) (core.LeaveRequest, error),
+) {
+	var decision core.LeaveDecision
+	if err := c.ShouldBindJSON(&decision); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req, err := step(c.Request.Context(), c.Param("id"), decision)
+	if err != nil {
+		writeLeaveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+func (h *LeaveHandler) Balances(c *gin.Context) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a number"})
+		return
+	}
+
+	balances, err := h.service.Balances(c.Request.Context(), c.Param("user_id"), year)
+	if err != nil {
+		writeLeaveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, balances)
+}
+
+func (h *LeaveHandler) SetEntitlement(c *gin.Context) {
+	var req core.LeaveEntitlementUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	balances, err := h.service.SetEntitlement(c.Request.Context(), c.Param("user_id"), c.Param("type"), req)
+	if err != nil {
+		writeLeaveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, balances)
+}
+
+// Calendar lists approved absences in a department between ?from= and
+// ?to= (YYYY-MM-DD), the current month by default. ?pending=true adds
+// pending requests.
+func (h *LeaveHandler) Calendar(c *gin.Context) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2025-03-01"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2025-03-31"})
+			return
+		}
+	}
+	includePending, _ := strconv.ParseBool(c.DefaultQuery("pending", "false"))
+
+	calendar, err := h.service.Calendar(c.Request.Context(), c.Param("department_id"), from, to, includePending)
+	if err != nil {
+		writeLeaveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, calendar)
+}
+
+func writeLeaveError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrLeaveRequestState), errors.Is(err, core.ErrLeaveOverlap),
+		errors.Is(err, core.ErrEmploymentState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, core.ErrLeaveBalance):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
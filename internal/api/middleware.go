@@ -1,9 +1,15 @@
 package api
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"net/http"
+	"strings"
 	"time"
 
+	"multi-processing-backend/internal/core"
+
 	"github.com/gin-gonic/gin"
 	"golang.org/x/exp/slog"
 )
@@ -58,7 +64,8 @@ func CORSMiddleware(origins []string) gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Credentials", "true")
 			c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Origin,Content-Type,Authorization,Accept,X-Requested-With")
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID")
+			c.Header("Access-Control-Allow-Headers", "Origin,Content-Type,Authorization,Accept,X-Requested-With,X-Request-ID,X-Salary-Access-Token")
 		}
 
 		if c.Request.Method == "OPTIONS" {
@@ -68,4 +75,69 @@ func CORSMiddleware(origins []string) gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// AuditMiddleware puts the caller and request ID on the request context so
+// that database changes made while handling it are attributed in the audit
+// log. The caller is the actor whose token, from actorTokens (actor to
+// token), comes as "Authorization: Bearer <token>"; requests without one
+// are recorded as anonymous and a wrong token is rejected. A missing
+// X-Request-ID is generated and echoed back.
+func AuditMiddleware(actorTokens map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := "anonymous"
+		if given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			actor = ""
+			for name, token := range actorTokens {
+				if token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+					actor = name
+				}
+			}
+			if actor == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unknown actor token"})
+				return
+			}
+		}
+		requestID := strings.TrimSpace(c.GetHeader("X-Request-ID"))
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		c.Header("X-Request-ID", requestID)
+
+		ctx := core.WithAuditInfo(c.Request.Context(), core.AuditInfo{Actor: actor, RequestID: requestID})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// SalaryAccessMiddleware lets a request see salary data when it carries
+// the server's token in X-Salary-Access-Token. With an empty token nobody
+// gets access.
+func SalaryAccessMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader("X-Salary-Access-Token")
+		if token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			c.Request = c.Request.WithContext(core.WithSalaryAccess(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// RequireSalaryAccess rejects requests without salary access, see
+// SalaryAccessMiddleware. It guards route groups that only deal in pay.
+func RequireSalaryAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !core.HasSalaryAccess(c.Request.Context()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": core.ErrSalaryForbidden.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
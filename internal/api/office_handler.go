@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OfficeService interface {
+	List(ctx context.Context) ([]core.Office, error)
+	Create(ctx context.Context, o core.Office) (core.Office, error)
+	Get(ctx context.Context, id string) (core.Office, error)
+	Update(ctx context.Context, id string, update core.OfficeUpdate) (core.Office, error)
+	Delete(ctx context.Context, id string) error
+
+	GetWorkplace(ctx context.Context, userID string) (core.Workplace, error)
+	SetWorkplace(ctx context.Context, userID string, in core.WorkplaceUpdate) (core.Workplace, error)
+	Headcount(ctx context.Context) ([]core.OfficeHeadcount, error)
+	OfficeHeadcount(ctx context.Context, officeID string) (core.OfficeHeadcount, error)
+	Members(ctx context.Context, officeID, day string) ([]core.OfficeMember, error)
+}
+
+type OfficeHandler struct {
+	service OfficeService
+}
+
+func NewOfficeHandler(service OfficeService) *OfficeHandler {
+	return &OfficeHandler{service: service}
+}
+
+func RegisterOfficeRoutes(rg *gin.RouterGroup, h *OfficeHandler) {
+	offices := rg.Group("")
+	{
+		offices.GET("", h.List)
+		offices.POST("", h.Create)
+		offices.GET("/headcount", h.Headcount)
+		offices.GET("/workplace/:user_id", h.GetWorkplace)
+		offices.PUT("/workplace/:user_id", h.SetWorkplace)
+		offices.GET("/:id", h.Get)
+		offices.PATCH("/:id", h.Update)
+		offices.DELETE("/:id", h.Delete)
+		offices.GET("/:id/headcount", h.OfficeHeadcount)
+		offices.GET("/:id/people", h.Members)
+	}
+}
+
+func (h *OfficeHandler) List(c *gin.Context) {
+	offices, err := h.service.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, offices)
+}
+
+func (h *OfficeHandler) Create(c *gin.Context) {
+	var req core.Office
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *OfficeHandler) Get(c *gin.Context) {
+	office, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, office)
+}
+
+func (h *OfficeHandler) Update(c *gin.Context) {
+	var req core.OfficeUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.service.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *OfficeHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OfficeHandler) GetWorkplace(c *gin.Context) {
+	workplace, err := h.service.GetWorkplace(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, workplace)
+}
+
+func (h *OfficeHandler) SetWorkplace(c *gin.Context) {
+	var req core.WorkplaceUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workplace, err := h.service.SetWorkplace(c.Request.Context(), c.Param("user_id"), req)
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, workplace)
+}
+
+func (h *OfficeHandler) Headcount(c *gin.Context) {
+	counts, err := h.service.Headcount(c.Request.Context())
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, counts)
+}
+
+func (h *OfficeHandler) OfficeHeadcount(c *gin.Context) {
+	count, err := h.service.OfficeHeadcount(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, count)
+}
+
+// Members lists who works at an office. ?day=mon narrows it down to the
+// people expected in on that weekday.
+func (h *OfficeHandler) Members(c *gin.Context) {
+	members, err := h.service.Members(c.Request.Context(), c.Param("id"), c.Query("day"))
+	if err != nil {
+		writeOfficeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+func writeOfficeError(c *gin.Context, err error) {
+	var verr *core.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "fields": verr.Fields})
+	case errors.Is(err, core.ErrOfficeExists), errors.Is(err, core.ErrOfficeInUse),
+		errors.Is(err, core.ErrEmploymentState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
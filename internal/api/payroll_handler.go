@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"multi-processing-backend/internal/core"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollService interface {
+	CreateRun(ctx context.Context, req core.PayrollRunCreate) (core.PayrollRun, error)
+	Recalculate(ctx context.Context, id string) (core.PayrollRun, error)
+	ListRuns(ctx context.Context, page, limit int) ([]core.PayrollRun, int64, error)
+	GetRun(ctx context.Context, id string) (core.PayrollRun, error)
+	Approve(ctx context.Context, id string) (core.PayrollRun, error)
+	Lock(ctx context.Context, id string) (core.PayrollRun, error)
+	DeleteRun(ctx context.Context, id string) error
+}
+
+type PayrollHandler struct {
+	service PayrollService
+}
+
+func NewPayrollHandler(service PayrollService) *PayrollHandler {
+	return &PayrollHandler{service: service}
+}
+
+func RegisterPayrollRoutes(rg *gin.RouterGroup, h *PayrollHandler) {
+	payroll := rg.Group("")
+	{
+		payroll.GET("", h.List)
+		payroll.POST("", h.Create)
+		payroll.GET("/:id", h.Get)
+		payroll.POST("/:id/recalculate", h.Recalculate)
+		payroll.POST("/:id/approve", h.Approve)
+		payroll.POST("/:id/lock", h.Lock)
+		payroll.DELETE("/:id", h.Delete)
+	}
+}
+
+func (h *PayrollHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	runs, total, err := h.service.ListRuns(c.Request.Context(), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := core.PayrollRunPagination{
+		Data:  runs,
+		Total: total,
+		Error: nil,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *PayrollHandler) Create(c *gin.Context) {
+	var req core.PayrollRunCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	run, err := h.service.CreateRun(c.Request.Context(), req)
+	if err != nil {
+		writePayrollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, run)
+}
+
+func (h *PayrollHandler) Get(c *gin.Context) {
+	run, err := h.service.GetRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePayrollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+func (h *PayrollHandler) Recalculate(c *gin.Context) {
+	run, err := h.service.Recalculate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePayrollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+func (h *PayrollHandler) Approve(c *gin.Context) {
+	run, err := h.service.Approve(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePayrollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+func (h *PayrollHandler) Lock(c *gin.Context) {
+	run, err := h.service.Lock(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePayrollError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+func (h *PayrollHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteRun(c.Request.Context(), c.Param("id")); err != nil {
+		writePayrollError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writePayrollError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrPayrollRunState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, core.ErrNoExchangeRate):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
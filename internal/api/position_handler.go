@@ -4,6 +4,7 @@ import (
 	"context"
 	"multi-processing-backend/internal/core"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +17,15 @@ type PositionService interface {
 	Get(ctx context.Context, id string) (core.Position, error)
 	Update(ctx context.Context, id string, updates core.PositionUpdate) (core.Position, error)
 	Delete(ctx context.Context, id string) error
+
+	ListBands(ctx context.Context, positionID string) ([]core.SalaryBand, error)
+	UpsertBand(ctx context.Context, positionID string, level int, band core.SalaryBandUpsert) (core.SalaryBand, error)
+	DeleteBand(ctx context.Context, positionID string, level int) error
+
+	ListSkills(ctx context.Context, positionID string) ([]core.PositionSkill, error)
+	UpsertSkill(ctx context.Context, positionID, skillID string, in core.PositionSkillUpsert) (core.PositionSkill, error)
+	DeleteSkill(ctx context.Context, positionID, skillID string) error
+	SkillGaps(ctx context.Context, userID, positionID string) (core.SkillGapReport, error)
 }
 
 type PositionHandler struct {
@@ -34,6 +44,13 @@ func RegisterPositionRoutes(rg *gin.RouterGroup, h *PositionHandler) {
 		pos.GET("/:id", h.Get)
 		pos.PATCH("/:id", h.Update)
 		pos.DELETE("/:id", h.Delete)
+		pos.GET("/:id/bands", h.ListBands)
+		pos.PUT("/:id/bands/:level", h.UpsertBand)
+		pos.DELETE("/:id/bands/:level", h.DeleteBand)
+		pos.GET("/:id/skills", h.ListSkills)
+		pos.PUT("/:id/skills/:skill_id", h.UpsertSkill)
+		pos.DELETE("/:id/skills/:skill_id", h.DeleteSkill)
+		pos.GET("/gaps/user/:user_id", h.SkillGaps)
 	}
 }
 
@@ -110,3 +127,103 @@ func (h *PositionHandler) Delete(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *PositionHandler) ListBands(c *gin.Context) {
+	bands, err := h.service.ListBands(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePositionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, bands)
+}
+
+func (h *PositionHandler) UpsertBand(c *gin.Context) {
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "level must be a number"})
+		return
+	}
+
+	var req core.SalaryBandUpsert
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	band, err := h.service.UpsertBand(c.Request.Context(), c.Param("id"), level, req)
+	if err != nil {
+		writePositionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, band)
+}
+
+func (h *PositionHandler) DeleteBand(c *gin.Context) {
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "level must be a number"})
+		return
+	}
+
+	if err := h.service.DeleteBand(c.Request.Context(), c.Param("id"), level); err != nil {
+		writePositionError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// writePositionError maps errors of the salary band and skill requirement
+// endpoints of a position to a status.
+func writePositionError(c *gin.Context, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *PositionHandler) ListSkills(c *gin.Context) {
+	skills, err := h.service.ListSkills(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePositionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, skills)
+}
+
+func (h *PositionHandler) UpsertSkill(c *gin.Context) {
+	var req core.PositionSkillUpsert
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	skill, err := h.service.UpsertSkill(c.Request.Context(), c.Param("id"), c.Param("skill_id"), req)
+	if err != nil {
+		writePositionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, skill)
+}
+
+func (h *PositionHandler) DeleteSkill(c *gin.Context) {
+	if err := h.service.DeleteSkill(c.Request.Context(), c.Param("id"), c.Param("skill_id")); err != nil {
+		writePositionError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SkillGaps compares a user with their current position, or with the
+// position given as ?position_id=.
+func (h *PositionHandler) SkillGaps(c *gin.Context) {
+	report, err := h.service.SkillGaps(c.Request.Context(), c.Param("user_id"), c.Query("position_id"))
+	if err != nil {
+		writePositionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
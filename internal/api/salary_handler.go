@@ -2,21 +2,30 @@ package api
 
 import (
 	"context"
+	"errors"
 	"multi-processing-backend/internal/core"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type SalaryService interface {
 	List(ctx context.Context, page, limit int) ([]core.Salary, int64, error)
-	Create(ctx context.Context, user core.Salary) (core.Salary, error)
+	Create(ctx context.Context, user core.Salary, allowOutOfBand bool) (core.Salary, error)
 
 	Get(ctx context.Context, id string) (core.Salary, error)
-	Update(ctx context.Context, id string, updates core.SalaryUpdate) (core.Salary, error)
+	Update(ctx context.Context, id string, updates core.SalaryUpdate, allowOutOfBand bool) (core.Salary, error)
 	Delete(ctx context.Context, id string) error
+
+	CompaRatios(ctx context.Context) ([]core.CompaRatio, error)
+	OutOfBand(ctx context.Context) ([]core.CompaRatio, error)
+
+	Timeline(ctx context.Context, userID, currency string) (core.SalaryTimeline, error)
+	Current(ctx context.Context, userID, currency string) (core.Salary, error)
+	AsOf(ctx context.Context, userID string, date time.Time, currency string) (core.Salary, error)
 }
 
 type SalaryHandler struct {
@@ -32,6 +41,11 @@ func RegisterSalaryRoutes(rg *gin.RouterGroup, h *SalaryHandler) {
 	{
 		salary.GET("", h.List)
 		salary.POST("", h.Create)
+		salary.GET("/compa-ratio", h.CompaRatios)
+		salary.GET("/out-of-band", h.OutOfBand)
+		salary.GET("/user/:userId/timeline", h.Timeline)
+		salary.GET("/user/:userId/current", h.Current)
+		salary.GET("/user/:userId/as-of", h.AsOf)
 		salary.GET("/:id", h.Get)
 		salary.PATCH("/:id", h.Update)
 		salary.DELETE("/:id", h.Delete)
@@ -65,9 +79,9 @@ func (h *SalaryHandler) Create(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.Create(c.Request.Context(), req)
+	user, err := h.service.Create(c.Request.Context(), req, c.Query("allow_out_of_band") == "true")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeSalaryError(c, err)
 		return
 	}
 
@@ -93,13 +107,9 @@ func (h *SalaryHandler) Update(c *gin.Context) {
 		return
 	}
 
-	updated, err := h.service.Update(c.Request.Context(), id, req)
+	updated, err := h.service.Update(c.Request.Context(), id, req, c.Query("allow_out_of_band") == "true")
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "salary not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeSalaryError(c, err)
 		return
 	}
 
@@ -114,3 +124,74 @@ func (h *SalaryHandler) Delete(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *SalaryHandler) CompaRatios(c *gin.Context) {
+	ratios, err := h.service.CompaRatios(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ratios)
+}
+
+func (h *SalaryHandler) OutOfBand(c *gin.Context) {
+	ratios, err := h.service.OutOfBand(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ratios)
+}
+
+func (h *SalaryHandler) Timeline(c *gin.Context) {
+	timeline, err := h.service.Timeline(c.Request.Context(), c.Param("userId"), c.Query("currency"))
+	if err != nil {
+		writeSalaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
+
+func (h *SalaryHandler) Current(c *gin.Context) {
+	salary, err := h.service.Current(c.Request.Context(), c.Param("userId"), c.Query("currency"))
+	if err != nil {
+		writeSalaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, salary)
+}
+
+func (h *SalaryHandler) AsOf(c *gin.Context) {
+	date, err := time.Parse(time.DateOnly, c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date query parameter must be YYYY-MM-DD"})
+		return
+	}
+
+	salary, err := h.service.AsOf(c.Request.Context(), c.Param("userId"), date, c.Query("currency"))
+	if err != nil {
+		writeSalaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, salary)
+}
+
+func writeSalaryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrDuplicateSalary):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, core.ErrNoExchangeRate), errors.Is(err, core.ErrSalaryOutOfBand):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must be"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,8 +2,11 @@ package api
 
 import (
 	"context"
+	"errors"
+	"io"
 	"multi-processing-backend/internal/core"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +15,19 @@ import (
 type SkillService interface {
 	List(ctx context.Context) ([]core.Skill, int64, error)
 	Create(ctx context.Context, user core.Skill) (core.Skill, error)
-	AddSkillByUserId(ctx context.Context, skill_id, user_id string) error
+	AddSkillByUserId(ctx context.Context, skill_id, user_id string, input core.UserSkillUpdate) error
+	UpdateUserSkill(ctx context.Context, skillID, userID string, update core.UserSkillUpdate) (core.SkillWithDetails, error)
+	Search(ctx context.Context, query string, departmentIDs []string, limit int) ([]core.SkillSearchResult, error)
+
+	ListAliases(ctx context.Context, skillID string) ([]core.SkillAlias, error)
+	AddAlias(ctx context.Context, skillID, alias string) (core.SkillAlias, error)
+	DeleteAlias(ctx context.Context, skillID, alias string) error
+	Merge(ctx context.Context, targetID string, sourceIDs []string) (core.SkillMergeResult, error)
+
+	CategoryTree(ctx context.Context) ([]*core.SkillCategory, error)
+	CreateCategory(ctx context.Context, in core.SkillCategoryCreate) (core.SkillCategory, error)
+	UpdateCategory(ctx context.Context, id string, update core.SkillCategoryUpdate) (core.SkillCategory, error)
+	DeleteCategory(ctx context.Context, id string) error
 	Get(ctx context.Context, id string) (core.Skill, error)
 	GetByUserId(ctx context.Context, id string) ([]core.SkillWithDetails, error)
 	Update(ctx context.Context, id string, updates core.SkillUpdate) (core.Skill, error)
@@ -32,6 +47,15 @@ func RegisterSkillRoutes(rg *gin.RouterGroup, h *SkillHandler) {
 	skill := rg.Group("")
 	{
 		skill.GET("", h.List)
+		skill.GET("/search", h.Search)
+		skill.GET("/categories", h.CategoryTree)
+		skill.POST("/categories", h.CreateCategory)
+		skill.PATCH("/categories/:id", h.UpdateCategory)
+		skill.DELETE("/categories/:id", h.DeleteCategory)
+		skill.GET("/:id/aliases", h.ListAliases)
+		skill.POST("/:id/aliases", h.AddAlias)
+		skill.DELETE("/:id/aliases/:alias", h.DeleteAlias)
+		skill.POST("/:id/merge", h.Merge)
 		skill.GET("/:id", h.Get)
 		skill.GET("/user/:id", h.GetByUserId)
 
@@ -39,6 +63,7 @@ func RegisterSkillRoutes(rg *gin.RouterGroup, h *SkillHandler) {
 		skill.POST("/add/:user_id/skill/:skill_id", h.AddSkillByUserId)
 
 		skill.PATCH("/:id", h.Update)
+		skill.PATCH("/user/:user_id/skill/:skill_id", h.UpdateUserSkill)
 
 		skill.DELETE("/:id", h.Delete)
 		skill.DELETE("/delete/:user_id/skill/:skill_id", h.DeleteSkillByUserId)
@@ -71,7 +96,7 @@ func (h *SkillHandler) Create(c *gin.Context) {
 
 	skill, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeSkillError(c, err)
 		return
 	}
 
@@ -82,7 +107,14 @@ func (h *SkillHandler) AddSkillByUserId(c *gin.Context) {
 	skill_id := c.Param("skill_id")
 	user_id := c.Param("user_id")
 
-	if err := h.service.AddSkillByUserId(c.Request.Context(), skill_id, user_id); err != nil {
+	// The body is optional; without one the skill is added at level 1.
+	var req core.UserSkillUpdate
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.AddSkillByUserId(c.Request.Context(), skill_id, user_id, req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -121,11 +153,7 @@ func (h *SkillHandler) Update(c *gin.Context) {
 
 	updated, err := h.service.Update(c.Request.Context(), id, req)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "skill not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeSkillError(c, err)
 		return
 	}
 
@@ -152,3 +180,154 @@ func (h *SkillHandler) DeleteSkillByUserId(c *gin.Context) {
 
 	c.Status(http.StatusAccepted)
 }
+
+func (h *SkillHandler) UpdateUserSkill(c *gin.Context) {
+	var req core.UserSkillUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	skill, err := h.service.UpdateUserSkill(c.Request.Context(), c.Param("skill_id"), c.Param("user_id"), req)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "must be"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, skill)
+}
+
+// Search takes the skill query in ?q= and any number of ?department_id=
+// filters.
+func (h *SkillHandler) Search(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	results, err := h.service.Search(c.Request.Context(), c.Query("q"), c.QueryArray("department_id"), limit)
+	if err != nil {
+		if errors.Is(err, core.ErrInvalidSkillQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func (h *SkillHandler) ListAliases(c *gin.Context) {
+	aliases, err := h.service.ListAliases(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeSkillError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, aliases)
+}
+
+func (h *SkillHandler) AddAlias(c *gin.Context) {
+	var req struct {
+		Alias string `json:"alias" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias, err := h.service.AddAlias(c.Request.Context(), c.Param("id"), req.Alias)
+	if err != nil {
+		writeSkillError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, alias)
+}
+
+func (h *SkillHandler) DeleteAlias(c *gin.Context) {
+	if err := h.service.DeleteAlias(c.Request.Context(), c.Param("id"), c.Param("alias")); err != nil {
+		writeSkillError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SkillHandler) Merge(c *gin.Context) {
+	var req core.SkillMerge
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Merge(c.Request.Context(), c.Param("id"), req.SourceIDs)
+	if err != nil {
+		writeSkillError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *SkillHandler) CategoryTree(c *gin.Context) {
+	tree, err := h.service.CategoryTree(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+func (h *SkillHandler) CreateCategory(c *gin.Context) {
+	var req core.SkillCategoryCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.CreateCategory(c.Request.Context(), req)
+	if err != nil {
+		writeSkillError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, category)
+}
+
+func (h *SkillHandler) UpdateCategory(c *gin.Context) {
+	var req core.SkillCategoryUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeSkillError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *SkillHandler) DeleteCategory(c *gin.Context) {
+	if err := h.service.DeleteCategory(c.Request.Context(), c.Param("id")); err != nil {
+		writeSkillError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeSkillError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrSkillExists), errors.Is(err, core.ErrCategoryCycle),
+		errors.Is(err, core.ErrCategoryInUse), strings.Contains(err.Error(), "already exists"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
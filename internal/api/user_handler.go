@@ -2,22 +2,47 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"multi-processing-backend/internal/core"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 type UserService interface {
-	List(ctx context.Context, page, limit int, searchName, departmentName string) ([]core.UserWithDetails, int64, error)
+	List(ctx context.Context, page, limit int, filter core.UserFilter, includes core.UserIncludes) ([]core.UserWithDetails, int64, error)
 	Create(ctx context.Context, user core.User) (core.User, error)
 
-	Get(ctx context.Context, id string) (core.UserWithDetails, error)
+	Get(ctx context.Context, id string, includes core.UserIncludes) (core.UserWithDetails, error)
 	Update(ctx context.Context, id string, updates core.UserUpdate) (core.User, error)
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id, reassignTo string) error
+
+	SetManager(ctx context.Context, id, managerID string) (core.User, error)
+	GetDirectReports(ctx context.Context, id string) ([]core.OrgMember, error)
+	GetSubtree(ctx context.Context, id string) ([]core.OrgMember, error)
+	GetManagementChain(ctx context.Context, id string) ([]core.OrgMember, error)
+	GetOrgChart(ctx context.Context, rootID string) ([]*core.OrgChartNode, error)
+
+	Offboard(ctx context.Context, id string, in core.Offboarding) (core.User, error)
+	Rehire(ctx context.Context, id string, in core.Rehire) (core.User, error)
+	SetEmploymentStatus(ctx context.Context, id, status string) (core.User, error)
+	ListEmploymentPeriods(ctx context.Context, id string) ([]core.EmploymentPeriod, error)
+
+	ListAddresses(ctx context.Context, id string) ([]core.Address, error)
+
+	Promote(ctx context.Context, id string, p core.Promotion) (core.PromotionResult, error)
+	PositionTimeline(ctx context.Context, id string) (core.PositionTimeline, error)
+	TimeInRole(ctx context.Context, departmentID string) ([]core.TimeInRole, error)
+
+	Import(ctx context.Context, r io.Reader, format string, dryRun bool) (core.UserImportReport, error)
 }
 
 type UserHandler struct {
@@ -33,20 +58,46 @@ func RegisterUserRoutes(rg *gin.RouterGroup, h *UserHandler) {
 	{
 		users.GET("", h.List)
 		users.POST("", h.Create)
+		users.GET("/org-chart", h.GetOrgChart)
+		users.GET("/time-in-role", h.TimeInRole)
+		users.POST("/import", h.Import)
 		users.GET("/:id", h.Get)
+		users.GET("/:id/reports", h.GetDirectReports)
+		users.GET("/:id/subtree", h.GetSubtree)
+		users.GET("/:id/chain", h.GetManagementChain)
+		users.PUT("/:id/manager", h.SetManager)
+		users.GET("/:id/addresses", h.ListAddresses)
+		users.GET("/:id/employment", h.ListEmploymentPeriods)
+		users.PUT("/:id/employment-status", h.SetEmploymentStatus)
+		users.POST("/:id/offboard", h.Offboard)
+		users.POST("/:id/rehire", h.Rehire)
+		users.GET("/:id/positions", h.PositionTimeline)
+		users.POST("/:id/promote", h.Promote)
 		users.PATCH("/:id", h.Update)
 		users.DELETE("/:id", h.Delete)
 	}
 }
 
+// List returns a page of users matching the filters parseUserFilter reads.
 func (h *UserHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	searchName := c.Param("searchName")
-	departmentName := c.Query("departmentName")
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	users, total, err := h.service.List(c.Request.Context(), page, limit, searchName, departmentName)
+	users, total, err := h.service.List(c.Request.Context(), page, limit, filter, parseUserIncludes(c))
 	if err != nil {
+		if errors.Is(err, core.ErrSalaryForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "must") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -79,8 +130,12 @@ func (h *UserHandler) Create(c *gin.Context) {
 
 func (h *UserHandler) Get(c *gin.Context) {
 	id := c.Param("id")
-	user, err := h.service.Get(c.Request.Context(), id)
+	user, err := h.service.Get(c.Request.Context(), id, parseUserIncludes(c))
 	if err != nil {
+		if errors.Is(err, core.ErrSalaryForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -111,9 +166,352 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 func (h *UserHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Delete(c.Request.Context(), id, c.Query("reassign_to")); err != nil {
+		writeOrgError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *UserHandler) SetManager(c *gin.Context) {
+	var req struct {
+		ManagerID string `json:"manager_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.SetManager(c.Request.Context(), c.Param("id"), req.ManagerID)
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) GetDirectReports(c *gin.Context) {
+	members, err := h.service.GetDirectReports(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *UserHandler) GetSubtree(c *gin.Context) {
+	members, err := h.service.GetSubtree(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *UserHandler) GetManagementChain(c *gin.Context) {
+	members, err := h.service.GetManagementChain(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *UserHandler) GetOrgChart(c *gin.Context) {
+	chart, err := h.service.GetOrgChart(c.Request.Context(), c.Query("root"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, chart)
+}
+
+func writeOrgError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrManagerCycle), errors.Is(err, core.ErrHasDirectReports),
+		errors.Is(err, core.ErrEmploymentState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "must"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// Offboard ends an employment and keeps the record. Direct reports move to
+// reassign_to, which may be "manager" as for Delete.
+func (h *UserHandler) Offboard(c *gin.Context) {
+	var req core.Offboarding
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.Offboard(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) Rehire(c *gin.Context) {
+	var req core.Rehire
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.Rehire(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) SetEmploymentStatus(c *gin.Context) {
+	var req core.EmploymentStatusUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.SetEmploymentStatus(c.Request.Context(), c.Param("id"), req.Status)
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) ListEmploymentPeriods(c *gin.Context) {
+	periods, err := h.service.ListEmploymentPeriods(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, periods)
+}
+
+// Promote changes position, and optionally department, together with a new
+// salary. ?allow_out_of_band=true accepts a salary outside the new band.
+func (h *UserHandler) Promote(c *gin.Context) {
+	var req core.Promotion
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("allow_out_of_band") == "true" {
+		req.AllowOutOfBand = true
+	}
+
+	result, err := h.service.Promote(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writePromotionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *UserHandler) PositionTimeline(c *gin.Context) {
+	timeline, err := h.service.PositionTimeline(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// TimeInRole reports how long current staff have held their position,
+// optionally for one ?department_id=.
+func (h *UserHandler) TimeInRole(c *gin.Context) {
+	report, err := h.service.TimeInRole(c.Request.Context(), c.Query("department_id"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// maxImportSize limits the body of an import request.
+const maxImportSize = 20 << 20
+
+// Import creates employees from the request body, a CSV file (text/csv) or
+// JSON Lines (application/x-ndjson); ?format=csv|jsonl overrides the
+// content type. With ?dry_run=true every row is checked but nothing is
+// written. Otherwise all rows are created together, or none when any row
+// has a problem, in which case the report comes back with 422.
+func (h *UserHandler) Import(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = core.ImportFormatCSV
+		case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+			format = core.ImportFormatJSONL
+		}
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	report, err := h.service.Import(c.Request.Context(), body, format, dryRun)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file must not be larger than 20 MB"})
+			return
+		}
+		writeOrgError(c, err)
+		return
+	}
+
+	switch {
+	case report.Applied:
+		c.JSON(http.StatusCreated, report)
+	case report.Invalid > 0 && !dryRun:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// writePromotionError adds the salary errors a promotion can run into.
+func writePromotionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, core.ErrSalaryForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, core.ErrDuplicateSalary):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, core.ErrNoExchangeRate), errors.Is(err, core.ErrSalaryOutOfBand):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		writeOrgError(c, err)
+	}
+}
+
+// ListAddresses returns all addresses of a user, primary first.
+func (h *UserHandler) ListAddresses(c *gin.Context) {
+	addresses, err := h.service.ListAddresses(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeOrgError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, addresses)
+}
+
+// parseUserIncludes reads the comma separated include query parameter,
+// e.g. ?include=salary.
+func parseUserIncludes(c *gin.Context) core.UserIncludes {
+	var includes core.UserIncludes
+	for _, part := range strings.Split(c.Query("include"), ",") {
+		switch strings.TrimSpace(part) {
+		case "salary":
+			includes.Salary = true
+		}
+	}
+	return includes
+}
+
+// parseUserFilter reads the user filters from the query string:
+//
+//	search         words matched against name, email and phone (searchName
+//	               is the older name for it)
+//	departmentName, position, city, country
+//	status         active, on_leave, terminated or all; terminated
+//	               employees are hidden without it
+//	min_level, max_level, min_age, max_age
+//	hired_from, hired_to             dates, inclusive
+//	skill, min_proficiency           a skill held at a level or above
+//	min_salary, max_salary, currency current salary in currency
+//	sort           comma-separated fields, - in front for descending
+func parseUserFilter(c *gin.Context) (core.UserFilter, error) {
+	filter := core.UserFilter{
+		Search:     c.Query("search"),
+		Department: c.Query("departmentName"),
+		Position:   c.Query("position"),
+		Status:     c.Query("status"),
+		City:       c.Query("city"),
+		Country:    c.Query("country"),
+		Skill:      c.Query("skill"),
+
+		SalaryCurrency: c.Query("currency"),
+	}
+	if filter.Search == "" {
+		filter.Search = c.Query("searchName")
+	}
+
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{
+		{"min_level", &filter.MinLevel},
+		{"max_level", &filter.MaxLevel},
+		{"min_age", &filter.MinAge},
+		{"max_age", &filter.MaxAge},
+	} {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a whole number", p.name)
+			}
+			*p.dst = &n
+		}
+	}
+	if v := c.Query("min_proficiency"); v != "" {
+		if filter.MinProficiency, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("min_proficiency must be a whole number")
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"hired_from", &filter.HiredFrom},
+		{"hired_to", &filter.HiredTo},
+	} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a date like 2025-03-01", p.name)
+			}
+			*p.dst = &t
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **decimal.Decimal
+	}{
+		{"min_salary", &filter.MinSalary},
+		{"max_salary", &filter.MaxSalary},
+	} {
+		if v := c.Query(p.name); v != "" {
+			d, err := decimal.NewFromString(v)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a number", p.name)
+			}
+			*p.dst = &d
+		}
+	}
+
+	for _, field := range strings.Split(c.Query("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		desc := strings.HasPrefix(field, "-")
+		filter.Sort = append(filter.Sort, core.SortField{Field: strings.TrimLeft(field, "+-"), Desc: desc})
+	}
+	return filter, nil
+}
@@ -13,6 +13,19 @@ type Config struct {
 	ReadTimeout    time.Duration `env:"READ_TIMEOUT" envDefault:"15s"`
 	WriteTimeout   time.Duration `env:"WRITE_TIMEOUT" envDefault:"15s"`
 	IdleTimeout    time.Duration `env:"IDLE_TIMEOUT" envDefault:"300s"`
+	AvatarDir      string        `env:"AVATAR_DIR" envDefault:"uploads/avatars"`
+	BaseCurrency   string        `env:"BASE_CURRENCY" envDefault:"EUR"`
+	ExchangeRates  string        `env:"EXCHANGE_RATES_FILE" envDefault:"migrations/json/exchange_rates.json"`
+	// MailDropDir, when set, receives outgoing mail as files instead of
+	// sending it; for local development.
+	MailDropDir string `env:"MAIL_DROP_DIR"`
+	// ActorTokens maps each actor to the token it authenticates with, as
+	// "alice:token1,bob:token2". The audit log records the matching actor.
+	ActorTokens map[string]string `env:"ACTOR_TOKENS"`
+	// SalaryAccessToken, sent as X-Salary-Access-Token, unlocks salary data:
+	// the /salary and /payroll routes, salary figures elsewhere and salary
+	// audit entries. Empty unlocks it for nobody.
+	SalaryAccessToken string `env:"SALARY_ACCESS_TOKEN"`
 }
 
 func Load() *Config {
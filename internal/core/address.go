@@ -2,29 +2,40 @@ package core
 
 import "time"
 
+const (
+	AddressHome    = "home"
+	AddressMailing = "mailing"
+	AddressWork    = "work"
+)
+
+// Address belongs to one user. Every user with addresses has exactly one
+// primary address.
 type Address struct {
 	ID        string    `json:"id" db:"id"`
 	UserID    string    `json:"user_id" db:"user_id"`
+	Type      string    `json:"type" db:"address_type"`
 	Street    string    `json:"street" db:"street"`
 	City      string    `json:"city" db:"city"`
 	ZipCode   string    `json:"zip_code" db:"zip_code"`
 	Country   string    `json:"country" db:"country"`
 	IsPrimary bool      `json:"is_primary" db:"is_primary"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// AddressUpdate changes the given fields. Setting IsPrimary makes the
+// address the user's primary one; it cannot be cleared directly.
 type AddressUpdate struct {
-	UserID    string    `json:"user_id" db:"user_id"`
-	Street    string    `json:"street" db:"street"`
-	City      string    `json:"city" db:"city"`
-	ZipCode   string    `json:"zip_code" db:"zip_code"`
-	Country   string    `json:"country" db:"country"`
-	IsPrimary bool      `json:"is_primary" db:"is_primary"`
+	Type      *string `json:"type,omitempty"`
+	Street    *string `json:"street,omitempty"`
+	City      *string `json:"city,omitempty"`
+	ZipCode   *string `json:"zip_code,omitempty"`
+	Country   *string `json:"country,omitempty"`
+	IsPrimary *bool   `json:"is_primary,omitempty"`
 }
 
 type AddressPagination struct {
-	Data []Address
+	Data  []Address
 	Total int64
 	Error error
-}
\ No newline at end of file
+}
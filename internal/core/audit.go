@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditInfo identifies who made a change and in which request. The database
+// layer stamps it on the connection so the audit triggers can record it.
+// Actor is the name the request's token belongs to, "anonymous" for
+// requests without a token and "system" for background jobs.
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+}
+
+type auditInfoKey struct{}
+
+func WithAuditInfo(ctx context.Context, info AuditInfo) context.Context {
+	return context.WithValue(ctx, auditInfoKey{}, info)
+}
+
+func AuditInfoFrom(ctx context.Context) (AuditInfo, bool) {
+	info, ok := ctx.Value(auditInfoKey{}).(AuditInfo)
+	return info, ok
+}
+
+// AuditEntry is one recorded change. Changes maps each changed column to
+// its old and new value and is only filled for updates.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"request_id"`
+	Entity     string          `json:"entity"`
+	EntityID   string          `json:"entity_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Changes    json.RawMessage `json:"changes"`
+}
+
+// AuditFilter narrows down the audit log; zero values match everything.
+type AuditFilter struct {
+	Entity    string
+	EntityID  string
+	Actor     string
+	Action    string
+	RequestID string
+	From      time.Time
+	To        time.Time
+}
+
+type AuditPagination struct {
+	Data  []AuditEntry `json:"data"`
+	Total int64        `json:"total"`
+	Error error        `json:"error"`
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DepartmentStats describes one department, or the whole company when
+// DepartmentID is empty.
+type DepartmentStats struct {
+	DepartmentID       string             `json:"department_id,omitempty"`
+	Headcount          int                `json:"headcount"`
+	HiresPerMonth      []MonthlyHires     `json:"hires_per_month"`
+	AverageTenureYears float64            `json:"average_tenure_years"`
+	MedianTenureYears  float64            `json:"median_tenure_years"`
+	Salaries           SalaryDistribution `json:"salaries"`
+	PositionLevels     []LevelCount       `json:"position_levels"`
+	TopSkills          []SkillCount       `json:"top_skills"`
+	GeneratedAt        time.Time          `json:"generated_at"`
+}
+
+type MonthlyHires struct {
+	Month string `json:"month"`
+	Hires int    `json:"hires"`
+}
+
+// SalaryDistribution covers current salaries converted into Currency.
+// Unconverted counts salaries skipped for lack of an exchange rate.
+type SalaryDistribution struct {
+	Currency    string              `json:"currency"`
+	Employees   int                 `json:"employees"`
+	Unconverted int                 `json:"unconverted"`
+	Min         decimal.NullDecimal `json:"min"`
+	Median      decimal.NullDecimal `json:"median"`
+	P90         decimal.NullDecimal `json:"p90"`
+}
+
+type LevelCount struct {
+	Level     int `json:"level"`
+	Employees int `json:"employees"`
+}
+
+type SkillCount struct {
+	SkillID            string  `json:"skill_id"`
+	Name               string  `json:"name"`
+	Category           string  `json:"category"`
+	Employees          int     `json:"employees"`
+	AverageProficiency float64 `json:"average_proficiency"`
+}
@@ -0,0 +1,43 @@
+package core
+
+import "time"
+
+const (
+	EmploymentActive     = "active"
+	EmploymentOnLeave    = "on_leave"
+	EmploymentTerminated = "terminated"
+
+	// EmploymentAll lists employees in any status.
+	EmploymentAll = "all"
+)
+
+// Offboarding ends an employment. Direct reports must be handed over with
+// ReassignTo, as for Delete.
+type Offboarding struct {
+	TerminationDate time.Time `json:"termination_date" binding:"required"`
+	Reason          string    `json:"reason" binding:"required"`
+	ReassignTo      string    `json:"reassign_to"`
+}
+
+// Rehire starts a new employment on an existing record. Empty department
+// and position keep the previous ones; ManagerID replaces the old manager.
+type Rehire struct {
+	HireDate     time.Time `json:"hire_date" binding:"required"`
+	DepartmentID string    `json:"department_id"`
+	PositionID   string    `json:"position_id"`
+	ManagerID    string    `json:"manager_id"`
+}
+
+type EmploymentStatusUpdate struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// EmploymentPeriod is a completed stint of a rehired or departed employee.
+type EmploymentPeriod struct {
+	ID              string    `json:"id"`
+	UserID          string    `json:"user_id"`
+	HireDate        time.Time `json:"hire_date"`
+	TerminationDate time.Time `json:"termination_date"`
+	Reason          string    `json:"reason"`
+	CreatedAt       time.Time `json:"created_at"`
+}
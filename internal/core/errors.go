@@ -0,0 +1,60 @@
+package core
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrManagerCycle      = errors.New("manager assignment would create a reporting cycle")
+	ErrHasDirectReports  = errors.New("user has direct reports, reassign them first")
+	ErrDuplicateSalary   = errors.New("user already has a salary effective on that date")
+	ErrPayrollRunState   = errors.New("payroll run status does not allow this operation")
+	ErrNoExchangeRate    = errors.New("no exchange rate available")
+	ErrSalaryOutOfBand   = errors.New("salary is outside the band for the position")
+	ErrInvalidSkillQuery = errors.New("invalid skill query")
+	ErrSkillExists       = errors.New("a skill with this name or alias already exists")
+	ErrCategoryCycle     = errors.New("category move would create a cycle")
+	ErrCategoryInUse     = errors.New("category still has subcategories")
+	ErrEmploymentState   = errors.New("employment status does not allow this operation")
+	ErrLeaveRequestState = errors.New("leave request status does not allow this operation")
+	ErrLeaveOverlap      = errors.New("leave request overlaps another request")
+	ErrLeaveBalance      = errors.New("not enough leave left")
+	ErrOfficeExists      = errors.New("an office with this name already exists")
+	ErrOfficeInUse       = errors.New("office still has people assigned")
+	ErrSalaryForbidden   = errors.New("salary data needs elevated permission")
+	ErrMailUnavailable   = errors.New("mail delivery is not configured")
+)
+
+// FieldError describes why one input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError carries every field error found in one input so clients
+// can show them all at once.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Add records a field error.
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e when it holds field errors and nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PivotCurrency is the currency all exchange rates are quoted against, as
+// in the ECB reference rates: one unit of it buys Rate units of Currency.
+const PivotCurrency = "EUR"
+
+type ExchangeRate struct {
+	Currency  string          `json:"currency"`
+	RateDate  time.Time       `json:"rate_date"`
+	Rate      decimal.Decimal `json:"rate"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ExchangeRateFile is the layout of the local rates file loaded at startup.
+type ExchangeRateFile struct {
+	Base  string `json:"base"`
+	Rates []struct {
+		Date     string          `json:"date"`
+		Currency string          `json:"currency"`
+		Rate     decimal.Decimal `json:"rate"`
+	} `json:"rates"`
+}
@@ -0,0 +1,30 @@
+package core
+
+const (
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl"
+	ExportXLSX  = "xlsx"
+)
+
+// Kinds of export column; they decide how a value is written in formats
+// that know about types.
+const (
+	ExportText   = "text"
+	ExportNumber = "number"
+	ExportDate   = "date"
+)
+
+// ExportColumn describes one column an export can contain. Sensitive
+// columns, such as salaries, are left out unless the caller may see them.
+type ExportColumn struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// ExportOptions selects the format and columns of an export. Without
+// columns every column the caller may see is exported.
+type ExportOptions struct {
+	Format  string
+	Columns []string
+}
@@ -2,6 +2,13 @@ package core
 
 import "time"
 
+// Roles of channel members. Admins, like the channel's creator, may change
+// its name and topic and invite users.
+const (
+	ChannelRoleMember = "member"
+	ChannelRoleAdmin  = "admin"
+)
+
 type ForumChannel struct {
 	ID              string    `json:"id" db:"id"`
 	Name            string    `json:"name" db:"name"`
@@ -9,6 +16,7 @@ type ForumChannel struct {
 	IsPrivate       bool      `json:"is_private" db:"is_private"`
 	IsDirectMessage bool      `json:"is_direct_message" db:"is_direct_message"`
 	CreatedBy       string    `json:"created_by" db:"created_by"`
+	DepartmentID    string    `json:"department_id,omitempty" db:"department_id"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -19,3 +27,9 @@ type ForumChannelMessages struct {
 	Limit    int            `json:"limit"`
 	Total    int64          `json:"total"`
 }
+
+type DepartmentChannelSync struct {
+	Channels int `json:"channels"`
+	Joined   int `json:"joined"`
+	Left     int `json:"left"`
+}
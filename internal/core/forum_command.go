@@ -0,0 +1,24 @@
+package core
+
+type ForumCommandArg struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Required    bool     `json:"required"`
+	Variadic    bool     `json:"variadic"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+type ForumCommandSpec struct {
+	Name        string            `json:"name"`
+	Usage       string            `json:"usage"`
+	Description string            `json:"description"`
+	Args        []ForumCommandArg `json:"args"`
+}
+
+const (
+	CommandArgText     = "text"
+	CommandArgUser     = "user"
+	CommandArgDuration = "duration"
+	CommandArgCrypto   = "crypto"
+)
@@ -0,0 +1,40 @@
+package core
+
+import "time"
+
+const (
+	ForumEventSnapshot = "snapshot"
+	ForumEventUnread   = "unread"
+	ForumEventMentions = "mentions"
+	ForumEventPresence = "presence"
+)
+
+type ForumEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+type ForumCounterChange struct {
+	ChannelID string `json:"channel_id"`
+	Count     int    `json:"count"`
+}
+
+type ForumPresenceChange struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	IsOnline bool      `json:"is_online"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type ForumCounterSnapshot struct {
+	Unread   map[string]int        `json:"unread"`
+	Mentions map[string]int        `json:"mentions"`
+	Presence []ForumPresenceChange `json:"presence"`
+}
+
+type ForumEventSubscription struct {
+	Replay []ForumEvent
+	Events <-chan ForumEvent
+	Close  func()
+}
@@ -1,20 +1,58 @@
 package core
 
 import (
+	"encoding/json"
 	"time"
 )
 
+const (
+	MessageTypeText            = "text"
+	MessageTypeAction          = "action"
+	MessageTypeCommand         = "command"
+	MessageTypeReminder        = "reminder"
+	MessageTypeCommandResponse = "command_response"
+	MessageTypeSystem          = "system"
+)
+
+const (
+	SystemEventMemberJoined    = "member_joined"
+	SystemEventMemberLeft      = "member_left"
+	SystemEventChannelRenamed  = "channel_renamed"
+	SystemEventTopicChanged    = "topic_changed"
+	SystemEventMessagePinned   = "message_pinned"
+	SystemEventMessageUnpinned = "message_unpinned"
+	SystemEventDirectCreated   = "direct_message_created"
+)
+
+// ForumSystemEvent is stored as the payload of system messages so clients
+// can render localized text instead of the English fallback content.
+type ForumSystemEvent struct {
+	Event     string `json:"event"`
+	ActorID   string `json:"actor_id,omitempty"`
+	TargetID  string `json:"target_id,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	OldValue  string `json:"old_value,omitempty"`
+	NewValue  string `json:"new_value,omitempty"`
+}
+
 type ForumMessage struct {
-	ID              string        `json:"id" db:"id"`
-	ChannelID       string        `json:"channel_id" db:"channel_id"`
-	UserID          string        `json:"user_id" db:"user_id"`
-	Content         string        `json:"content" db:"content"`
-	MessageType     string        `json:"message_type" db:"message_type"`
-	ParentMessageID string        `json:"parent_message_id,omitempty" db:"parent_message_id"`
-	IsEdited        bool          `json:"is_edited" db:"is_edited"`
-	IsDeleted       bool          `json:"is_deleted" db:"is_deleted"`
-	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
-	User            *ForumUser    `json:"user,omitempty"`
-	ParentMessage   *ForumMessage `json:"parent_message,omitempty"`
+	ID              string          `json:"id" db:"id"`
+	ChannelID       string          `json:"channel_id" db:"channel_id"`
+	UserID          string          `json:"user_id" db:"user_id"`
+	Content         string          `json:"content" db:"content"`
+	MessageType     string          `json:"message_type" db:"message_type"`
+	ParentMessageID string          `json:"parent_message_id,omitempty" db:"parent_message_id"`
+	IsEdited        bool            `json:"is_edited" db:"is_edited"`
+	IsDeleted       bool            `json:"is_deleted" db:"is_deleted"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
+	Payload         json.RawMessage `json:"payload,omitempty" db:"payload"`
+	User            *ForumUser      `json:"user,omitempty"`
+	ParentMessage   *ForumMessage   `json:"parent_message,omitempty"`
+}
+
+type PinnedMessage struct {
+	Message  ForumMessage `json:"message"`
+	PinnedBy string       `json:"pinned_by"`
+	PinnedAt time.Time    `json:"pinned_at"`
 }
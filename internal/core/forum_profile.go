@@ -0,0 +1,40 @@
+package core
+
+import "time"
+
+type ForumUserStatus struct {
+	Text      string     `json:"text"`
+	Emoji     string     `json:"emoji"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ForumUserProfile struct {
+	UserID      string           `json:"user_id"`
+	Username    string           `json:"username"`
+	DisplayName string           `json:"display_name"`
+	AvatarUrl   string           `json:"avatar_url"`
+	Bio         string           `json:"bio"`
+	Pronouns    string           `json:"pronouns"`
+	Timezone    string           `json:"timezone"`
+	Status      *ForumUserStatus `json:"status,omitempty"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+
+	EmailVerified bool   `json:"email_verified"`
+	HRUserID      string `json:"hr_user_id,omitempty"`
+	Department    string `json:"department,omitempty"`
+	Position      string `json:"position,omitempty"`
+}
+
+type ForumUserProfileUpdate struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	Pronouns    *string `json:"pronouns,omitempty"`
+	Timezone    *string `json:"timezone,omitempty"`
+}
+
+type ForumUserStatusUpdate struct {
+	Text      string     `json:"text"`
+	Emoji     string     `json:"emoji"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ExpiresIn string     `json:"expires_in,omitempty"`
+}
@@ -0,0 +1,12 @@
+package core
+
+import "time"
+
+type ForumReminder struct {
+	ID        string    `json:"id" db:"id"`
+	ChannelID string    `json:"channel_id" db:"channel_id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Content   string    `json:"content" db:"content"`
+	RemindAt  time.Time `json:"remind_at" db:"remind_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
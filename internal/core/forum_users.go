@@ -16,3 +16,11 @@ type ForumUser struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// ForumMember is a forum user as shown in member lists and search results,
+// enriched with HR data when the account is linked to an employee record.
+type ForumMember struct {
+	ForumUser
+	Department string `json:"department,omitempty"`
+	Position   string `json:"position,omitempty"`
+}
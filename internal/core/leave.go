@@ -0,0 +1,113 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	LeaveStatusPending   = "pending"
+	LeaveStatusApproved  = "approved"
+	LeaveStatusRejected  = "rejected"
+	LeaveStatusCancelled = "cancelled"
+)
+
+type LeaveType struct {
+	ID             string          `json:"id"`
+	Code           string          `json:"code"`
+	Name           string          `json:"name"`
+	HasEntitlement bool            `json:"has_entitlement"`
+	DefaultDays    decimal.Decimal `json:"default_days"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// LeaveRequest covers StartDate to EndDate inclusive. HalfDayStart means
+// the first day starts at noon, HalfDayEnd that the last day ends at noon.
+// Days counts the working days taken.
+type LeaveRequest struct {
+	ID           string          `json:"id"`
+	UserID       string          `json:"user_id"`
+	LeaveTypeID  string          `json:"leave_type_id"`
+	LeaveType    string          `json:"leave_type"`
+	StartDate    time.Time       `json:"start_date"`
+	EndDate      time.Time       `json:"end_date"`
+	HalfDayStart bool            `json:"half_day_start"`
+	HalfDayEnd   bool            `json:"half_day_end"`
+	Days         decimal.Decimal `json:"days"`
+	Status       string          `json:"status"`
+	Reason       string          `json:"reason"`
+	DecidedBy    string          `json:"decided_by,omitempty"`
+	DecisionNote string          `json:"decision_note,omitempty"`
+	DecidedAt    *time.Time      `json:"decided_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// LeaveRequestCreate names the leave type by its code, e.g. "vacation".
+type LeaveRequestCreate struct {
+	UserID       string    `json:"user_id" binding:"required"`
+	LeaveType    string    `json:"leave_type" binding:"required"`
+	StartDate    time.Time `json:"start_date" binding:"required"`
+	EndDate      time.Time `json:"end_date" binding:"required"`
+	HalfDayStart bool      `json:"half_day_start"`
+	HalfDayEnd   bool      `json:"half_day_end"`
+	Reason       string    `json:"reason"`
+}
+
+type LeaveDecision struct {
+	DecidedBy string `json:"decided_by"`
+	Note      string `json:"note"`
+}
+
+type LeaveRequestPagination struct {
+	Data  []LeaveRequest `json:"data"`
+	Total int64          `json:"total"`
+	Error error          `json:"error"`
+}
+
+type LeaveEntitlementUpdate struct {
+	Year int             `json:"year" binding:"required"`
+	Days decimal.Decimal `json:"days"`
+}
+
+// LeaveBalance is the state of one leave type for a user and year.
+// Remaining is Entitlement less Taken and is not set for types without an
+// entitlement.
+type LeaveBalance struct {
+	LeaveType   string              `json:"leave_type"`
+	Name        string              `json:"name"`
+	Entitlement decimal.NullDecimal `json:"entitlement"`
+	Taken       decimal.Decimal     `json:"taken"`
+	Pending     decimal.Decimal     `json:"pending"`
+	Remaining   decimal.NullDecimal `json:"remaining"`
+}
+
+type LeaveBalances struct {
+	UserID   string         `json:"user_id"`
+	Year     int            `json:"year"`
+	Balances []LeaveBalance `json:"balances"`
+}
+
+// Absence is an entry of a department absence calendar.
+type Absence struct {
+	RequestID    string          `json:"request_id"`
+	UserID       string          `json:"user_id"`
+	FirstName    string          `json:"first_name"`
+	LastName     string          `json:"last_name"`
+	LeaveType    string          `json:"leave_type"`
+	StartDate    time.Time       `json:"start_date"`
+	EndDate      time.Time       `json:"end_date"`
+	HalfDayStart bool            `json:"half_day_start"`
+	HalfDayEnd   bool            `json:"half_day_end"`
+	Days         decimal.Decimal `json:"days"`
+	Status       string          `json:"status"`
+}
+
+type AbsenceCalendar struct {
+	DepartmentID string    `json:"department_id"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Absences     []Absence `json:"absences"`
+}
@@ -0,0 +1,87 @@
+package core
+
+import "time"
+
+const (
+	WorkplaceOffice = "office"
+	WorkplaceRemote = "remote"
+	WorkplaceHybrid = "hybrid"
+)
+
+// Office is a company location. Capacity counts desks.
+type Office struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Street    string    `json:"street"`
+	City      string    `json:"city"`
+	ZipCode   string    `json:"zip_code"`
+	Country   string    `json:"country"`
+	Timezone  string    `json:"timezone"`
+	Capacity  int       `json:"capacity"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OfficeUpdate struct {
+	Name     *string `json:"name,omitempty"`
+	Street   *string `json:"street,omitempty"`
+	City     *string `json:"city,omitempty"`
+	ZipCode  *string `json:"zip_code,omitempty"`
+	Country  *string `json:"country,omitempty"`
+	Timezone *string `json:"timezone,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+// Workplace tells where a user works. OfficeDays lists the weekdays
+// ("mon" to "fri") a hybrid worker spends in the office.
+type Workplace struct {
+	UserID     string    `json:"user_id"`
+	Mode       string    `json:"mode"`
+	OfficeID   string    `json:"office_id,omitempty"`
+	OfficeName string    `json:"office_name,omitempty"`
+	OfficeDays []string  `json:"office_days"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WorkplaceUpdate struct {
+	Mode       string   `json:"mode" binding:"required"`
+	OfficeID   string   `json:"office_id"`
+	OfficeDays []string `json:"office_days"`
+}
+
+// OfficeDayLoad is the number of people expected in an office on a
+// weekday.
+type OfficeDayLoad struct {
+	Day      string `json:"day"`
+	Expected int    `json:"expected"`
+}
+
+// OfficeHeadcount compares the people assigned to an office with its
+// capacity. Peak is the busiest weekday; Utilization is Peak / Capacity.
+type OfficeHeadcount struct {
+	OfficeID     string          `json:"office_id"`
+	Name         string          `json:"name"`
+	City         string          `json:"city"`
+	Country      string          `json:"country"`
+	Capacity     int             `json:"capacity"`
+	Assigned     int             `json:"assigned"`
+	OfficeBased  int             `json:"office_based"`
+	Hybrid       int             `json:"hybrid"`
+	Daily        []OfficeDayLoad `json:"daily"`
+	Peak         int             `json:"peak"`
+	Utilization  float64         `json:"utilization"`
+	OverCapacity bool            `json:"over_capacity"`
+}
+
+// OfficeMember is a colleague working at an office. It deliberately holds
+// no personal address data.
+type OfficeMember struct {
+	UserID       string   `json:"user_id"`
+	FirstName    string   `json:"first_name"`
+	LastName     string   `json:"last_name"`
+	Email        string   `json:"email"`
+	DepartmentID string   `json:"department_id"`
+	PositionID   string   `json:"position_id"`
+	Mode         string   `json:"mode"`
+	OfficeDays   []string `json:"office_days"`
+}
@@ -0,0 +1,21 @@
+package core
+
+// ReassignToManager hands direct reports of a deleted user to that user's
+// own manager.
+const ReassignToManager = "manager"
+
+type OrgMember struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	ManagerID     string `json:"manager_id,omitempty"`
+	DepartmentID  string `json:"department_id,omitempty"`
+	PositionTitle string `json:"position_title,omitempty"`
+	Depth         int    `json:"depth"`
+}
+
+type OrgChartNode struct {
+	OrgMember
+	Reports []*OrgChartNode `json:"reports"`
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PayrollPeriodMonth   = "month"
+	PayrollPeriodQuarter = "quarter"
+
+	PayrollStatusDraft    = "draft"
+	PayrollStatusApproved = "approved"
+	PayrollStatusLocked   = "locked"
+)
+
+type PayrollRun struct {
+	ID            string          `json:"id"`
+	PeriodType    string          `json:"period_type"`
+	PeriodStart   time.Time       `json:"period_start"`
+	PeriodEnd     time.Time       `json:"period_end"`
+	Status        string          `json:"status"`
+	Currency      string          `json:"currency"`
+	Total         decimal.Decimal `json:"total"`
+	EmployeeCount int             `json:"employee_count"`
+	ApprovedAt    *time.Time      `json:"approved_at,omitempty"`
+	LockedAt      *time.Time      `json:"locked_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+
+	Departments []PayrollDepartmentTotal `json:"departments,omitempty"`
+	Lines       []PayrollLine            `json:"lines,omitempty"`
+}
+
+// PayrollSegment is the part of a line paid at one salary rate.
+// AnnualAmount is in the salary's own currency; Rate converts it into the
+// run currency that Amount is reported in.
+type PayrollSegment struct {
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	Currency     string          `json:"currency"`
+	AnnualAmount decimal.Decimal `json:"annual_amount"`
+	Rate         decimal.Decimal `json:"rate"`
+	Days         int             `json:"days"`
+	Amount       decimal.Decimal `json:"amount"`
+}
+
+type PayrollLine struct {
+	ID             string           `json:"id"`
+	RunID          string           `json:"run_id"`
+	UserID         string           `json:"user_id"`
+	EmployeeName   string           `json:"employee_name"`
+	DepartmentID   string           `json:"department_id,omitempty"`
+	DepartmentName string           `json:"department_name,omitempty"`
+	DaysWorked     int              `json:"days_worked"`
+	PeriodDays     int              `json:"period_days"`
+	Amount         decimal.Decimal  `json:"amount"`
+	Segments       []PayrollSegment `json:"segments"`
+}
+
+type PayrollDepartmentTotal struct {
+	DepartmentID   string          `json:"department_id,omitempty"`
+	DepartmentName string          `json:"department_name"`
+	Employees      int             `json:"employees"`
+	Total          decimal.Decimal `json:"total"`
+}
+
+type PayrollRunCreate struct {
+	PeriodType string `json:"period_type" binding:"required"`
+	// Period is "2025-03" for months and "2025-Q1" for quarters.
+	Period string `json:"period" binding:"required"`
+	// Currency defaults to the configured base currency.
+	Currency string `json:"currency"`
+}
+
+type PayrollRunPagination struct {
+	Data  []PayrollRun `json:"data"`
+	Total int64        `json:"total"`
+	Error error        `json:"error"`
+}
+
+// PayrollEmployee is the input for one payroll line: an employee and the
+// salaries that may be in effect during the period, oldest first.
+type PayrollEmployee struct {
+	UserID          string
+	Name            string
+	DepartmentID    string
+	DepartmentName  string
+	HireDate        time.Time
+	TerminationDate *time.Time
+	Salaries        []Salary
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	AssignmentHire      = "hire"
+	AssignmentRehire    = "rehire"
+	AssignmentPromotion = "promotion"
+	AssignmentChange    = "change"
+)
+
+// PositionAssignment is one stretch of a user's position and department
+// history. EndDate is exclusive and nil while the assignment is current.
+type PositionAssignment struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	PositionID     string     `json:"position_id"`
+	PositionTitle  string     `json:"position_title"`
+	PositionLevel  int        `json:"position_level"`
+	DepartmentID   string     `json:"department_id"`
+	DepartmentName string     `json:"department_name"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	Reason         string     `json:"reason"`
+	SalaryID       string     `json:"salary_id,omitempty"`
+	Note           string     `json:"note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Promotion moves a user to a new position, and optionally department,
+// with a new salary, all effective on EffectiveDate. The department stays
+// unchanged when DepartmentID is empty; Currency defaults to the pivot
+// currency.
+type Promotion struct {
+	PositionID     string          `json:"position_id" binding:"required"`
+	DepartmentID   string          `json:"department_id"`
+	EffectiveDate  time.Time       `json:"effective_date" binding:"required"`
+	Amount         decimal.Decimal `json:"amount"`
+	Currency       string          `json:"currency"`
+	AllowOutOfBand bool            `json:"allow_out_of_band"`
+	Note           string          `json:"note"`
+}
+
+type PromotionResult struct {
+	User       User               `json:"user"`
+	Assignment PositionAssignment `json:"assignment"`
+	Salary     Salary             `json:"salary"`
+}
+
+// TimeInRole tells since when a user has held their current position,
+// counting across department changes but not across gaps in employment.
+type TimeInRole struct {
+	UserID        string    `json:"user_id"`
+	FirstName     string    `json:"first_name"`
+	LastName      string    `json:"last_name"`
+	DepartmentID  string    `json:"department_id"`
+	PositionID    string    `json:"position_id"`
+	PositionTitle string    `json:"position_title"`
+	RoleSince     time.Time `json:"role_since"`
+	DaysInRole    int       `json:"days_in_role"`
+	YearsInRole   float64   `json:"years_in_role"`
+}
+
+type PositionTimeline struct {
+	UserID      string               `json:"user_id"`
+	Current     *TimeInRole          `json:"current,omitempty"`
+	Assignments []PositionAssignment `json:"assignments"`
+}
@@ -0,0 +1,62 @@
+package core
+
+import "time"
+
+type PositionSkill struct {
+	PositionID     string    `json:"position_id"`
+	SkillID        string    `json:"skill_id"`
+	SkillName      string    `json:"skill_name"`
+	MinProficiency int       `json:"min_proficiency"`
+	Required       bool      `json:"required"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// PositionSkillUpsert sets a skill requirement. Required defaults to true.
+type PositionSkillUpsert struct {
+	MinProficiency int   `json:"min_proficiency" binding:"required"`
+	Required       *bool `json:"required"`
+}
+
+// SkillGap compares one requirement with the employee's level, 0 when the
+// skill is not held. Gap is how many levels are missing.
+type SkillGap struct {
+	SkillID          string `json:"skill_id"`
+	SkillName        string `json:"skill_name"`
+	Required         bool   `json:"required"`
+	MinProficiency   int    `json:"min_proficiency"`
+	ProficiencyLevel int    `json:"proficiency_level"`
+	Gap              int    `json:"gap"`
+}
+
+// SkillGapReport holds the requirements of a position an employee falls
+// short of, and those they meet. Ready is set when no required skill has a
+// gap.
+type SkillGapReport struct {
+	UserID          string     `json:"user_id"`
+	PositionID      string     `json:"position_id"`
+	PositionTitle   string     `json:"position_title"`
+	CurrentPosition bool       `json:"current_position"`
+	Ready           bool       `json:"ready"`
+	Gaps            []SkillGap `json:"gaps"`
+	Met             []SkillGap `json:"met"`
+}
+
+// DepartmentSkillGap aggregates one skill over the employees whose current
+// position requires it. Employees counts them, Short those below the
+// minimum, and TotalGap adds up the missing levels.
+type DepartmentSkillGap struct {
+	SkillID    string  `json:"skill_id"`
+	SkillName  string  `json:"skill_name"`
+	Required   bool    `json:"required"`
+	Employees  int     `json:"employees"`
+	Short      int     `json:"short"`
+	TotalGap   int     `json:"total_gap"`
+	AverageGap float64 `json:"average_gap"`
+}
+
+type DepartmentSkillGapReport struct {
+	DepartmentID string               `json:"department_id,omitempty"`
+	Employees    int                  `json:"employees"`
+	Gaps         []DepartmentSkillGap `json:"gaps"`
+}
@@ -1,19 +1,26 @@
 package core
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Salary struct {
 	ID            string  `json:"id" db:"id"`
 	UserID        string  `json:"user_id" db:"user_id"`
-	Amount        float64 `json:"amount" db:"amount"`
+	Amount        decimal.Decimal `json:"amount" db:"amount"`
+	Currency      string  `json:"currency" db:"currency"`
 	EffectiveDate time.Time `json:"effective_date" db:"effective_date"`
+	OutOfBand     bool    `json:"out_of_band" db:"out_of_band"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type SalaryUpdate struct {
 	UserID        string  `json:"user_id"`
-	Amount        float64 `json:"amount,omitempty"`
+	Amount        decimal.Decimal `json:"amount,omitempty"`
+	Currency      string  `json:"currency,omitempty"`
 	EffectiveDate time.Time `json:"effective_date,omitempty"`
 }
 
@@ -21,4 +28,22 @@ type SalaryPagination struct {
 	Data  []Salary `json:"data"`
 	Total int64  `json:"total"`
 	Error error  `json:"error"`
-}
\ No newline at end of file
+}
+// SalaryChange is one entry of a salary timeline. The delta fields are nil
+// for the first salary of a user and when the currency changed between the
+// two entries.
+type SalaryChange struct {
+	Salary
+	PreviousAmount *decimal.Decimal `json:"previous_amount,omitempty"`
+	Delta          *decimal.Decimal `json:"delta,omitempty"`
+	DeltaPercent   *decimal.Decimal `json:"delta_percent,omitempty"`
+}
+
+// SalaryTimeline lists salaries in their own currency, or all converted
+// into Currency when one was requested.
+type SalaryTimeline struct {
+	UserID   string         `json:"user_id"`
+	Currency string         `json:"currency,omitempty"`
+	Current  *Salary        `json:"current,omitempty"`
+	Changes  []SalaryChange `json:"changes"`
+}
@@ -0,0 +1,16 @@
+package core
+
+import "context"
+
+type salaryAccessKey struct{}
+
+// WithSalaryAccess marks ctx as allowed to see salary data. Only the API
+// layer sets it, after checking a credential the server controls.
+func WithSalaryAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, salaryAccessKey{}, true)
+}
+
+func HasSalaryAccess(ctx context.Context) bool {
+	ok, _ := ctx.Value(salaryAccessKey{}).(bool)
+	return ok
+}
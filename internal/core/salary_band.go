@@ -0,0 +1,52 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	BandBelow  = "below"
+	BandWithin = "within"
+	BandAbove  = "above"
+	// BandNoRate marks a salary that cannot be converted into the band
+	// currency for lack of an exchange rate.
+	BandNoRate = "no_rate"
+)
+
+type SalaryBand struct {
+	ID         string          `json:"id"`
+	PositionID string          `json:"position_id"`
+	Level      int             `json:"level"`
+	Currency   string          `json:"currency"`
+	Min        decimal.Decimal `json:"min"`
+	Mid        decimal.Decimal `json:"mid"`
+	Max        decimal.Decimal `json:"max"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+type SalaryBandUpsert struct {
+	Currency string          `json:"currency"`
+	Min      decimal.Decimal `json:"min" binding:"required"`
+	Mid      decimal.Decimal `json:"mid" binding:"required"`
+	Max      decimal.Decimal `json:"max" binding:"required"`
+}
+
+// CompaRatio compares an employee's current salary, converted into the band
+// currency, against the midpoint of their band.
+type CompaRatio struct {
+	UserID        string              `json:"user_id"`
+	Email         string              `json:"email"`
+	FirstName     string              `json:"first_name"`
+	LastName      string              `json:"last_name"`
+	PositionID    string              `json:"position_id"`
+	PositionTitle string              `json:"position_title"`
+	Level         int                 `json:"level"`
+	Band          SalaryBand          `json:"band"`
+	Salary        Salary              `json:"salary"`
+	Amount        decimal.NullDecimal `json:"amount"`
+	Ratio         decimal.NullDecimal `json:"compa_ratio"`
+	Status        string              `json:"status"`
+}
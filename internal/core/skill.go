@@ -5,17 +5,19 @@ import (
 )
 
 type Skill struct {
-	ID        string    `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Category  string    `json:"category" db:"category"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID         string    `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	Category   string    `json:"category" db:"category"`
+	CategoryID string    `json:"category_id,omitempty" db:"category_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type SkillWithDetails struct {
 	ID               string    `json:"id" db:"id"`
 	Name             string    `json:"name" db:"name"`
 	Category         string    `json:"category" db:"category"`
+	CategoryID       string    `json:"category_id,omitempty" db:"category_id"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
 	ProficiencyLevel int       `json:"proficiency_level" db:"proficiency_level"`
@@ -23,8 +25,9 @@ type SkillWithDetails struct {
 }
 
 type SkillUpdate struct {
-	Name     *string `json:"name,omitempty"`
-	Category *string `json:"category,omitempty"`
+	Name       *string `json:"name,omitempty"`
+	Category   *string `json:"category,omitempty"`
+	CategoryID *string `json:"category_id,omitempty"`
 }
 
 type SkillPagination struct {
@@ -32,3 +35,30 @@ type SkillPagination struct {
 	Total int64   `json:"total"`
 	Error error   `json:"error"`
 }
+
+// UserSkillUpdate sets the proficiency (1-5) and acquired date of a skill
+// held by a user. Nil fields are left alone, or defaulted when adding.
+type UserSkillUpdate struct {
+	ProficiencyLevel *int       `json:"proficiency_level"`
+	AcquiredDate     *time.Time `json:"acquired_date"`
+}
+
+type SkillMatch struct {
+	SkillID          string `json:"skill_id"`
+	Name             string `json:"name"`
+	ProficiencyLevel int    `json:"proficiency_level"`
+}
+
+// SkillSearchResult is an employee matching a skill query. Skills lists the
+// queried skills the employee holds; Score sums the proficiency of the
+// terms that matched.
+type SkillSearchResult struct {
+	UserID         string       `json:"user_id"`
+	Email          string       `json:"email"`
+	FirstName      string       `json:"first_name"`
+	LastName       string       `json:"last_name"`
+	DepartmentID   string       `json:"department_id,omitempty"`
+	DepartmentName string       `json:"department_name,omitempty"`
+	Score          int          `json:"score"`
+	Skills         []SkillMatch `json:"skills"`
+}
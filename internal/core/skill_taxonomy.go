@@ -0,0 +1,44 @@
+package core
+
+import "time"
+
+// SkillCategory is a node of the skill category tree. Children is only
+// filled when the tree is requested.
+type SkillCategory struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	ParentID  string           `json:"parent_id,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	Children  []*SkillCategory `json:"children,omitempty"`
+}
+
+type SkillCategoryCreate struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID string `json:"parent_id"`
+}
+
+// SkillCategoryUpdate renames or moves a category. An empty ParentID
+// moves it to the top level.
+type SkillCategoryUpdate struct {
+	Name     *string `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+type SkillAlias struct {
+	Alias     string    `json:"alias"`
+	SkillID   string    `json:"skill_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SkillMerge struct {
+	SourceIDs []string `json:"source_ids" binding:"required,min=1"`
+}
+
+// SkillMergeResult describes a merge: the surviving skill, the names that
+// became its aliases and how many user skills were moved onto it.
+type SkillMergeResult struct {
+	Skill           Skill    `json:"skill"`
+	MergedNames     []string `json:"merged_names"`
+	UserSkillsMoved int64    `json:"user_skills_moved"`
+}
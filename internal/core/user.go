@@ -12,11 +12,16 @@ type User struct {
 	LastName     string    `json:"last_name" db:"last_name"`
 	DepartmentID string    `json:"department_id" db:"department_id"`
 	PositionID   string    `json:"position_id" db:"position_id"`
+	ManagerID    string    `json:"manager_id,omitempty" db:"manager_id"`
 	HireDate     time.Time `json:"hire_date" db:"hire_date"`
 	Phone        string    `json:"phone" db:"phone"`
 	DateOfBirth  time.Time `json:"date_of_birth" db:"date_of_birth"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
+	EmploymentStatus  string     `json:"employment_status" db:"employment_status"`
+	TerminationDate   *time.Time `json:"termination_date,omitempty" db:"termination_date"`
+	TerminationReason string     `json:"termination_reason,omitempty" db:"termination_reason"`
 }
 
 type UserUpdate struct {
@@ -42,6 +47,7 @@ type UserWithDetails struct {
 	Position    *Position    `json:"position,omitempty"`
 	Address     *Address     `json:"address,omitempty"`
 	Skill      []Skill       `json:"skill,omitempty"`
+	CurrentSalary *Salary    `json:"current_salary,omitempty"`
 }
 
 type UserWithDetailsPagination struct {
@@ -80,3 +86,8 @@ func (us *UserSkill) UnmarshelJSON(data []byte) error {
 	}
 	return nil
 }
+
+// UserIncludes selects optional parts of UserWithDetails.
+type UserIncludes struct {
+	Salary bool
+}
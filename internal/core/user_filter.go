@@ -0,0 +1,55 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// UserFilter narrows the user list and export. Zero values and nil
+// pointers leave a criterion out; ranges are inclusive.
+type UserFilter struct {
+	// Search matches every word against first and last name, email and
+	// phone.
+	Search     string
+	Department string
+	Position   string
+	// Status is an employment status or EmploymentAll; empty hides
+	// terminated employees.
+	Status string
+
+	MinLevel  *int
+	MaxLevel  *int
+	HiredFrom *time.Time
+	HiredTo   *time.Time
+	MinAge    *int
+	MaxAge    *int
+
+	City    string
+	Country string
+
+	// Skill is a skill name or alias, held at MinProficiency or above.
+	Skill          string
+	MinProficiency int
+
+	// MinSalary and MaxSalary compare the current salary converted to
+	// SalaryCurrency at today's rate.
+	MinSalary      *decimal.Decimal
+	MaxSalary      *decimal.Decimal
+	SalaryCurrency string
+
+	Sort []SortField
+}
+
+// SortField orders a list by one of the fields in UserSortFields.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// UserSortFields are the fields users can be sorted by.
+var UserSortFields = []string{
+	"id", "email", "first_name", "last_name", "phone", "date_of_birth", "age",
+	"hire_date", "employment_status", "termination_date", "created_at", "updated_at",
+	"department", "position", "level", "city", "country", "salary",
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+const (
+	ImportValid   = "valid"
+	ImportInvalid = "invalid"
+	ImportCreated = "created"
+)
+
+// UserImportRow is one employee in an import file, as read from a CSV
+// record or a JSON line. Department, position and skills are given by
+// name; dates are YYYY-MM-DD.
+type UserImportRow struct {
+	Email       string            `json:"email"`
+	FirstName   string            `json:"first_name"`
+	LastName    string            `json:"last_name"`
+	Phone       string            `json:"phone"`
+	DateOfBirth string            `json:"date_of_birth"`
+	HireDate    string            `json:"hire_date"`
+	Department  string            `json:"department"`
+	Position    string            `json:"position"`
+	Address     *Address          `json:"address,omitempty"`
+	Salary      *UserImportSalary `json:"salary,omitempty"`
+	Skills      []UserImportSkill `json:"skills,omitempty"`
+}
+
+type UserImportSalary struct {
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	EffectiveDate string          `json:"effective_date"`
+}
+
+type UserImportSkill struct {
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+// UserImport is an import row that passed validation. Department and
+// Position still hold names; they are resolved when the row is written.
+type UserImport struct {
+	Line       int
+	User       User
+	Department string
+	Position   string
+	Address    *Address
+	Salary     *Salary
+	Skills     []UserImportSkill
+}
+
+// UserImportResult reports on one row. Line is the line in the file the
+// row starts on.
+type UserImportResult struct {
+	Line   int          `json:"line"`
+	Email  string       `json:"email"`
+	Status string       `json:"status"`
+	UserID string       `json:"user_id,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// UserImportReport is the outcome of an import. Applied is only true when
+// every row was valid and the import was not a dry run.
+type UserImportReport struct {
+	DryRun  bool               `json:"dry_run"`
+	Applied bool               `json:"applied"`
+	Total   int                `json:"total"`
+	Valid   int                `json:"valid"`
+	Invalid int                `json:"invalid"`
+	Rows    []UserImportResult `json:"rows"`
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"multi-processing-backend/internal/core"
 
@@ -17,6 +19,17 @@ func NewAddressRepository(pool *pgxpool.Pool) *AddressRepository {
 	return &AddressRepository{pool: pool}
 }
 
+const addressColumns = `
+	id, user_id, address_type, street, city, zip_code, country, is_primary, created_at, updated_at
+`
+
+func addressDest(a *core.Address) []any {
+	return []any{
+		&a.ID, &a.UserID, &a.Type, &a.Street, &a.City, &a.ZipCode, &a.Country, &a.IsPrimary,
+		&a.CreatedAt, &a.UpdatedAt,
+	}
+}
+
 func (r *AddressRepository) List(
 	ctx context.Context,
 	page, limit int,
@@ -29,7 +42,7 @@ func (r *AddressRepository) List(
 	}
 
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, user_id, street, city, zip_code, country, is_primary, created_at, updated_at
+		SELECT `+addressColumns+`
 		FROM addresses
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -48,21 +61,104 @@ func (r *AddressRepository) List(
 	return users, total, nil
 }
 
+// ListByUser returns the addresses of a user, primary first.
+func (r *AddressRepository) ListByUser(
+	ctx context.Context,
+	userID string,
+) ([]core.Address, error) {
+	if err := requireExistingUser(ctx, r.pool, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+addressColumns+`
+		FROM addresses
+		WHERE user_id = $1
+		ORDER BY is_primary DESC, address_type, created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.Address])
+}
+
+// lockAddressOwner locks the user row so concurrent changes to the
+// addresses of one user cannot race for the primary flag.
+func lockAddressOwner(ctx context.Context, q querier, userID string) error {
+	var id string
+	err := q.QueryRow(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// demotePrimary clears the primary flag of every other address of a user.
+func demotePrimary(ctx context.Context, q querier, userID, keepID string) error {
+	_, err := q.Exec(ctx, `
+		UPDATE addresses
+		SET is_primary = false, updated_at = NOW()
+		WHERE user_id = $1 AND is_primary AND id::text <> $2
+	`, userID, keepID)
+	return err
+}
+
+// Create adds an address. The first address of a user always becomes the
+// primary one; a later one only when asked for.
 func (r *AddressRepository) Create(
 	ctx context.Context,
 	add core.Address,
 ) (core.Address, error) {
-	query := `
-		INSERT INTO addresses (user_id, street, city, zip_code, country, is_primary)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at, updated_at
-	`
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Address{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockAddressOwner(ctx, tx, add.UserID); err != nil {
+		return core.Address{}, err
+	}
 
-	err := r.pool.QueryRow(ctx, query, add.UserID, add.Street, add.City, add.ZipCode, add.Country, add.IsPrimary).Scan(&add.ID, &add.CreatedAt, &add.UpdatedAt)
+	var hasPrimary bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM addresses WHERE user_id = $1 AND is_primary)
+	`, add.UserID).Scan(&hasPrimary)
 	if err != nil {
 		return core.Address{}, err
 	}
+	add.IsPrimary = add.IsPrimary || !hasPrimary
+	if add.IsPrimary {
+		if err := demotePrimary(ctx, tx, add.UserID, ""); err != nil {
+			return core.Address{}, err
+		}
+	}
 
+	add, err = insertAddress(ctx, tx, add)
+	if err != nil {
+		return core.Address{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.Address{}, err
+	}
+	return add, nil
+}
+
+// insertAddress stores an address as given; callers keep the single
+// primary address rule.
+func insertAddress(ctx context.Context, q querier, add core.Address) (core.Address, error) {
+	err := q.QueryRow(ctx, `
+		INSERT INTO addresses (user_id, address_type, street, city, zip_code, country, is_primary)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+addressColumns,
+		add.UserID, add.Type, add.Street, add.City, add.ZipCode, add.Country, add.IsPrimary,
+	).Scan(addressDest(&add)...)
+	if err != nil {
+		return core.Address{}, err
+	}
 	return add, nil
 }
 
@@ -72,13 +168,14 @@ func (r *AddressRepository) Get(
 ) (core.Address, error) {
 	var add core.Address
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, user_id, street, city, zip_code, country, is_primary, created_at, updated_at
-		FROM addresses 
+		SELECT `+addressColumns+`
+		FROM addresses
 		WHERE id = $1
-	`, id).Scan(
-		&add.ID, &add.UserID, &add.Street, &add.City, &add.ZipCode, &add.Country, &add.IsPrimary, &add.CreatedAt, &add.UpdatedAt,
-	)
+	`, id).Scan(addressDest(&add)...)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Address{}, fmt.Errorf("address not found")
+		}
 		return core.Address{}, err
 	}
 	return add, nil
@@ -89,25 +186,123 @@ func (r *AddressRepository) Update(
 	id string,
 	update core.AddressUpdate,
 ) (core.Address, error) {
-	var add core.Address
-	err := r.pool.QueryRow(ctx, `
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Address{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	add, err := lockAddress(ctx, tx, id)
+	if err != nil {
+		return core.Address{}, err
+	}
+
+	if update.Type != nil {
+		add.Type = *update.Type
+	}
+	if update.Street != nil {
+		add.Street = *update.Street
+	}
+	if update.City != nil {
+		add.City = *update.City
+	}
+	if update.ZipCode != nil {
+		add.ZipCode = *update.ZipCode
+	}
+	if update.Country != nil {
+		add.Country = *update.Country
+	}
+	if update.IsPrimary != nil {
+		if !*update.IsPrimary && add.IsPrimary {
+			return core.Address{}, fmt.Errorf("is_primary must not be cleared, make another address primary instead")
+		}
+		if *update.IsPrimary && !add.IsPrimary {
+			if err := demotePrimary(ctx, tx, add.UserID, id); err != nil {
+				return core.Address{}, err
+			}
+			add.IsPrimary = true
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
 		UPDATE addresses
-		SET user_id = $1, street = $2, city = $3, zip_code = $4, country = $5, is_primary = true, updated_at = NOW()
-		WHERE id = $6
-		RETURNING id, user_id, street, city, zip_code, country, is_primary, created_at, updated_at
-	`,
-		update.UserID, update.Street, update.City, update.ZipCode, update.Country, id).Scan(
-		&add.ID, &add.UserID, &add.Street, &add.City, &add.ZipCode,
-		&add.Country, &add.IsPrimary, &add.CreatedAt, &add.UpdatedAt,
-	)
+		SET address_type = $1, street = $2, city = $3, zip_code = $4, country = $5, is_primary = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING `+addressColumns,
+		add.Type, add.Street, add.City, add.ZipCode, add.Country, add.IsPrimary, id,
+	).Scan(addressDest(&add)...)
 	if err != nil {
 		return core.Address{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return core.Address{}, err
+	}
 	return add, nil
 }
 
+// MakePrimary moves the primary flag of the owner's addresses to id in one
+// transaction.
+func (r *AddressRepository) MakePrimary(ctx context.Context, id string) (core.Address, error) {
+	primary := true
+	return r.Update(ctx, id, core.AddressUpdate{IsPrimary: &primary})
+}
+
+// Delete removes an address. When it was the primary one the oldest
+// remaining address of the user takes over.
 func (r *AddressRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
-	return err
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	add, err := lockAddress(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM addresses WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	if add.IsPrimary {
+		_, err := tx.Exec(ctx, `
+			UPDATE addresses
+			SET is_primary = true, updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM addresses WHERE user_id = $1 ORDER BY created_at, id LIMIT 1
+			)
+		`, add.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockAddress loads an address and locks its owner.
+func lockAddress(ctx context.Context, q querier, id string) (core.Address, error) {
+	var add core.Address
+	err := q.QueryRow(ctx, `SELECT `+addressColumns+` FROM addresses WHERE id = $1`, id).Scan(addressDest(&add)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Address{}, fmt.Errorf("address not found")
+		}
+		return core.Address{}, err
+	}
+	if err := lockAddressOwner(ctx, q, add.UserID); err != nil {
+		return core.Address{}, err
+	}
+
+	// Re-read under the lock in case the primary moved meanwhile.
+	err = q.QueryRow(ctx, `SELECT `+addressColumns+` FROM addresses WHERE id = $1`, id).Scan(addressDest(&add)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Address{}, fmt.Errorf("address not found")
+		}
+		return core.Address{}, err
+	}
+	return add, nil
 }
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// auditStamps remembers which actor and request each pooled connection was
+// last stamped with. Every request carries its own request ID, so in
+// practice each acquire made while handling a request costs one extra
+// set_config round trip; only runs of background work on the same
+// connection skip it.
+var auditStamps sync.Map // *pgx.Conn -> core.AuditInfo
+
+// stampAuditInfo copies the actor and request ID of ctx into the audit.*
+// settings of a connection as it is acquired, where the audit triggers of
+// migration 022 read them. Connections used without audit info are reset,
+// so changes made by background jobs are recorded as "system".
+func stampAuditInfo(ctx context.Context, conn *pgx.Conn) (bool, error) {
+	info, _ := core.AuditInfoFrom(ctx)
+	last, _ := auditStamps.Load(conn)
+	if (last == nil && info == core.AuditInfo{}) || last == info {
+		return true, nil
+	}
+
+	_, err := conn.Exec(ctx, `
+		SELECT set_config('audit.actor', $1, false), set_config('audit.request_id', $2, false)
+	`, info.Actor, info.RequestID)
+	if err != nil {
+		auditStamps.Delete(conn)
+		return false, err
+	}
+	auditStamps.Store(conn, info)
+	return true, nil
+}
+
+func forgetAuditStamp(conn *pgx.Conn) {
+	auditStamps.Delete(conn)
+}
+
+type AuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+// List returns audit entries matching f, newest first.
+func (r *AuditRepository) List(
+	ctx context.Context,
+	f core.AuditFilter,
+	page, limit int,
+) ([]core.AuditEntry, int64, error) {
+	offset := (page - 1) * limit
+
+	var where []string
+	var params []any
+	add := func(cond string, v any) {
+		params = append(params, v)
+		where = append(where, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(params))))
+	}
+	if f.Entity != "" {
+		add("entity = ?", f.Entity)
+	}
+	if f.EntityID != "" {
+		add("entity_id = ?", f.EntityID)
+	}
+	if f.Actor != "" {
+		add("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.RequestID != "" {
+		add("request_id = ?", f.RequestID)
+	}
+	if !f.From.IsZero() {
+		add("occurred_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		add("occurred_at < ?", f.To)
+	}
+
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM audit_log `+filter, params...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, occurred_at, actor, request_id, entity, entity_id, action, before, after, changes
+		FROM audit_log
+		`+filter+`
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $`+strconv.Itoa(len(params)+1)+` OFFSET $`+strconv.Itoa(len(params)+2),
+		append(params, limit, offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByPos[core.AuditEntry])
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/exp/slog"
+)
+
+// ensureDepartmentChannel returns the private channel of a department and
+// creates it on first use. Department channels are owned by the admin user.
+func ensureDepartmentChannel(ctx context.Context, q querier, departmentID string) (string, error) {
+	_, err := q.Exec(ctx, `
+		INSERT INTO forum_channels (name, description, is_private, is_direct_message, created_by, department_id, created_at)
+		SELECT d.name, COALESCE(d.description, ''), true, false,
+			(SELECT id FROM forum_users WHERE username = 'admin'), d.id, NOW()
+		FROM departments d
+		WHERE d.id = $1
+		ON CONFLICT (department_id) DO NOTHING
+	`, departmentID)
+	if err != nil {
+		return "", err
+	}
+
+	var channelID string
+	err = q.QueryRow(ctx, `
+		SELECT id FROM forum_channels WHERE department_id = $1
+	`, departmentID).Scan(&channelID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("department not found")
+		}
+		return "", err
+	}
+	return channelID, nil
+}
+
+// renameDepartmentChannel keeps the channel name in line with the department
+// and posts a channel_renamed system message when it changes.
+func renameDepartmentChannel(ctx context.Context, q querier, departmentID, name string) error {
+	var channelID, oldName string
+	err := q.QueryRow(ctx, `
+		SELECT id, name FROM forum_channels WHERE department_id = $1
+	`, departmentID).Scan(&channelID, &oldName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			_, err = ensureDepartmentChannel(ctx, q, departmentID)
+		}
+		return err
+	}
+	if oldName == name {
+		return nil
+	}
+
+	if _, err := q.Exec(ctx, `UPDATE forum_channels SET name = $1 WHERE id = $2`, name, channelID); err != nil {
+		return err
+	}
+
+	adminID, err := forumAdminID(ctx, q)
+	if err != nil || adminID == "" {
+		return err
+	}
+	_, err = insertSystemMessage(ctx, q, channelID, core.ForumSystemEvent{
+		Event:    core.SystemEventChannelRenamed,
+		ActorID:  adminID,
+		OldValue: oldName,
+		NewValue: name,
+	})
+	return err
+}
+
+// syncDepartmentChannels puts a forum user into the channel of the department
+// of its linked employee record and removes it from every other department
+// channel. Unlinked and deactivated accounts end up in none.
+func syncDepartmentChannels(ctx context.Context, q querier, forumUserID string) (joined, left int, err error) {
+	var departmentID sql.NullString
+	err = q.QueryRow(ctx, `
+		SELECT CASE WHEN fu.is_active THEN u.department_id::text END
+		FROM forum_users fu
+		LEFT JOIN users u ON u.id = fu.hr_user_id
+		WHERE fu.id = $1
+	`, forumUserID).Scan(&departmentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+
+	rows, err := q.Query(ctx, `
+		DELETE FROM channel_members cm
+		USING forum_channels fc
+		WHERE cm.channel_id = fc.id
+			AND cm.user_id = $1
+			AND fc.department_id IS NOT NULL
+			AND fc.department_id IS DISTINCT FROM $2::uuid
+		RETURNING cm.channel_id
+	`, forumUserID, departmentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	leftChannels, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, channelID := range leftChannels {
+		_, err := insertSystemMessage(ctx, q, channelID, core.ForumSystemEvent{
+			Event:   core.SystemEventMemberLeft,
+			ActorID: forumUserID,
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	left = len(leftChannels)
+
+	if !departmentID.Valid {
+		return 0, left, nil
+	}
+
+	channelID, err := ensureDepartmentChannel(ctx, q, departmentID.String)
+	if err != nil {
+		return 0, left, err
+	}
+
+	tag, err := q.Exec(ctx, `
+		INSERT INTO channel_members (channel_id, user_id, role, joined_at)
+		VALUES ($1, $2, 'member', NOW())
+		ON CONFLICT (channel_id, user_id) DO NOTHING
+	`, channelID, forumUserID)
+	if err != nil {
+		return 0, left, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, left, nil
+	}
+
+	_, err = insertSystemMessage(ctx, q, channelID, core.ForumSystemEvent{
+		Event:   core.SystemEventMemberJoined,
+		ActorID: forumUserID,
+	})
+	if err != nil {
+		return 0, left, err
+	}
+	return 1, left, nil
+}
+
+// syncDepartmentChannelsForEmployee runs syncDepartmentChannels for the forum
+// account linked to an employee record, if there is one.
+func syncDepartmentChannelsForEmployee(ctx context.Context, q querier, hrUserID string) error {
+	var forumUserID string
+	err := q.QueryRow(ctx, `
+		SELECT id FROM forum_users WHERE hr_user_id = $1
+	`, hrUserID).Scan(&forumUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	_, _, err = syncDepartmentChannels(ctx, q, forumUserID)
+	return err
+}
+
+func forumAdminID(ctx context.Context, q querier) (string, error) {
+	var id string
+	err := q.QueryRow(ctx, `SELECT id FROM forum_users WHERE username = 'admin'`).Scan(&id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	return id, nil
+}
+
+// ReconcileDepartmentChannels repairs drift between departments, employee
+// assignments and channel membership, e.g. after bulk imports that bypass
+// the repositories.
+func (r *ForumUserRepository) ReconcileDepartmentChannels(
+	ctx context.Context,
+) (core.DepartmentChannelSync, error) {
+	var result core.DepartmentChannelSync
+
+	rows, err := r.pool.Query(ctx, `SELECT id, name FROM departments`)
+	if err != nil {
+		return result, err
+	}
+	type department struct{ id, name string }
+	departments, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (department, error) {
+		var d department
+		err := row.Scan(&d.id, &d.name)
+		return d, err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for _, d := range departments {
+		err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+			return renameDepartmentChannel(ctx, tx, d.id, d.name)
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	result.Channels = len(departments)
+
+	rows, err = r.pool.Query(ctx, `
+		SELECT id FROM forum_users WHERE hr_user_id IS NOT NULL
+		UNION
+		SELECT cm.user_id
+		FROM channel_members cm
+		JOIN forum_channels fc ON fc.id = cm.channel_id
+		WHERE fc.department_id IS NOT NULL
+	`)
+	if err != nil {
+		return result, err
+	}
+	userIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return result, err
+	}
+
+	for _, id := range userIDs {
+		var joined, left int
+		err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+			var err error
+			joined, left, err = syncDepartmentChannels(ctx, tx, id)
+			return err
+		})
+		if err != nil {
+			slog.Warn("failed to sync department channels", "userID", id, "error", err)
+			continue
+		}
+		result.Joined += joined
+		result.Left += left
+	}
+
+	return result, nil
+}
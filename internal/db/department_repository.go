@@ -22,15 +22,7 @@ func (r *DepartmentRepository) List(
 	ctx context.Context,
 	searchName string,
 ) ([]core.Departments, int64, error) {
-	var whereClause string
-	params := []any{}
-	paramCount := 0
-
-	if searchName != "" {
-		paramCount++
-		whereClause += "WHERE (d.name ILIKE $1 OR d.description ILIKE $1)"
-		params = append(params, "%"+searchName+"%")
-	}
+	whereClause, params := departmentListFilter(searchName)
 
 	countQuery := "SELECT COUNT(*) FROM departments d"
 	if whereClause != "" {
@@ -62,6 +54,14 @@ func (r *DepartmentRepository) List(
 	return deps, total, nil
 }
 
+// departmentListFilter builds the WHERE clause shared by List and Export.
+func departmentListFilter(searchName string) (string, []any) {
+	if searchName == "" {
+		return "", []any{}
+	}
+	return "WHERE (d.name ILIKE $1 OR d.description ILIKE $1)", []any{"%" + searchName + "%"}
+}
+
 func (r *DepartmentRepository) Create(
 	ctx context.Context,
 	d core.Departments,
@@ -72,10 +72,24 @@ func (r *DepartmentRepository) Create(
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.pool.QueryRow(ctx, query, d.Name, d.Description).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return core.Departments{}, err
 	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, d.Name, d.Description).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return core.Departments{}, err
+	}
+
+	if _, err := ensureDepartmentChannel(ctx, tx, d.ID); err != nil {
+		return core.Departments{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.Departments{}, err
+	}
 
 	return d, nil
 }
@@ -122,14 +136,28 @@ func (r *DepartmentRepository) Update(
 		d.Name = *update.Name
 	}
 
-	_, err = r.pool.Exec(ctx, `
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Departments{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
 		UPDATE departments
-		SET name = $1, description = $2
+		SET name = $1, description = $2, updated_at = NOW()
 		WHERE id = $3
 	`, d.Name, d.Description, d.ID)
 
 	if err != nil {
-		return core.Departments{}, nil
+		return core.Departments{}, err
+	}
+
+	if err := renameDepartmentChannel(ctx, tx, d.ID, d.Name); err != nil {
+		return core.Departments{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.Departments{}, err
 	}
 
 	return d, nil
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// departmentFilter restricts users u to the department in $1, or keeps
+// everyone when $1 is empty.
+const departmentFilter = `($1::text = '' OR u.department_id::text = $1::text)`
+
+// currentStaff leaves out offboarded users u. Hiring history still counts
+// them.
+const currentStaff = `u.employment_status <> 'terminated'`
+
+// Stats aggregates headcount, hiring, tenure, pay, levels and skills for a
+// department, or the whole company when departmentID is empty. Salaries are
+// converted into currency at today's rates.
+func (r *DepartmentRepository) Stats(
+	ctx context.Context,
+	departmentID, currency string,
+	months int,
+) (core.DepartmentStats, error) {
+	if departmentID != "" {
+		var exists bool
+		err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM departments WHERE id::text = $1)`, departmentID).Scan(&exists)
+		if err != nil {
+			return core.DepartmentStats{}, err
+		}
+		if !exists {
+			return core.DepartmentStats{}, fmt.Errorf("department not found")
+		}
+	}
+
+	stats := core.DepartmentStats{
+		DepartmentID: departmentID,
+		GeneratedAt:  time.Now(),
+	}
+
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*),
+			COALESCE(ROUND(AVG(CURRENT_DATE - u.hire_date) / 365.25, 2), 0)::float8,
+			COALESCE(ROUND((PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY CURRENT_DATE - u.hire_date) / 365.25)::numeric, 2), 0)::float8
+		FROM users u
+		WHERE `+departmentFilter+` AND `+currentStaff,
+		departmentID,
+	).Scan(&stats.Headcount, &stats.AverageTenureYears, &stats.MedianTenureYears)
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT TO_CHAR(m, 'YYYY-MM'), COUNT(u.id)
+		FROM GENERATE_SERIES(
+			DATE_TRUNC('month', CURRENT_DATE) - ($2::int - 1) * INTERVAL '1 month',
+			DATE_TRUNC('month', CURRENT_DATE),
+			INTERVAL '1 month'
+		) m
+		LEFT JOIN users u ON DATE_TRUNC('month', u.hire_date) = m AND `+departmentFilter+`
+		GROUP BY m
+		ORDER BY m
+	`, departmentID, months)
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+	stats.HiresPerMonth, err = pgx.CollectRows(rows, pgx.RowToStructByPos[core.MonthlyHires])
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+
+	// PERCENTILE_DISC keeps the exact amounts instead of interpolating in
+	// floating point, so the median is the lower middle value.
+	stats.Salaries.Currency = currency
+	err = r.pool.QueryRow(ctx, `
+		WITH current_salaries AS (
+			SELECT cs.amount * exchange_rate_on($2, CURRENT_DATE) / exchange_rate_on(cs.currency, CURRENT_DATE) AS amount
+			FROM users u
+			JOIN LATERAL (
+				SELECT amount, currency
+				FROM salaries s
+				WHERE s.user_id = u.id AND s.effective_date <= CURRENT_DATE
+				ORDER BY s.effective_date DESC, s.created_at DESC
+				LIMIT 1
+			) cs ON true
+			WHERE `+departmentFilter+` AND `+currentStaff+`
+		)
+		SELECT COUNT(amount), COUNT(*) - COUNT(amount),
+			ROUND(MIN(amount), 2),
+			ROUND(PERCENTILE_DISC(0.5) WITHIN GROUP (ORDER BY amount), 2),
+			ROUND(PERCENTILE_DISC(0.9) WITHIN GROUP (ORDER BY amount), 2)
+		FROM current_salaries
+	`, departmentID, currency).Scan(
+		&stats.Salaries.Employees, &stats.Salaries.Unconverted,
+		&stats.Salaries.Min, &stats.Salaries.Median, &stats.Salaries.P90,
+	)
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+
+	rows, err = r.pool.Query(ctx, `
+		SELECT p.level, COUNT(*)
+		FROM users u
+		JOIN positions p ON p.id = u.position_id
+		WHERE `+departmentFilter+` AND `+currentStaff+`
+		GROUP BY p.level
+		ORDER BY p.level
+	`, departmentID)
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+	stats.PositionLevels, err = pgx.CollectRows(rows, pgx.RowToStructByPos[core.LevelCount])
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+
+	rows, err = r.pool.Query(ctx, `
+		SELECT s.id, s.name, s.category, COUNT(*), ROUND(AVG(us.proficiency_level), 2)::float8
+		FROM user_skills us
+		JOIN users u ON u.id = us.user_id
+		JOIN skills s ON s.id = us.skill_id
+		WHERE `+departmentFilter+` AND `+currentStaff+`
+		GROUP BY s.id, s.name, s.category
+		ORDER BY COUNT(*) DESC, AVG(us.proficiency_level) DESC, s.name
+		LIMIT 10
+	`, departmentID)
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+	stats.TopSkills, err = pgx.CollectRows(rows, pgx.RowToStructByPos[core.SkillCount])
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const userColumns = `
+	id, email, first_name, last_name, department_id, position_id,
+	COALESCE(manager_id::text, ''), hire_date, phone, date_of_birth, created_at, updated_at,
+	employment_status, termination_date, COALESCE(termination_reason, '')
+`
+
+func userDest(u *core.User) []any {
+	return []any{
+		&u.ID, &u.Email, &u.FirstName, &u.LastName, &u.DepartmentID, &u.PositionID,
+		&u.ManagerID, &u.HireDate, &u.Phone, &u.DateOfBirth, &u.CreatedAt, &u.UpdatedAt,
+		&u.EmploymentStatus, &u.TerminationDate, &u.TerminationReason,
+	}
+}
+
+// lockEmployee locks a user row and returns the employment status, hire
+// date and termination date.
+func lockEmployee(ctx context.Context, q querier, id string) (string, time.Time, *time.Time, error) {
+	var status string
+	var hireDate time.Time
+	var terminationDate *time.Time
+	err := q.QueryRow(ctx, `
+		SELECT employment_status, hire_date, termination_date FROM users WHERE id = $1 FOR UPDATE
+	`, id).Scan(&status, &hireDate, &terminationDate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", time.Time{}, nil, fmt.Errorf("user not found")
+		}
+		return "", time.Time{}, nil, err
+	}
+	return status, hireDate, terminationDate, nil
+}
+
+// requireEmployedManager checks that a would-be manager exists and has not
+// left the company.
+func requireEmployedManager(ctx context.Context, q querier, id string) error {
+	var status string
+	err := q.QueryRow(ctx, `SELECT employment_status FROM users WHERE id = $1`, id).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("manager not found")
+		}
+		return err
+	}
+	if status == core.EmploymentTerminated {
+		return fmt.Errorf("%w: manager has been offboarded", core.ErrEmploymentState)
+	}
+	return nil
+}
+
+// Offboard terminates an employment. The record, salaries, addresses and
+// skills are kept; direct reports are handed over as on delete, the
+// workplace assignment is released, the linked forum account is deactivated
+// and the stint is written to employment_periods.
+func (r *UserRepository) Offboard(
+	ctx context.Context,
+	id string,
+	in core.Offboarding,
+) (core.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	status, hireDate, _, err := lockEmployee(ctx, tx, id)
+	if err != nil {
+		return core.User{}, err
+	}
+	if status == core.EmploymentTerminated {
+		return core.User{}, fmt.Errorf("%w: user is already terminated", core.ErrEmploymentState)
+	}
+	if in.TerminationDate.Before(hireDate) {
+		return core.User{}, fmt.Errorf("termination_date must not be before the hire date")
+	}
+
+	if in.ReassignTo != "" && in.ReassignTo != core.ReassignToManager {
+		if err := requireEmployedManager(ctx, tx, in.ReassignTo); err != nil {
+			return core.User{}, err
+		}
+	}
+	if err := reassignDirectReports(ctx, tx, id, in.ReassignTo); err != nil {
+		return core.User{}, err
+	}
+
+	var user core.User
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET employment_status = 'terminated', termination_date = $2, termination_reason = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+userColumns,
+		id, in.TerminationDate, in.Reason,
+	).Scan(userDest(&user)...)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO employment_periods (user_id, hire_date, termination_date, reason)
+		VALUES ($1, $2, $3, $4)
+	`, id, hireDate, in.TerminationDate, in.Reason)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	if err := closePositionAssignment(ctx, tx, id, in.TerminationDate); err != nil {
+		return core.User{}, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_workplaces WHERE user_id = $1`, id); err != nil {
+		return core.User{}, err
+	}
+
+	if err := deactivateLinkedForumUser(ctx, tx, id); err != nil {
+		return core.User{}, err
+	}
+	if err := syncDepartmentChannelsForEmployee(ctx, tx, id); err != nil {
+		return core.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.User{}, err
+	}
+	return user, nil
+}
+
+// Rehire starts a new employment on the record of a terminated user. The
+// forum account stays deactivated until a forum admin turns it back on.
+func (r *UserRepository) Rehire(
+	ctx context.Context,
+	id string,
+	in core.Rehire,
+) (core.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, terminationDate, err := lockEmployee(ctx, tx, id)
+	if err != nil {
+		return core.User{}, err
+	}
+	if status != core.EmploymentTerminated {
+		return core.User{}, fmt.Errorf("%w: only terminated users can be rehired", core.ErrEmploymentState)
+	}
+	if !in.HireDate.After(*terminationDate) {
+		return core.User{}, fmt.Errorf("hire_date must be after the last termination date")
+	}
+
+	if in.ManagerID != "" {
+		if err := lockReportingLines(ctx, tx); err != nil {
+			return core.User{}, err
+		}
+		if err := requireEmployedManager(ctx, tx, in.ManagerID); err != nil {
+			return core.User{}, err
+		}
+	}
+
+	var user core.User
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET employment_status = 'active', termination_date = NULL, termination_reason = NULL,
+			hire_date = $2,
+			department_id = COALESCE(NULLIF($3, '')::uuid, department_id),
+			position_id = COALESCE(NULLIF($4, '')::uuid, position_id),
+			manager_id = NULLIF($5, '')::uuid,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+userColumns,
+		id, in.HireDate, in.DepartmentID, in.PositionID, in.ManagerID,
+	).Scan(userDest(&user)...)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	if _, err := syncPositionAssignment(ctx, tx, id, in.HireDate, core.AssignmentRehire, "", ""); err != nil {
+		return core.User{}, err
+	}
+	if err := syncDepartmentChannelsForEmployee(ctx, tx, id); err != nil {
+		return core.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.User{}, err
+	}
+	return user, nil
+}
+
+func (r *UserRepository) SetEmploymentStatus(
+	ctx context.Context,
+	id, status string,
+) (core.User, error) {
+	var user core.User
+	err := r.pool.QueryRow(ctx, `
+		UPDATE users
+		SET employment_status = $2, updated_at = NOW()
+		WHERE id = $1 AND employment_status <> 'terminated'
+		RETURNING `+userColumns,
+		id, status,
+	).Scan(userDest(&user)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := r.requireUser(ctx, id); err != nil {
+				return core.User{}, err
+			}
+			return core.User{}, fmt.Errorf("%w: user is terminated, rehire them instead", core.ErrEmploymentState)
+		}
+		return core.User{}, err
+	}
+	return user, nil
+}
+
+// ListEmploymentPeriods returns the completed stints of a user, oldest
+// first.
+func (r *UserRepository) ListEmploymentPeriods(
+	ctx context.Context,
+	id string,
+) ([]core.EmploymentPeriod, error) {
+	if err := r.requireUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, user_id, hire_date, termination_date, reason, created_at
+		FROM employment_periods
+		WHERE user_id = $1
+		ORDER BY hire_date
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.EmploymentPeriod])
+}
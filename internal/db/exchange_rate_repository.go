@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type ExchangeRateRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewExchangeRateRepository(pool *pgxpool.Pool) *ExchangeRateRepository {
+	return &ExchangeRateRepository{pool: pool}
+}
+
+// Upsert stores the given rates, replacing any rate already recorded for
+// the same currency and day.
+func (r *ExchangeRateRepository) Upsert(
+	ctx context.Context,
+	rates []core.ExchangeRate,
+) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, rate := range rates {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO exchange_rates (currency, rate_date, rate)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (currency, rate_date)
+			DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()
+			WHERE exchange_rates.rate <> EXCLUDED.rate
+		`, rate.Currency, rate.RateDate, rate.Rate)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// List returns the rate in effect on date for every known currency.
+func (r *ExchangeRateRepository) List(
+	ctx context.Context,
+	date time.Time,
+) ([]core.ExchangeRate, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT ON (currency) currency, rate_date, rate, updated_at
+		FROM exchange_rates
+		WHERE rate_date <= $1::date
+		ORDER BY currency, rate_date DESC
+	`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.ExchangeRate])
+}
+
+// RateOn returns how many units of currency one unit of the pivot currency
+// bought on date.
+func (r *ExchangeRateRepository) RateOn(
+	ctx context.Context,
+	currency string,
+	date time.Time,
+) (decimal.Decimal, error) {
+	if currency == core.PivotCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	var rate decimal.Decimal
+	err := r.pool.QueryRow(ctx, `
+		SELECT rate
+		FROM exchange_rates
+		WHERE currency = $1 AND rate_date <= $2::date
+		ORDER BY rate_date DESC
+		LIMIT 1
+	`, currency, date).Scan(&rate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return decimal.Decimal{}, fmt.Errorf("%w for %s on %s", core.ErrNoExchangeRate, currency, date.Format(time.DateOnly))
+		}
+		return decimal.Decimal{}, err
+	}
+	return rate, nil
+}
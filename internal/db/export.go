@@ -0,0 +1,261 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// exportBatchSize is how many rows are fetched from the cursor at a time.
+const exportBatchSize = 1000
+
+// exportColumn maps an export column to the SQL expression producing it.
+type exportColumn struct {
+	core.ExportColumn
+	expr string
+}
+
+func exportText(name, expr string) exportColumn {
+	return exportColumn{core.ExportColumn{Name: name, Kind: core.ExportText}, expr}
+}
+
+func exportNumber(name, expr string) exportColumn {
+	return exportColumn{core.ExportColumn{Name: name, Kind: core.ExportNumber}, expr}
+}
+
+func exportDate(name, expr string) exportColumn {
+	return exportColumn{core.ExportColumn{Name: name, Kind: core.ExportDate}, expr}
+}
+
+func exportTimestamp(name, expr string) exportColumn {
+	return exportText(name, `to_char(`+expr+` AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`)
+}
+
+// sensitive marks a column that needs elevated permission.
+func (c exportColumn) sensitive() exportColumn {
+	c.Sensitive = true
+	return c
+}
+
+func exportColumnList(columns []exportColumn) []core.ExportColumn {
+	list := make([]core.ExportColumn, len(columns))
+	for i, c := range columns {
+		list[i] = c.ExportColumn
+	}
+	return list
+}
+
+// exportSelect builds the select list for the named columns. Every value
+// comes back as text, empty for NULL.
+func exportSelect(available []exportColumn, names []string) (string, error) {
+	exprs := make([]string, len(names))
+	for i, name := range names {
+		found := false
+		for _, c := range available {
+			if c.Name == name {
+				exprs[i] = `COALESCE((` + c.expr + `)::text, '')`
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("column %q is not available", name)
+		}
+	}
+	return strings.Join(exprs, ", "), nil
+}
+
+// streamRows runs query through a server-side cursor in a read-only
+// transaction and passes every row to fn, fetching exportBatchSize rows at
+// a time so exports of any size need constant memory. The record slice is
+// reused between calls.
+func streamRows(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	width int,
+	query string,
+	args []any,
+	fn func(record []string) error,
+) error {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DECLARE export_cursor NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		return err
+	}
+
+	record := make([]string, width)
+	dest := make([]any, width)
+	for i := range record {
+		dest[i] = &record[i]
+	}
+
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM export_cursor`, exportBatchSize))
+		if err != nil {
+			return err
+		}
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return err
+			}
+			if err := fn(record); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if fetched < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+var userExportColumns = []exportColumn{
+	exportText("id", "u.id"),
+	exportText("email", "u.email"),
+	exportText("first_name", "u.first_name"),
+	exportText("last_name", "u.last_name"),
+	exportText("phone", "u.phone"),
+	exportDate("date_of_birth", "u.date_of_birth"),
+	exportDate("hire_date", "u.hire_date"),
+	exportText("employment_status", "u.employment_status"),
+	exportDate("termination_date", "u.termination_date"),
+	exportText("department", "d.name"),
+	exportText("position", "p.title"),
+	exportNumber("level", "p.level"),
+	exportText("manager_email", "m.email"),
+	exportText("street", "a.street"),
+	exportText("city", "a.city"),
+	exportText("zip_code", "a.zip_code"),
+	exportText("country", "a.country"),
+	exportNumber("salary_amount", "cs.amount").sensitive(),
+	exportText("salary_currency", "cs.currency").sensitive(),
+	exportDate("salary_effective_date", "cs.effective_date").sensitive(),
+}
+
+func (r *UserRepository) ExportColumns() []core.ExportColumn {
+	return exportColumnList(userExportColumns)
+}
+
+// Export streams the named columns for the users List would return for
+// filter, oldest hire first unless filter sorts otherwise.
+func (r *UserRepository) Export(
+	ctx context.Context,
+	filter core.UserFilter,
+	columns []string,
+	fn func(record []string) error,
+) error {
+	selectList, err := exportSelect(userExportColumns, columns)
+	if err != nil {
+		return err
+	}
+	orderBy, err := userListOrder(filter.Sort, "u.hire_date, u.last_name, u.first_name")
+	if err != nil {
+		return err
+	}
+
+	// The salary join only runs when a salary column was picked or the
+	// filter needs it.
+	withSalary := userFilterNeedsSalary(filter) || slices.ContainsFunc(columns, func(name string) bool {
+		return strings.HasPrefix(name, "salary_")
+	})
+	f := userListFilter(filter)
+	from := userListFrom(f.bind(withSalary))
+
+	return streamRows(ctx, r.pool, len(columns), `
+		SELECT `+selectList+`
+		`+from+`
+		LEFT JOIN users m ON m.id = u.manager_id
+		`+f.clause()+`
+		`+orderBy+`
+	`, f.args, fn)
+}
+
+var salaryExportColumns = []exportColumn{
+	exportText("id", "s.id"),
+	exportText("user_id", "s.user_id"),
+	exportText("email", "u.email"),
+	exportText("first_name", "u.first_name"),
+	exportText("last_name", "u.last_name"),
+	exportText("department", "d.name"),
+	exportText("position", "p.title"),
+	exportNumber("amount", "s.amount").sensitive(),
+	exportText("currency", "s.currency").sensitive(),
+	exportDate("effective_date", "s.effective_date").sensitive(),
+	exportText("out_of_band", "s.out_of_band").sensitive(),
+	exportTimestamp("created_at", "s.created_at"),
+}
+
+func (r *SalaryRepository) ExportColumns() []core.ExportColumn {
+	return exportColumnList(salaryExportColumns)
+}
+
+// Export streams the named columns for every salary, grouped by user.
+func (r *SalaryRepository) Export(
+	ctx context.Context,
+	columns []string,
+	fn func(record []string) error,
+) error {
+	selectList, err := exportSelect(salaryExportColumns, columns)
+	if err != nil {
+		return err
+	}
+	return streamRows(ctx, r.pool, len(columns), `
+		SELECT `+selectList+`
+		FROM salaries s
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN departments d ON d.id = u.department_id
+		LEFT JOIN positions p ON p.id = u.position_id
+		ORDER BY u.last_name, u.first_name, s.user_id, s.effective_date
+	`, nil, fn)
+}
+
+var departmentExportColumns = []exportColumn{
+	exportText("id", "d.id"),
+	exportText("name", "d.name"),
+	exportText("description", "d.description"),
+	exportNumber("headcount", `(SELECT COUNT(*) FROM users u WHERE u.department_id = d.id AND `+currentStaff+`)`),
+	exportTimestamp("created_at", "d.created_at"),
+	exportTimestamp("updated_at", "d.updated_at"),
+}
+
+func (r *DepartmentRepository) ExportColumns() []core.ExportColumn {
+	return exportColumnList(departmentExportColumns)
+}
+
+// Export streams the named columns for the departments List would return
+// for searchName, by name.
+func (r *DepartmentRepository) Export(
+	ctx context.Context,
+	searchName string,
+	columns []string,
+	fn func(record []string) error,
+) error {
+	selectList, err := exportSelect(departmentExportColumns, columns)
+	if err != nil {
+		return err
+	}
+	whereClause, params := departmentListFilter(searchName)
+	return streamRows(ctx, r.pool, len(columns), `
+		SELECT `+selectList+`
+		FROM departments d
+		`+whereClause+`
+		ORDER BY d.name
+	`, params, fn)
+}
@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Wrong codes count against the user for emailVerificationWindow, however
+// many new codes they ask for in that time. created_at marks the start of
+// the window.
+const (
+	maxEmailVerificationAttempts = 5
+	emailVerificationWindow      = time.Hour
+)
+
+// forumMemberSelect joins a forum user with the department and position of
+// the linked employee record. Unlinked users get empty strings.
+const forumMemberSelect = `
+	SELECT fu.id, fu.email, fu.username, fu.display_name, fu.avatar_url,
+		fu.is_online, fu.last_seen, fu.created_at, fu.updated_at,
+		COALESCE(d.name, ''), COALESCE(p.title, '')
+	FROM forum_users fu
+	LEFT JOIN users u ON u.id = fu.hr_user_id
+	LEFT JOIN departments d ON d.id = u.department_id
+	LEFT JOIN positions p ON p.id = u.position_id
+`
+
+func scanForumMembers(rows pgx.Rows) ([]core.ForumMember, error) {
+	defer rows.Close()
+
+	members := []core.ForumMember{}
+	for rows.Next() {
+		var m core.ForumMember
+		err := rows.Scan(
+			&m.ID, &m.Email, &m.Username, &m.DisplayName, &m.AvatarUrl,
+			&m.IsOnline, &m.LastSeen, &m.CreatedAt, &m.UpdatedAt,
+			&m.Department, &m.Position,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (r *ForumUserRepository) CreateEmailVerification(
+	ctx context.Context,
+	userID, codeHash string,
+	expiresAt time.Time,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO forum_email_verifications (user_id, code_hash, attempts, expires_at, created_at)
+		SELECT id, $2, 0, $3, NOW()
+		FROM forum_users
+		WHERE id = $1
+		ON CONFLICT (user_id) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash,
+			expires_at = EXCLUDED.expires_at,
+			attempts = CASE WHEN forum_email_verifications.created_at > $4
+				THEN forum_email_verifications.attempts ELSE 0 END,
+			created_at = CASE WHEN forum_email_verifications.created_at > $4
+				THEN forum_email_verifications.created_at ELSE NOW() END
+	`, userID, codeHash, expiresAt, time.Now().Add(-emailVerificationWindow))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+func (r *ForumUserRepository) ConfirmEmailVerification(
+	ctx context.Context,
+	userID, codeHash string,
+) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var storedHash string
+	var attempts int
+	var expiresAt time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT code_hash, attempts, expires_at
+		FROM forum_email_verifications
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID).Scan(&storedHash, &attempts, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("verification not found")
+		}
+		return err
+	}
+
+	// The row stays so a new code doesn't bring back the spent attempts.
+	if attempts >= maxEmailVerificationAttempts {
+		return fmt.Errorf("too many wrong verification codes, try again later")
+	}
+	if !expiresAt.After(time.Now()) {
+		return fmt.Errorf("verification code expired, request a new one")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(storedHash), []byte(codeHash)) != 1 {
+		_, err := tx.Exec(ctx, `
+			UPDATE forum_email_verifications
+			SET attempts = attempts + 1
+			WHERE user_id = $1
+		`, userID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+		return fmt.Errorf("invalid verification code")
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE forum_users
+		SET email_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM forum_email_verifications WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// LinkHRUser links a forum account to the employee record with the same
+// email address. Only verified addresses are matched.
+func (r *ForumUserRepository) LinkHRUser(
+	ctx context.Context,
+	userID string,
+) (string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var email string
+	var verified, active bool
+	err = tx.QueryRow(ctx, `
+		SELECT email, email_verified_at IS NOT NULL, is_active
+		FROM forum_users
+		WHERE id = $1
+		FOR UPDATE
+	`, userID).Scan(&email, &verified, &active)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", err
+	}
+	if !active {
+		return "", fmt.Errorf("account is deactivated")
+	}
+	if !verified {
+		return "", fmt.Errorf("email not verified")
+	}
+
+	var hrUserID string
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM users WHERE LOWER(email) = LOWER($1)
+	`, email).Scan(&hrUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("employee record not found for %s", email)
+		}
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE forum_users
+		SET hr_user_id = $1, updated_at = NOW()
+		WHERE id = $2
+	`, hrUserID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, _, err := syncDepartmentChannels(ctx, tx, userID); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return hrUserID, nil
+}
+
+func (r *ForumUserRepository) UnlinkHRUser(
+	ctx context.Context,
+	userID string,
+) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE forum_users
+			SET hr_user_id = NULL, updated_at = NOW()
+			WHERE id = $1
+		`, userID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("user not found")
+		}
+
+		_, _, err = syncDepartmentChannels(ctx, tx, userID)
+		return err
+	})
+}
+
+// linkVerifiedForumUser links a new employee record to an existing forum
+// account with the same verified email.
+func linkVerifiedForumUser(ctx context.Context, q querier, hrUserID, email string) error {
+	_, err := q.Exec(ctx, `
+		UPDATE forum_users
+		SET hr_user_id = $1, updated_at = NOW()
+		WHERE LOWER(email) = LOWER($2)
+			AND email_verified_at IS NOT NULL
+			AND hr_user_id IS NULL
+			AND is_active = true
+	`, hrUserID, email)
+	return err
+}
+
+// deactivateLinkedForumUser runs inside the HR delete transaction so the forum
+// account is switched off before ON DELETE SET NULL drops the link.
+func deactivateLinkedForumUser(ctx context.Context, q querier, hrUserID string) error {
+	_, err := q.Exec(ctx, `
+		UPDATE forum_users
+		SET is_active = false, is_online = false, deactivated_at = NOW(), updated_at = NOW()
+		WHERE hr_user_id = $1 AND is_active = true
+	`, hrUserID)
+	return err
+}
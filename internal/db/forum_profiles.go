@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *ForumUserRepository) GetProfile(
+	ctx context.Context,
+	userID string,
+) (core.ForumUserProfile, error) {
+	var p core.ForumUserProfile
+	var displayName, avatarUrl sql.NullString
+	var statusText, statusEmoji string
+	var statusExpiresAt sql.NullTime
+
+	err := r.pool.QueryRow(ctx, `
+		SELECT fu.id, fu.username, fu.display_name, fu.avatar_url, fu.bio, fu.pronouns, fu.timezone,
+			fu.status_text, fu.status_emoji, fu.status_expires_at, fu.updated_at,
+			fu.email_verified_at IS NOT NULL, COALESCE(fu.hr_user_id::text, ''),
+			COALESCE(d.name, ''), COALESCE(p.title, '')
+		FROM forum_users fu
+		LEFT JOIN users u ON u.id = fu.hr_user_id
+		LEFT JOIN departments d ON d.id = u.department_id
+		LEFT JOIN positions p ON p.id = u.position_id
+		WHERE fu.id = $1
+	`, userID).Scan(
+		&p.UserID, &p.Username, &displayName, &avatarUrl, &p.Bio, &p.Pronouns, &p.Timezone,
+		&statusText, &statusEmoji, &statusExpiresAt, &p.UpdatedAt,
+		&p.EmailVerified, &p.HRUserID, &p.Department, &p.Position,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.ForumUserProfile{}, fmt.Errorf("user not found")
+		}
+		return core.ForumUserProfile{}, err
+	}
+
+	p.DisplayName = displayName.String
+	p.AvatarUrl = avatarUrl.String
+
+	expired := statusExpiresAt.Valid && !statusExpiresAt.Time.After(time.Now())
+	if (statusText != "" || statusEmoji != "") && !expired {
+		p.Status = &core.ForumUserStatus{Text: statusText, Emoji: statusEmoji}
+		if statusExpiresAt.Valid {
+			p.Status.ExpiresAt = &statusExpiresAt.Time
+		}
+	}
+
+	return p, nil
+}
+
+func (r *ForumUserRepository) UpdateProfile(
+	ctx context.Context,
+	userID string,
+	update core.ForumUserProfileUpdate,
+) (core.ForumUserProfile, error) {
+	p, err := r.GetProfile(ctx, userID)
+	if err != nil {
+		return core.ForumUserProfile{}, err
+	}
+
+	if update.DisplayName != nil {
+		p.DisplayName = *update.DisplayName
+	}
+	if update.Bio != nil {
+		p.Bio = *update.Bio
+	}
+	if update.Pronouns != nil {
+		p.Pronouns = *update.Pronouns
+	}
+	if update.Timezone != nil {
+		p.Timezone = *update.Timezone
+	}
+
+	err = r.pool.QueryRow(ctx, `
+		UPDATE forum_users
+		SET display_name = $1, bio = $2, pronouns = $3, timezone = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`, p.DisplayName, p.Bio, p.Pronouns, p.Timezone, userID).Scan(&p.UpdatedAt)
+	if err != nil {
+		return core.ForumUserProfile{}, err
+	}
+	return p, nil
+}
+
+func (r *ForumUserRepository) SetStatus(
+	ctx context.Context,
+	userID string,
+	status core.ForumUserStatus,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE forum_users
+		SET status_text = $1, status_emoji = $2, status_expires_at = $3, updated_at = NOW()
+		WHERE id = $4
+	`, status.Text, status.Emoji, status.ExpiresAt, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+func (r *ForumUserRepository) SetAvatarURL(
+	ctx context.Context,
+	userID, avatarURL string,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE forum_users
+		SET avatar_url = $1, updated_at = NOW()
+		WHERE id = $2
+	`, avatarURL, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
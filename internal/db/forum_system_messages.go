@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func insertSystemMessage(
+	ctx context.Context,
+	q querier,
+	channelID string,
+	event core.ForumSystemEvent,
+) (*core.ForumMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := systemMessageText(ctx, q, event)
+	if err != nil {
+		return nil, err
+	}
+
+	var message core.ForumMessage
+	var parentMessageID sql.NullString
+	err = q.QueryRow(ctx, `
+		INSERT INTO forum_messages (channel_id, user_id, content, message_type, payload,
+									is_edited, is_deleted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, false, false, NOW(), NOW())
+		RETURNING id, channel_id, user_id, content, message_type,
+				  parent_message_id, is_edited, is_deleted, created_at, updated_at, payload
+	`, channelID, event.ActorID, content, core.MessageTypeSystem, payload).Scan(
+		&message.ID, &message.ChannelID, &message.UserID, &message.Content, &message.MessageType,
+		&parentMessageID, &message.IsEdited, &message.IsDeleted,
+		&message.CreatedAt, &message.UpdatedAt, &message.Payload,
+	)
+	if err != nil {
+		return nil, err
+	}
+	message.ParentMessageID = parentMessageID.String
+	return &message, nil
+}
+
+func systemMessageText(ctx context.Context, q querier, event core.ForumSystemEvent) (string, error) {
+	actor, err := forumUsername(ctx, q, event.ActorID)
+	if err != nil {
+		return "", err
+	}
+
+	switch event.Event {
+	case core.SystemEventMemberJoined:
+		if event.TargetID != "" && event.TargetID != event.ActorID {
+			target, err := forumUsername(ctx, q, event.TargetID)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("@%s added @%s to the channel", actor, target), nil
+		}
+		return fmt.Sprintf("@%s joined the channel", actor), nil
+	case core.SystemEventMemberLeft:
+		return fmt.Sprintf("@%s left the channel", actor), nil
+	case core.SystemEventChannelRenamed:
+		return fmt.Sprintf("@%s renamed the channel from %q to %q", actor, event.OldValue, event.NewValue), nil
+	case core.SystemEventTopicChanged:
+		return fmt.Sprintf("@%s changed the topic to %q", actor, event.NewValue), nil
+	case core.SystemEventMessagePinned:
+		return fmt.Sprintf("@%s pinned a message", actor), nil
+	case core.SystemEventMessageUnpinned:
+		return fmt.Sprintf("@%s unpinned a message", actor), nil
+	case core.SystemEventDirectCreated:
+		return fmt.Sprintf("@%s started a direct conversation", actor), nil
+	}
+	return fmt.Sprintf("@%s: %s", actor, event.Event), nil
+}
+
+func forumUsername(ctx context.Context, q querier, userID string) (string, error) {
+	var username string
+	err := q.QueryRow(ctx, `SELECT username FROM forum_users WHERE id = $1`, userID).Scan(&username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", err
+	}
+	return username, nil
+}
+
+func (r *ForumUserRepository) CreateSystemMessage(
+	ctx context.Context,
+	channelID string,
+	event core.ForumSystemEvent,
+) (*core.ForumMessage, error) {
+	return insertSystemMessage(ctx, r.pool, channelID, event)
+}
+
+func (r *ForumUserRepository) RenameChannel(
+	ctx context.Context,
+	channelID, name string,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE forum_channels
+		SET name = $1
+		WHERE id = $2
+	`, name, channelID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("channel not found")
+	}
+	return nil
+}
+
+func (r *ForumUserRepository) PinMessage(
+	ctx context.Context,
+	messageID, userID string,
+) (string, error) {
+	var channelID string
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO pinned_messages (message_id, channel_id, pinned_by, pinned_at)
+		SELECT fm.id, fm.channel_id, $2, NOW()
+		FROM forum_messages fm
+		JOIN channel_members cm ON cm.channel_id = fm.channel_id AND cm.user_id = $2
+		WHERE fm.id = $1 AND fm.is_deleted = false
+		ON CONFLICT (message_id) DO NOTHING
+		RETURNING channel_id
+	`, messageID, userID).Scan(&channelID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("message not found or already pinned")
+		}
+		return "", err
+	}
+	return channelID, nil
+}
+
+func (r *ForumUserRepository) UnpinMessage(
+	ctx context.Context,
+	messageID, userID string,
+) (string, error) {
+	var channelID string
+	err := r.pool.QueryRow(ctx, `
+		DELETE FROM pinned_messages pm
+		USING channel_members cm
+		WHERE pm.message_id = $1
+			AND cm.channel_id = pm.channel_id
+			AND cm.user_id = $2
+		RETURNING pm.channel_id
+	`, messageID, userID).Scan(&channelID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("pinned message not found")
+		}
+		return "", err
+	}
+	return channelID, nil
+}
+
+func (r *ForumUserRepository) GetChannelPins(
+	ctx context.Context,
+	channelID string,
+) ([]core.PinnedMessage, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT fm.id, fm.channel_id, fm.user_id, fm.content, fm.message_type, fm.parent_message_id,
+			fm.is_edited, fm.is_deleted, fm.created_at, fm.updated_at, fm.payload,
+			COALESCE(pm.pinned_by::text, ''), pm.pinned_at
+		FROM pinned_messages pm
+		JOIN forum_messages fm ON fm.id = pm.message_id
+		WHERE pm.channel_id = $1 AND fm.is_deleted = false
+		ORDER BY pm.pinned_at DESC
+	`, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := []core.PinnedMessage{}
+	for rows.Next() {
+		var p core.PinnedMessage
+		var parentMessageID sql.NullString
+		err := rows.Scan(
+			&p.Message.ID, &p.Message.ChannelID, &p.Message.UserID, &p.Message.Content,
+			&p.Message.MessageType, &parentMessageID, &p.Message.IsEdited, &p.Message.IsDeleted,
+			&p.Message.CreatedAt, &p.Message.UpdatedAt, &p.Message.Payload,
+			&p.PinnedBy, &p.PinnedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		p.Message.ParentMessageID = parentMessageID.String
+		pins = append(pins, p)
+	}
+	return pins, rows.Err()
+}
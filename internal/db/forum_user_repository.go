@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"multi-processing-backend/internal/core"
 	"os"
@@ -67,6 +68,26 @@ func (r *ForumUserRepository) GetByID(
 	return &user, err
 }
 
+func (r *ForumUserRepository) GetByUsername(
+	ctx context.Context,
+	username string,
+) (*core.ForumUser, error) {
+	var user core.ForumUser
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, email, username, display_name, avatar_url, is_online, last_seen, created_at, updated_at
+		FROM forum_users
+		WHERE LOWER(username) = LOWER($1)
+	`, username).Scan(
+		&user.ID, &user.Email, &user.Username, &user.DisplayName,
+		&user.AvatarUrl, &user.IsOnline, &user.LastSeen,
+		&user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *ForumUserRepository) Create(
 	ctx context.Context,
 	user *core.ForumUser,
@@ -86,9 +107,9 @@ func (r *ForumUserRepository) Update(
 ) error {
 	_, err := r.pool.Exec(ctx, `
 		UPDATE forum_users
-		SET display_name = $1, avatar_url = $2, is_online = $3, last_seen = $4, updated_at = $5
-		WHERE id = $6
-	`, user.DisplayName, user.AvatarUrl, user.IsOnline, user.LastSeen, time.Now(), user.ID)
+		SET display_name = $1, updated_at = $2
+		WHERE id = $3
+	`, user.DisplayName, time.Now(), user.ID)
 	return err
 }
 
@@ -106,17 +127,43 @@ func (r *ForumUserRepository) IsChannelMember(
 	return exists, err
 }
 
+// GetChannelMemberRole returns the role of userID in the channel, or an
+// empty string if they are not a member.
+func (r *ForumUserRepository) GetChannelMemberRole(
+	ctx context.Context,
+	channelID, userID string,
+) (string, error) {
+	var role string
+	err := r.pool.QueryRow(ctx, `
+		SELECT COALESCE(role, '') FROM channel_members
+		WHERE channel_id = $1 AND user_id = $2
+	`, channelID, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
 func (r *ForumUserRepository) RegisterOrLogin(
 	ctx context.Context,
 	username, email string,
 ) (*core.ForumUser, error) {
 	user, err := r.GetByEmail(ctx, email)
 	if err == nil {
-		user.IsOnline = true
-		user.LastSeen = time.Now()
-		if err := r.Update(ctx, user); err != nil {
+		var active bool
+		if err := r.pool.QueryRow(ctx, `
+			SELECT is_active FROM forum_users WHERE id = $1
+		`, user.ID).Scan(&active); err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, fmt.Errorf("account is deactivated")
+		}
+		if err := r.UpdateUserPresence(ctx, user.ID, true); err != nil {
 			return nil, err
 		}
+		user.IsOnline = true
+		user.LastSeen = time.Now()
 		return user, nil
 	}
 
@@ -143,7 +190,7 @@ func (r *ForumUserRepository) GetUserChannels(
 	userID string,
 ) ([]core.ForumChannel, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT fc.id, fc.name, fc.description, fc.is_private, fc.is_direct_message, fc.created_by, fc.created_at
+		SELECT fc.id, fc.name, COALESCE(fc.description, ''), fc.is_private, fc.is_direct_message, COALESCE(fc.created_by::text, ''), fc.created_at
 		FROM forum_channels fc
 		JOIN channel_members cm ON fc.id = cm.channel_id
 		WHERE cm.user_id = $1
@@ -195,7 +242,7 @@ func (r *ForumUserRepository) GetChannelMessages(
 
 	rows, err := r.pool.Query(ctx, `
 		SELECT id, channel_id, user_id, content, message_type, parent_message_id, 
-               is_edited, is_deleted, created_at, updated_at
+               is_edited, is_deleted, created_at, updated_at, payload
         FROM forum_messages
         WHERE channel_id = $1 AND is_deleted = false
         ORDER BY created_at ASC
@@ -215,6 +262,7 @@ func (r *ForumUserRepository) GetChannelMessages(
 		err := rows.Scan(
 			&m.ID, &m.ChannelID, &m.UserID, &m.Content, &m.MessageType,
 			&parentMessageID, &m.IsEdited, &m.IsDeleted, &m.CreatedAt, &m.UpdatedAt,
+			&m.Payload,
 		)
 		 if err != nil {
             slog.Error(operation+" row scan failed", "error", err)
@@ -264,6 +312,7 @@ func (r *ForumUserRepository) GetPublicChannelMessages(
 		SELECT 
 			fm.id, fm.channel_id, fm.user_id, fm.content, fm.message_type, 
 			fm.parent_message_id, fm.is_edited, fm.is_deleted, fm.created_at, fm.updated_at,
+			fm.payload,
 
 			fu.id, fu.email, fu.username, fu.display_name, fu.avatar_url, fu.is_online,
 			fu.last_seen, fu.created_at, fu.updated_at,
@@ -315,6 +364,7 @@ func (r *ForumUserRepository) GetPublicChannelMessages(
 		err := rows.Scan(
 			&msg.ID, &msg.ChannelID, &msg.UserID, &msg.Content, &msg.MessageType,
 			&parentMsgID, &msg.IsEdited, &msg.IsDeleted, &msg.CreatedAt, &msg.UpdatedAt,
+			&msg.Payload,
 
 			&userID, &userEmail, &userUsername, &userDisplayName,
 			&avatarUrl, &userIsOnline, &userLastSeen, &userCreatedAt, &userUpdatedAt,
@@ -398,11 +448,77 @@ func (r *ForumUserRepository) GetPublicChannelMessages(
 	return response, nil
 }
 
+func (r *ForumUserRepository) GetChannel(
+	ctx context.Context,
+	channelID string,
+) (core.ForumChannel, error) {
+	var ch core.ForumChannel
+	var description sql.NullString
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, name, COALESCE(description, ''), is_private, is_direct_message, COALESCE(created_by::text, ''),
+			COALESCE(department_id::text, ''), created_at
+		FROM forum_channels
+		WHERE id = $1
+	`, channelID).Scan(
+		&ch.ID, &ch.Name, &description, &ch.IsPrivate, &ch.IsDirectMessage,
+		&ch.CreatedBy, &ch.DepartmentID, &ch.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.ForumChannel{}, fmt.Errorf("channel not found")
+		}
+		return core.ForumChannel{}, err
+	}
+	ch.Description = description.String
+	return ch, nil
+}
+
+func (r *ForumUserRepository) UpdateChannelTopic(
+	ctx context.Context,
+	channelID, topic string,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE forum_channels
+		SET description = $1
+		WHERE id = $2
+	`, topic, channelID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("channel not found")
+	}
+	return nil
+}
+
+func (r *ForumUserRepository) AddChannelMember(
+	ctx context.Context,
+	channelID, userID, role string,
+) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO channel_members (channel_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (channel_id, user_id) DO NOTHING
+	`, channelID, userID, role)
+	return err
+}
+
+func (r *ForumUserRepository) RemoveChannelMember(
+	ctx context.Context,
+	channelID, userID string,
+) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM channel_members
+		WHERE channel_id = $1 AND user_id = $2
+	`, channelID, userID)
+	return err
+}
+
 func (r *ForumUserRepository) GetPublicChannel(ctx context.Context) (core.ForumChannel, error) {
 	var ch core.ForumChannel
 	publicChannel := "Public Channel"
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, name, description, is_private, is_direct_message, created_by, created_at
+		SELECT id, name, COALESCE(description, ''), is_private, is_direct_message, COALESCE(created_by::text, ''), created_at
 		FROM forum_channels
 		WHERE name = $1
 		LIMIT 1
@@ -420,6 +536,13 @@ func (r *ForumUserRepository) GetPublicChannel(ctx context.Context) (core.ForumC
 func (r *ForumUserRepository) CreateMessage(
 	ctx context.Context,
 	channelID, userID, content, parentMessageID string,
+) (*core.ForumMessage, error) {
+	return r.CreateMessageOfType(ctx, channelID, userID, content, core.MessageTypeText, parentMessageID)
+}
+
+func (r *ForumUserRepository) CreateMessageOfType(
+	ctx context.Context,
+	channelID, userID, content, messageType, parentMessageID string,
 ) (*core.ForumMessage, error) {
 	var message core.ForumMessage
 
@@ -448,13 +571,13 @@ func (r *ForumUserRepository) CreateMessage(
 	err := r.pool.QueryRow(ctx, `
         INSERT INTO forum_messages (channel_id, user_id, content, message_type, parent_message_id,
                                     is_edited, is_deleted, created_at, updated_at)
-        VALUES ($1, $2, $3, 'text', $4, false, false, NOW(), NOW())
+        VALUES ($1, $2, $3, $4, $5, false, false, NOW(), NOW())
         RETURNING id, channel_id, user_id, content, message_type, 
-                  parent_message_id, is_edited, is_deleted, created_at, updated_at
-    `, channelID, userID, content, parMsgValue).Scan(
+                  parent_message_id, is_edited, is_deleted, created_at, updated_at, payload
+    `, channelID, userID, content, messageType, parMsgValue).Scan(
 		&message.ID, &message.ChannelID, &message.UserID, &message.Content, &message.MessageType,
 		&scannedPMsgID, &message.IsEdited, &message.IsDeleted,
-		&message.CreatedAt, &message.UpdatedAt,
+		&message.CreatedAt, &message.UpdatedAt, &message.Payload,
 	)
 
 	if err != nil {
@@ -487,7 +610,7 @@ func (r *ForumUserRepository) MarkMessagesAsRead(ctx context.Context, channelID,
 func (r *ForumUserRepository) GetOrCreateDirectMessageChannel(
 	ctx context.Context,
 	user1Id, user2Id string,
-) (string, error) {
+) (string, bool, error) {
 	var existingChannelID string
 	err := r.pool.QueryRow(ctx, `
 		SELECT fc.id 
@@ -499,52 +622,52 @@ func (r *ForumUserRepository) GetOrCreateDirectMessageChannel(
 	`, user1Id, user2Id).Scan(&existingChannelID)
 
 	if err == nil {
-		return existingChannelID, nil
+		return existingChannelID, false, nil
 	}
 
 	var channelID string
 	err = r.pool.QueryRow(ctx, `
 		SELECT create_direct_message_channel($1, $2)
 	`, user1Id, user2Id).Scan(&channelID)
-	return channelID, err
+	if err != nil {
+		return "", false, err
+	}
+	return channelID, true, nil
 }
 
 func (r *ForumUserRepository) GetOnlineUsers(
 	ctx context.Context,
 	userID string,
-) ([]core.ForumUser, error) {
-	rows, err := r.pool.Query(ctx, `
-		SELECT id, email, username, display_name, avatar_url, is_online, last_seen, created_at, updatedat
-		FROM fourm_users
-		WHERE is_online = true AND id != $1
-		ORDER BY last_seen DESC
+) ([]core.ForumMember, error) {
+	rows, err := r.pool.Query(ctx, forumMemberSelect+`
+		WHERE fu.is_online = true AND fu.is_active = true AND fu.id != $1
+		ORDER BY fu.last_seen DESC
 	`, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	defer rows.Close()
-
-	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.ForumUser])
+	return scanForumMembers(rows)
 }
 
 func (r *ForumUserRepository) SearchUsers(
 	ctx context.Context,
 	query string,
 	currentUserID string,
-) ([]core.ForumUser, error) {
-	rows, err := r.pool.Query(ctx, `
-		SELECT id, email, username, display_name, avatar_url, is_online, last_seen, created_at, updatedat
-		FROM fourm_users
-		WHERE (username ILIKE $1 OR display_name ILIKE $1) AND id != $2
+) ([]core.ForumMember, error) {
+	rows, err := r.pool.Query(ctx, forumMemberSelect+`
+		WHERE (fu.username ILIKE $1 OR fu.display_name ILIKE $1
+				OR d.name ILIKE $1 OR p.title ILIKE $1)
+			AND fu.is_active = true
+			AND fu.id != $2
+		ORDER BY fu.username
 		LIMIT 20
 	`, "%"+query+"%", currentUserID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.ForumUser])
+	return scanForumMembers(rows)
 }
 
 func (r *ForumUserRepository) GetUnreadCount(
@@ -557,9 +680,10 @@ func (r *ForumUserRepository) GetUnreadCount(
 		JOIN channel_members cm ON fm.channel_id = cm.channel_id
 		LEFT JOIN message_read_status mrs ON fm.id = mrs.message_id AND mrs.user_id = $1
 		WHERE cm.user_id = $1
-			AND fm.uder_id != $1
+			AND fm.user_id != $1
 			AND mrs.message_id IS NULL
 			AND fm.is_deleted = false
+			AND fm.message_type <> 'system'
 		GROUP BY fm.channel_id
 	`, userID)
 	if err != nil {
@@ -579,6 +703,62 @@ func (r *ForumUserRepository) GetUnreadCount(
 	return result, nil
 }
 
+func (r *ForumUserRepository) GetMentionCounts(
+	ctx context.Context,
+	userID string,
+) (map[string]int, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT fm.channel_id, COUNT(*) AS mention_count
+		FROM forum_messages fm
+		JOIN channel_members cm ON fm.channel_id = cm.channel_id
+		JOIN forum_users fu ON fu.id = cm.user_id
+		LEFT JOIN message_read_status mrs ON fm.id = mrs.message_id AND mrs.user_id = $1
+		WHERE cm.user_id = $1
+			AND fm.user_id != $1
+			AND mrs.message_id IS NULL
+			AND fm.is_deleted = false
+			AND fm.message_type <> 'system'
+			AND forum_mentions(fm.content, fu.username)
+		GROUP BY fm.channel_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]int)
+	for rows.Next() {
+		var channelID string
+		var count int
+		if err := rows.Scan(&channelID, &count); err != nil {
+			return nil, err
+		}
+		result[channelID] = count
+	}
+	return result, rows.Err()
+}
+
+func (r *ForumUserRepository) GetDirectMessagePartners(
+	ctx context.Context,
+	userID string,
+) ([]core.ForumUser, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT fu.id, fu.email, fu.username, fu.display_name, fu.avatar_url,
+			fu.is_online, fu.last_seen, fu.created_at, fu.updated_at
+		FROM forum_channels fc
+		JOIN channel_members me ON me.channel_id = fc.id AND me.user_id = $1
+		JOIN channel_members other ON other.channel_id = fc.id AND other.user_id != $1
+		JOIN forum_users fu ON fu.id = other.user_id
+		WHERE fc.is_direct_message = true
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.ForumUser])
+}
+
 func (r *ForumUserRepository) UpdateUserPresence(
 	ctx context.Context,
 	userID string,
@@ -595,11 +775,8 @@ func (r *ForumUserRepository) UpdateUserPresence(
 func (r *ForumUserRepository) GetChannelMembers(
 	ctx context.Context,
 	channelID string,
-) ([]core.ForumUser, error) {
-	rows, err := r.pool.Query(ctx, `
-		SELECT fu.id, fu.email, fu.username, fu.display_name, fu.avatar_url, 
-			fu.is_online, fu.last_seen, fu.created_at, fu.updated_at
-		FROM forum_users fu
+) ([]core.ForumMember, error) {
+	rows, err := r.pool.Query(ctx, forumMemberSelect+`
 		JOIN channel_members cm ON fu.id = cm.user_id
 		WHERE cm.channel_id = $1
 		ORDER BY cm.joined_at
@@ -607,33 +784,44 @@ func (r *ForumUserRepository) GetChannelMembers(
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.ForumUser])
+	return scanForumMembers(rows)
 }
 
 func (r *ForumUserRepository) EditMessage(
 	ctx context.Context,
 	messageID, userID, newContent string,
 ) error {
-	_, err := r.pool.Exec(ctx, `
+	tag, err := r.pool.Exec(ctx, `
         UPDATE forum_messages 
         SET content = $1, is_edited = true, updated_at = NOW()
-        WHERE id = $2 AND user_id = $3
+        WHERE id = $2 AND user_id = $3 AND message_type <> 'system'
     `, newContent, messageID, userID)
-	return err
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("message not found or cannot be edited")
+	}
+	return nil
 }
 
 func (r *ForumUserRepository) DeleteMessage(
 	ctx context.Context,
 	messageID, userID string,
 ) error {
-	_, err := r.pool.Exec(ctx, `
+	tag, err := r.pool.Exec(ctx, `
         UPDATE forum_messages 
         SET is_deleted = true, content = '[deleted]', updated_at = NOW()
-        WHERE id = $1 AND user_id = $2
+        WHERE id = $1 AND user_id = $2 AND message_type <> 'system'
     `, messageID, userID)
-	return err
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("message not found or cannot be deleted")
+	}
+	return nil
 }
 
 func (r *ForumUserRepository) AddReaction(
@@ -659,13 +847,56 @@ func (r *ForumUserRepository) RemoveReaction(
 	return err
 }
 
+func (r *ForumUserRepository) CreateReminder(
+	ctx context.Context,
+	reminder *core.ForumReminder,
+) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO forum_reminders (channel_id, user_id, content, remind_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, reminder.ChannelID, reminder.UserID, reminder.Content, reminder.RemindAt,
+	).Scan(&reminder.ID, &reminder.CreatedAt)
+}
+
+func (r *ForumUserRepository) ListDueReminders(
+	ctx context.Context,
+	limit int,
+) ([]core.ForumReminder, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, channel_id, user_id, content, remind_at, created_at
+		FROM forum_reminders
+		WHERE delivered_at IS NULL AND remind_at <= NOW()
+		ORDER BY remind_at ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.ForumReminder])
+}
+
+func (r *ForumUserRepository) MarkReminderDelivered(
+	ctx context.Context,
+	reminderID string,
+) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE forum_reminders
+		SET delivered_at = NOW()
+		WHERE id = $1
+	`, reminderID)
+	return err
+}
+
 // Creating public channel at App start
 func (r *ForumUserRepository) CreatePublicChannel(ctx context.Context) {
 	var pc core.ForumChannel
 	var channel = "Public Channel"
 
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, name, description, is_private, is_direct_message, created_by, created_at
+		SELECT id, name, COALESCE(description, ''), is_private, is_direct_message, COALESCE(created_by::text, ''), created_at
 		FROM forum_channels
 		WHERE name = $1
 		LIMIT 1
@@ -735,7 +966,22 @@ func (r *ForumUserRepository) createAdminUser(ctx context.Context) string {
 }
 
 func (r *ForumUserRepository) DeleteForumTables(ctx context.Context) {
-	_, err := r.pool.Exec(ctx, "DROP TABLE message_reactions CASCADE")
+	_, err := r.pool.Exec(ctx, "DROP TABLE pinned_messages CASCADE")
+	if err != nil {
+		slog.Warn("ForumUserRepository | DeleteForumTables | error occurred while deleting pinned_messages")
+	}
+
+	_, err = r.pool.Exec(ctx, "DROP TABLE forum_email_verifications CASCADE")
+	if err != nil {
+		slog.Warn("ForumUserRepository | DeleteForumTables | error occurred while deleting forum_email_verifications")
+	}
+
+	_, err = r.pool.Exec(ctx, "DROP TABLE forum_reminders CASCADE")
+	if err != nil {
+		slog.Warn("ForumUserRepository | DeleteForumTables | error occurred while deleting forum_reminders")
+	}
+
+	_, err = r.pool.Exec(ctx, "DROP TABLE message_reactions CASCADE")
 	if err != nil {
 		slog.Warn("ForumUserRepository | DeleteForumTables | error occurred while deleting message_reactions")
 	}
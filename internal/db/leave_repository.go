@@ -0,0 +1,402 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type LeaveRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLeaveRepository(pool *pgxpool.Pool) *LeaveRepository {
+	return &LeaveRepository{pool: pool}
+}
+
+const leaveRequestColumns = `
+	r.id, r.user_id, r.leave_type_id, t.code, r.start_date, r.end_date, r.half_day_start, r.half_day_end,
+	r.days, r.status, r.reason, COALESCE(r.decided_by::text, ''), r.decision_note, r.decided_at,
+	r.created_at, r.updated_at
+`
+
+func (r *LeaveRepository) ListTypes(ctx context.Context) ([]core.LeaveType, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, code, name, has_entitlement, default_days, created_at, updated_at
+		FROM leave_types
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.LeaveType])
+}
+
+func leaveType(ctx context.Context, q querier, code string) (core.LeaveType, error) {
+	var t core.LeaveType
+	err := q.QueryRow(ctx, `
+		SELECT id, code, name, has_entitlement, default_days, created_at, updated_at
+		FROM leave_types
+		WHERE code = $1
+	`, code).Scan(&t.ID, &t.Code, &t.Name, &t.HasEntitlement, &t.DefaultDays, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.LeaveType{}, fmt.Errorf("leave type not found")
+		}
+		return core.LeaveType{}, err
+	}
+	return t, nil
+}
+
+// checkLeaveBalance fails with core.ErrLeaveBalance when days on top of the
+// approved and pending leave of the year would exceed the entitlement.
+// excludeID leaves out the request being decided.
+func checkLeaveBalance(
+	ctx context.Context,
+	q querier,
+	userID, leaveTypeID string,
+	year int,
+	days decimal.Decimal,
+	excludeID string,
+) error {
+	var entitlement decimal.NullDecimal
+	var committed decimal.Decimal
+	err := q.QueryRow(ctx, `
+		SELECT CASE WHEN t.has_entitlement THEN COALESCE(e.days, t.default_days) END,
+			COALESCE((
+				SELECT SUM(r.days)
+				FROM leave_requests r
+				WHERE r.user_id = $1 AND r.leave_type_id = t.id
+					AND r.status IN ('pending', 'approved')
+					AND EXTRACT(YEAR FROM r.start_date)::int = $3::int
+					AND r.id::text <> $4
+			), 0)
+		FROM leave_types t
+		LEFT JOIN leave_entitlements e ON e.leave_type_id = t.id AND e.user_id = $1 AND e.year = $3::int
+		WHERE t.id = $2
+	`, userID, leaveTypeID, year, excludeID).Scan(&entitlement, &committed)
+	if err != nil {
+		return err
+	}
+	if !entitlement.Valid {
+		return nil
+	}
+	if remaining := entitlement.Decimal.Sub(committed); days.GreaterThan(remaining) {
+		return fmt.Errorf("%w: %s days requested, %s left", core.ErrLeaveBalance,
+			days.StringFixed(1), remaining.StringFixed(1))
+	}
+	return nil
+}
+
+// CreateRequest files a pending request. The user row is locked so two
+// requests of the same user cannot both pass the overlap and balance
+// checks.
+func (r *LeaveRepository) CreateRequest(
+	ctx context.Context,
+	req core.LeaveRequest,
+) (core.LeaveRequest, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, _, err := lockEmployee(ctx, tx, req.UserID)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+	if status == core.EmploymentTerminated {
+		return core.LeaveRequest{}, fmt.Errorf("%w: user has been offboarded", core.ErrEmploymentState)
+	}
+
+	t, err := leaveType(ctx, tx, req.LeaveType)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+
+	// Two requests may share a day when one ends at noon and the other
+	// starts there.
+	var overlapping bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM leave_requests r
+			WHERE r.user_id = $1
+				AND r.status IN ('pending', 'approved')
+				AND r.start_date <= $3 AND r.end_date >= $2
+				AND NOT (r.end_date = $2 AND r.half_day_end AND $4)
+				AND NOT (r.start_date = $3 AND r.half_day_start AND $5)
+		)
+	`, req.UserID, req.StartDate, req.EndDate, req.HalfDayStart, req.HalfDayEnd).Scan(&overlapping)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+	if overlapping {
+		return core.LeaveRequest{}, core.ErrLeaveOverlap
+	}
+
+	if err := checkLeaveBalance(ctx, tx, req.UserID, t.ID, req.StartDate.Year(), req.Days, ""); err != nil {
+		return core.LeaveRequest{}, err
+	}
+
+	var id string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO leave_requests (user_id, leave_type_id, start_date, end_date, half_day_start, half_day_end, days, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, req.UserID, t.ID, req.StartDate, req.EndDate, req.HalfDayStart, req.HalfDayEnd, req.Days, req.Reason).Scan(&id)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.LeaveRequest{}, err
+	}
+	return r.GetRequest(ctx, id)
+}
+
+func (r *LeaveRepository) GetRequest(ctx context.Context, id string) (core.LeaveRequest, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+leaveRequestColumns+`
+		FROM leave_requests r
+		JOIN leave_types t ON t.id = r.leave_type_id
+		WHERE r.id::text = $1
+	`, id)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+	req, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByPos[core.LeaveRequest])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.LeaveRequest{}, fmt.Errorf("leave request not found")
+		}
+		return core.LeaveRequest{}, err
+	}
+	return req, nil
+}
+
+// ListRequests pages through leave requests, newest first, optionally for
+// one user and in one status.
+func (r *LeaveRepository) ListRequests(
+	ctx context.Context,
+	userID, status string,
+	page, limit int,
+) ([]core.LeaveRequest, int64, error) {
+	offset := (page - 1) * limit
+	filter := `($1::text = '' OR r.user_id::text = $1) AND ($2::text = '' OR r.status = $2)`
+
+	var total int64
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM leave_requests r WHERE `+filter,
+		userID, status,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+leaveRequestColumns+`
+		FROM leave_requests r
+		JOIN leave_types t ON t.id = r.leave_type_id
+		WHERE `+filter+`
+		ORDER BY r.start_date DESC, r.created_at DESC
+		LIMIT $3 OFFSET $4
+	`, userID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	requests, err := pgx.CollectRows(rows, pgx.RowToStructByPos[core.LeaveRequest])
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// TransitionRequest moves a request from one of the from statuses to to and
+// records the decision. Approving re-checks the balance in case the
+// entitlement was lowered since the request was filed.
+func (r *LeaveRepository) TransitionRequest(
+	ctx context.Context,
+	id string,
+	from []string,
+	to string,
+	decision core.LeaveDecision,
+) (core.LeaveRequest, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, leaveTypeID, status string
+	var startDate time.Time
+	var days decimal.Decimal
+	err = tx.QueryRow(ctx, `
+		SELECT user_id, leave_type_id, status, start_date, days
+		FROM leave_requests
+		WHERE id::text = $1
+		FOR UPDATE
+	`, id).Scan(&userID, &leaveTypeID, &status, &startDate, &days)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.LeaveRequest{}, fmt.Errorf("leave request not found")
+		}
+		return core.LeaveRequest{}, err
+	}
+
+	allowed := false
+	for _, s := range from {
+		allowed = allowed || s == status
+	}
+	if !allowed {
+		return core.LeaveRequest{}, fmt.Errorf("%w: request is %s", core.ErrLeaveRequestState, status)
+	}
+
+	if to == core.LeaveStatusApproved {
+		if err := checkLeaveBalance(ctx, tx, userID, leaveTypeID, startDate.Year(), days, id); err != nil {
+			return core.LeaveRequest{}, err
+		}
+	}
+	if decision.DecidedBy != "" {
+		exists, err := userExists(ctx, tx, decision.DecidedBy)
+		if err != nil {
+			return core.LeaveRequest{}, err
+		}
+		if !exists {
+			return core.LeaveRequest{}, fmt.Errorf("decided_by user not found")
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE leave_requests
+		SET status = $2, decided_by = NULLIF($3, '')::uuid, decision_note = $4, decided_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id, to, decision.DecidedBy, decision.Note)
+	if err != nil {
+		return core.LeaveRequest{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.LeaveRequest{}, err
+	}
+	return r.GetRequest(ctx, id)
+}
+
+// SetEntitlement overrides the allowance of a leave type for a user and
+// year.
+func (r *LeaveRepository) SetEntitlement(
+	ctx context.Context,
+	userID, typeCode string,
+	in core.LeaveEntitlementUpdate,
+) error {
+	if err := requireExistingUser(ctx, r.pool, userID); err != nil {
+		return err
+	}
+	t, err := leaveType(ctx, r.pool, typeCode)
+	if err != nil {
+		return err
+	}
+	if !t.HasEntitlement {
+		return fmt.Errorf("leave type %s must not have an entitlement", t.Code)
+	}
+
+	_, err = r.pool.Exec(ctx, `
+		INSERT INTO leave_entitlements (user_id, leave_type_id, year, days)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, leave_type_id, year) DO UPDATE
+		SET days = EXCLUDED.days, updated_at = NOW()
+	`, userID, t.ID, in.Year, in.Days)
+	return err
+}
+
+// Balances reports, per leave type, the entitlement of a user for a year
+// and the approved and pending days counted against it.
+func (r *LeaveRepository) Balances(
+	ctx context.Context,
+	userID string,
+	year int,
+) ([]core.LeaveBalance, error) {
+	if err := requireExistingUser(ctx, r.pool, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT t.code, t.name,
+			CASE WHEN t.has_entitlement THEN COALESCE(e.days, t.default_days) END,
+			COALESCE(SUM(r.days) FILTER (WHERE r.status = 'approved'), 0),
+			COALESCE(SUM(r.days) FILTER (WHERE r.status = 'pending'), 0)
+		FROM leave_types t
+		LEFT JOIN leave_entitlements e ON e.leave_type_id = t.id AND e.user_id = $1 AND e.year = $2::int
+		LEFT JOIN leave_requests r ON r.leave_type_id = t.id AND r.user_id = $1
+			AND EXTRACT(YEAR FROM r.start_date)::int = $2::int
+		GROUP BY t.id, t.code, t.name, t.has_entitlement, e.days, t.default_days
+		ORDER BY t.name
+	`, userID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (core.LeaveBalance, error) {
+		var b core.LeaveBalance
+		if err := row.Scan(&b.LeaveType, &b.Name, &b.Entitlement, &b.Taken, &b.Pending); err != nil {
+			return b, err
+		}
+		if b.Entitlement.Valid {
+			b.Remaining = decimal.NewNullDecimal(b.Entitlement.Decimal.Sub(b.Taken))
+		}
+		return b, nil
+	})
+}
+
+// Calendar lists the leave of a department's current staff that overlaps
+// from..to. Pending requests are included on request so planners can see
+// what may still come.
+func (r *LeaveRepository) Calendar(
+	ctx context.Context,
+	departmentID string,
+	from, to time.Time,
+	includePending bool,
+) ([]core.Absence, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM departments WHERE id::text = $1)`, departmentID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("department not found")
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT r.id, u.id, u.first_name, u.last_name, t.code, r.start_date, r.end_date,
+			r.half_day_start, r.half_day_end, r.days, r.status
+		FROM leave_requests r
+		JOIN leave_types t ON t.id = r.leave_type_id
+		JOIN users u ON u.id = r.user_id
+		WHERE `+departmentFilter+` AND `+currentStaff+`
+			AND r.start_date <= $3 AND r.end_date >= $2
+			AND (r.status = 'approved' OR ($4 AND r.status = 'pending'))
+		ORDER BY r.start_date, u.last_name, u.first_name
+	`, departmentID, from, to, includePending)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.Absence])
+}
+
+func requireExistingUser(ctx context.Context, q querier, id string) error {
+	exists, err := userExists(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OfficeRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewOfficeRepository(pool *pgxpool.Pool) *OfficeRepository {
+	return &OfficeRepository{pool: pool}
+}
+
+const officeColumns = `
+	id, name, street, city, zip_code, country, timezone, capacity, created_at, updated_at
+`
+
+func officeDest(o *core.Office) []any {
+	return []any{
+		&o.ID, &o.Name, &o.Street, &o.City, &o.ZipCode, &o.Country, &o.Timezone, &o.Capacity,
+		&o.CreatedAt, &o.UpdatedAt,
+	}
+}
+
+func (r *OfficeRepository) List(ctx context.Context) ([]core.Office, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+officeColumns+` FROM offices ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.Office])
+}
+
+func (r *OfficeRepository) Create(ctx context.Context, o core.Office) (core.Office, error) {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO offices (name, street, city, zip_code, country, timezone, capacity)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+officeColumns,
+		o.Name, o.Street, o.City, o.ZipCode, o.Country, o.Timezone, o.Capacity,
+	).Scan(officeDest(&o)...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return core.Office{}, core.ErrOfficeExists
+		}
+		return core.Office{}, err
+	}
+	return o, nil
+}
+
+func (r *OfficeRepository) Get(ctx context.Context, id string) (core.Office, error) {
+	var o core.Office
+	err := r.pool.QueryRow(ctx, `SELECT `+officeColumns+` FROM offices WHERE id = $1`, id).Scan(officeDest(&o)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Office{}, fmt.Errorf("office not found")
+		}
+		return core.Office{}, err
+	}
+	return o, nil
+}
+
+// Update overwrites an office with o, which the service has already merged
+// with the stored values.
+func (r *OfficeRepository) Update(ctx context.Context, id string, o core.Office) (core.Office, error) {
+	err := r.pool.QueryRow(ctx, `
+		UPDATE offices
+		SET name = $1, street = $2, city = $3, zip_code = $4, country = $5, timezone = $6, capacity = $7,
+			updated_at = NOW()
+		WHERE id = $8
+		RETURNING `+officeColumns,
+		o.Name, o.Street, o.City, o.ZipCode, o.Country, o.Timezone, o.Capacity, id,
+	).Scan(officeDest(&o)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Office{}, fmt.Errorf("office not found")
+		}
+		if isUniqueViolation(err) {
+			return core.Office{}, core.ErrOfficeExists
+		}
+		return core.Office{}, err
+	}
+	return o, nil
+}
+
+// Delete removes an office no current employee is assigned to any more.
+// Assignments of former employees, left over from before offboarding
+// released them, are dropped with it.
+func (r *OfficeRepository) Delete(ctx context.Context, id string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var assigned bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM user_workplaces w
+				JOIN users u ON u.id = w.user_id
+				WHERE w.office_id = $1 AND `+currentStaff+`
+			)
+		`, id).Scan(&assigned)
+		if err != nil {
+			return err
+		}
+		if assigned {
+			return core.ErrOfficeInUse
+		}
+
+		// Only former employees' rows go, so someone assigned in the meantime
+		// still trips the foreign key.
+		_, err = tx.Exec(ctx, `
+			DELETE FROM user_workplaces w
+			USING users u
+			WHERE u.id = w.user_id AND w.office_id = $1 AND u.employment_status = 'terminated'
+		`, id)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `DELETE FROM offices WHERE id = $1`, id)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return core.ErrOfficeInUse
+			}
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("office not found")
+		}
+		return nil
+	})
+}
+
+func requireOffice(ctx context.Context, q querier, id string) error {
+	var exists bool
+	if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM offices WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("office not found")
+	}
+	return nil
+}
+
+const workplaceSelect = `
+	SELECT w.user_id, w.mode, COALESCE(w.office_id::text, ''), COALESCE(o.name, ''), w.office_days, w.updated_at
+	FROM user_workplaces w
+	LEFT JOIN offices o ON o.id = w.office_id
+`
+
+func (r *OfficeRepository) GetWorkplace(ctx context.Context, userID string) (core.Workplace, error) {
+	if err := requireExistingUser(ctx, r.pool, userID); err != nil {
+		return core.Workplace{}, err
+	}
+
+	var w core.Workplace
+	err := r.pool.QueryRow(ctx, workplaceSelect+` WHERE w.user_id = $1`, userID).Scan(
+		&w.UserID, &w.Mode, &w.OfficeID, &w.OfficeName, &w.OfficeDays, &w.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Workplace{}, fmt.Errorf("workplace not found")
+		}
+		return core.Workplace{}, err
+	}
+	return w, nil
+}
+
+// SetWorkplace assigns a user, who must not have been offboarded, to an
+// office or to remote work.
+func (r *OfficeRepository) SetWorkplace(ctx context.Context, w core.Workplace) (core.Workplace, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Workplace{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, _, err := lockEmployee(ctx, tx, w.UserID)
+	if err != nil {
+		return core.Workplace{}, err
+	}
+	if status == core.EmploymentTerminated {
+		return core.Workplace{}, fmt.Errorf("%w: user has been offboarded", core.ErrEmploymentState)
+	}
+	if w.OfficeID != "" {
+		if err := requireOffice(ctx, tx, w.OfficeID); err != nil {
+			return core.Workplace{}, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_workplaces (user_id, mode, office_id, office_days)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET mode = EXCLUDED.mode, office_id = EXCLUDED.office_id, office_days = EXCLUDED.office_days,
+			updated_at = NOW()
+	`, w.UserID, w.Mode, w.OfficeID, w.OfficeDays)
+	if err != nil {
+		return core.Workplace{}, err
+	}
+
+	err = tx.QueryRow(ctx, workplaceSelect+` WHERE w.user_id = $1`, w.UserID).Scan(
+		&w.UserID, &w.Mode, &w.OfficeID, &w.OfficeName, &w.OfficeDays, &w.UpdatedAt,
+	)
+	if err != nil {
+		return core.Workplace{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.Workplace{}, err
+	}
+	return w, nil
+}
+
+// Headcount counts the current staff assigned to each office, or to the
+// office officeID when it is set, and how many are expected on each
+// weekday. Office-based staff count on every weekday.
+func (r *OfficeRepository) Headcount(ctx context.Context, officeID string) ([]core.OfficeHeadcount, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT o.id, o.name, o.city, o.country, o.capacity,
+			COUNT(u.id),
+			COUNT(u.id) FILTER (WHERE w.mode = 'office'),
+			COUNT(u.id) FILTER (WHERE w.mode = 'hybrid'),
+			COUNT(u.id) FILTER (WHERE w.mode = 'office' OR 'mon' = ANY(w.office_days)),
+			COUNT(u.id) FILTER (WHERE w.mode = 'office' OR 'tue' = ANY(w.office_days)),
+			COUNT(u.id) FILTER (WHERE w.mode = 'office' OR 'wed' = ANY(w.office_days)),
+			COUNT(u.id) FILTER (WHERE w.mode = 'office' OR 'thu' = ANY(w.office_days)),
+			COUNT(u.id) FILTER (WHERE w.mode = 'office' OR 'fri' = ANY(w.office_days))
+		FROM offices o
+		LEFT JOIN user_workplaces w ON w.office_id = o.id
+		LEFT JOIN users u ON u.id = w.user_id AND `+currentStaff+`
+		WHERE ($1 = '' OR o.id::text = $1)
+		GROUP BY o.id
+		ORDER BY o.name
+	`, officeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []core.OfficeHeadcount
+	for rows.Next() {
+		var h core.OfficeHeadcount
+		var daily [5]int
+		err := rows.Scan(
+			&h.OfficeID, &h.Name, &h.City, &h.Country, &h.Capacity,
+			&h.Assigned, &h.OfficeBased, &h.Hybrid,
+			&daily[0], &daily[1], &daily[2], &daily[3], &daily[4],
+		)
+		if err != nil {
+			return nil, err
+		}
+		for i, day := range []string{"mon", "tue", "wed", "thu", "fri"} {
+			h.Daily = append(h.Daily, core.OfficeDayLoad{Day: day, Expected: daily[i]})
+		}
+		counts = append(counts, h)
+	}
+	return counts, rows.Err()
+}
+
+// Members lists the current staff working at an office. With day set,
+// only those expected in on that weekday are returned.
+func (r *OfficeRepository) Members(ctx context.Context, officeID, day string) ([]core.OfficeMember, error) {
+	if err := requireOffice(ctx, r.pool, officeID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.first_name, u.last_name, u.email,
+			COALESCE(u.department_id::text, ''), COALESCE(u.position_id::text, ''),
+			w.mode, w.office_days
+		FROM user_workplaces w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.office_id = $1 AND `+currentStaff+`
+			AND ($2 = '' OR w.mode = 'office' OR $2 = ANY(w.office_days))
+		ORDER BY u.last_name, u.first_name
+	`, officeID, day)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.OfficeMember])
+}
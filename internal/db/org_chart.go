@@ -0,0 +1,310 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// orgMemberSelect is shared by the reporting line queries. It expects a CTE
+// or table aliased as t with columns id and depth. Offboarded employees are
+// left out; they have no reports left to hang anyone off.
+const orgMemberSelect = `
+	SELECT u.id, u.email, u.first_name, u.last_name,
+		COALESCE(u.manager_id::text, ''), COALESCE(u.department_id::text, ''),
+		COALESCE(p.title, ''), t.depth
+	FROM t
+	JOIN users u ON u.id = t.id
+	LEFT JOIN positions p ON p.id = u.position_id
+	WHERE u.employment_status <> 'terminated'
+`
+
+func scanOrgMembers(rows pgx.Rows) ([]core.OrgMember, error) {
+	defer rows.Close()
+
+	members := []core.OrgMember{}
+	for rows.Next() {
+		var m core.OrgMember
+		err := rows.Scan(
+			&m.ID, &m.Email, &m.FirstName, &m.LastName,
+			&m.ManagerID, &m.DepartmentID, &m.PositionTitle, &m.Depth,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// lockReportingLines serialises manager changes so two concurrent updates
+// cannot each pass the cycle check and together form a loop.
+func lockReportingLines(ctx context.Context, q querier) error {
+	_, err := q.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('users.manager_id'))`)
+	return err
+}
+
+// isInSubtree reports whether candidateID is rootID or reports to it,
+// directly or indirectly.
+func isInSubtree(ctx context.Context, q querier, rootID, candidateID string) (bool, error) {
+	var found bool
+	err := q.QueryRow(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT id, manager_id, ARRAY[id] AS path
+			FROM users
+			WHERE id = $2
+			UNION ALL
+			SELECT u.id, u.manager_id, c.path || u.id
+			FROM users u
+			JOIN chain c ON u.id = c.manager_id
+			WHERE NOT u.id = ANY(c.path)
+		)
+		SELECT EXISTS (SELECT 1 FROM chain WHERE id = $1)
+	`, rootID, candidateID).Scan(&found)
+	return found, err
+}
+
+func userExists(ctx context.Context, q querier, id string) (bool, error) {
+	var exists bool
+	err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+func (r *UserRepository) SetManager(
+	ctx context.Context,
+	id, managerID string,
+) (core.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockReportingLines(ctx, tx); err != nil {
+		return core.User{}, err
+	}
+
+	exists, err := userExists(ctx, tx, id)
+	if err != nil {
+		return core.User{}, err
+	}
+	if !exists {
+		return core.User{}, fmt.Errorf("user not found")
+	}
+
+	if managerID != "" {
+		if err := requireEmployedManager(ctx, tx, managerID); err != nil {
+			return core.User{}, err
+		}
+
+		cycle, err := isInSubtree(ctx, tx, id, managerID)
+		if err != nil {
+			return core.User{}, err
+		}
+		if cycle {
+			return core.User{}, core.ErrManagerCycle
+		}
+	}
+
+	var user core.User
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET manager_id = NULLIF($1, '')::uuid, updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+userColumns+`
+	`, managerID, id).Scan(userDest(&user)...)
+	if err != nil {
+		return core.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.User{}, err
+	}
+	return user, nil
+}
+
+func (r *UserRepository) GetDirectReports(
+	ctx context.Context,
+	id string,
+) ([]core.OrgMember, error) {
+	if err := r.requireUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH t AS (
+			SELECT id, 1 AS depth FROM users WHERE manager_id = $1
+		)
+	`+orgMemberSelect+`
+		ORDER BY u.last_name, u.first_name
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanOrgMembers(rows)
+}
+
+// GetSubtree returns everyone reporting to id, directly or indirectly, in
+// breadth-first order. The user itself is not included.
+func (r *UserRepository) GetSubtree(
+	ctx context.Context,
+	id string,
+) ([]core.OrgMember, error) {
+	if err := r.requireUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH RECURSIVE t AS (
+			SELECT id, 1 AS depth, ARRAY[id] AS path
+			FROM users
+			WHERE manager_id = $1
+			UNION ALL
+			SELECT u.id, t.depth + 1, t.path || u.id
+			FROM users u
+			JOIN t ON u.manager_id = t.id
+			WHERE NOT u.id = ANY(t.path)
+		)
+	`+orgMemberSelect+`
+		ORDER BY t.depth, u.last_name, u.first_name
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanOrgMembers(rows)
+}
+
+// GetManagementChain returns the managers above id, nearest first.
+func (r *UserRepository) GetManagementChain(
+	ctx context.Context,
+	id string,
+) ([]core.OrgMember, error) {
+	if err := r.requireUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH RECURSIVE t AS (
+			SELECT m.id, 1 AS depth, ARRAY[u.id, m.id] AS path
+			FROM users u
+			JOIN users m ON m.id = u.manager_id
+			WHERE u.id = $1
+			UNION ALL
+			SELECT m.id, t.depth + 1, t.path || m.id
+			FROM t
+			JOIN users u ON u.id = t.id
+			JOIN users m ON m.id = u.manager_id
+			WHERE NOT m.id = ANY(t.path)
+		)
+	`+orgMemberSelect+`
+		ORDER BY t.depth
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanOrgMembers(rows)
+}
+
+// GetOrgMembers returns the flat member list for an org chart: the whole
+// company when rootID is empty, otherwise rootID and its subtree. Depth is
+// counted from the top of the returned chart.
+func (r *UserRepository) GetOrgMembers(
+	ctx context.Context,
+	rootID string,
+) ([]core.OrgMember, error) {
+	if rootID != "" {
+		if err := r.requireUser(ctx, rootID); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH RECURSIVE t AS (
+			SELECT id, 0 AS depth, ARRAY[id] AS path
+			FROM users
+			WHERE ($1::text = '' AND manager_id IS NULL) OR id::text = $1::text
+			UNION ALL
+			SELECT u.id, t.depth + 1, t.path || u.id
+			FROM users u
+			JOIN t ON u.manager_id = t.id
+			WHERE NOT u.id = ANY(t.path)
+		)
+	`+orgMemberSelect+`
+		ORDER BY t.depth, u.last_name, u.first_name
+	`, rootID)
+	if err != nil {
+		return nil, err
+	}
+	return scanOrgMembers(rows)
+}
+
+func (r *UserRepository) requireUser(ctx context.Context, id string) error {
+	exists, err := userExists(ctx, r.pool, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// reassignDirectReports moves the reports of a user that is about to be
+// deleted. Without a target it refuses when there is anyone to move.
+func reassignDirectReports(ctx context.Context, q querier, id, reassignTo string) error {
+	if err := lockReportingLines(ctx, q); err != nil {
+		return err
+	}
+
+	var reports int
+	var managerID *string
+	err := q.QueryRow(ctx, `
+		SELECT manager_id::text, (SELECT COUNT(*) FROM users WHERE manager_id = $1)
+		FROM users
+		WHERE id = $1
+	`, id).Scan(&managerID, &reports)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("user not found")
+		}
+		return err
+	}
+	if reports == 0 {
+		return nil
+	}
+
+	var target *string
+	switch reassignTo {
+	case "":
+		return core.ErrHasDirectReports
+	case core.ReassignToManager:
+		target = managerID
+	default:
+		exists, err := userExists(ctx, q, reassignTo)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("reassignment target not found")
+		}
+		cycle, err := isInSubtree(ctx, q, id, reassignTo)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return core.ErrManagerCycle
+		}
+		target = &reassignTo
+	}
+
+	_, err = q.Exec(ctx, `
+		UPDATE users
+		SET manager_id = $1::uuid, updated_at = NOW()
+		WHERE manager_id = $2
+	`, target, id)
+	return err
+}
@@ -0,0 +1,313 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PayrollRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPayrollRepository(pool *pgxpool.Pool) *PayrollRepository {
+	return &PayrollRepository{pool: pool}
+}
+
+// LoadEmployees returns everyone hired on or before end and not terminated
+// before start, together with the salaries that took effect on or before
+// end.
+func (r *PayrollRepository) LoadEmployees(
+	ctx context.Context,
+	start, end time.Time,
+) ([]core.PayrollEmployee, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.first_name || ' ' || u.last_name,
+			COALESCE(d.id::text, ''), COALESCE(d.name, ''), u.hire_date, u.termination_date
+		FROM users u
+		LEFT JOIN departments d ON d.id = u.department_id
+		WHERE u.hire_date <= $1::date
+			AND (u.termination_date IS NULL OR u.termination_date >= $2::date)
+		ORDER BY d.name, u.last_name, u.first_name
+	`, end, start)
+	if err != nil {
+		return nil, err
+	}
+
+	employees, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (core.PayrollEmployee, error) {
+		var e core.PayrollEmployee
+		err := row.Scan(&e.UserID, &e.Name, &e.DepartmentID, &e.DepartmentName, &e.HireDate, &e.TerminationDate)
+		return e, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(employees))
+	for i, e := range employees {
+		index[e.UserID] = i
+	}
+
+	rows, err = r.pool.Query(ctx, `
+		SELECT id, user_id, amount, currency, effective_date, out_of_band, created_at, updated_at
+		FROM salaries
+		WHERE effective_date <= $1::date
+		ORDER BY user_id, effective_date, created_at
+	`, end)
+	if err != nil {
+		return nil, err
+	}
+	salaries, err := pgx.CollectRows(rows, pgx.RowToStructByPos[core.Salary])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range salaries {
+		if i, ok := index[s.UserID]; ok {
+			employees[i].Salaries = append(employees[i].Salaries, s)
+		}
+	}
+
+	return employees, nil
+}
+
+func (r *PayrollRepository) CreateRun(
+	ctx context.Context,
+	run core.PayrollRun,
+) (core.PayrollRun, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO payroll_runs (period_type, period_start, period_end, status, currency, total, employee_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, run.PeriodType, run.PeriodStart, run.PeriodEnd, core.PayrollStatusDraft,
+		run.Currency, run.Total, run.EmployeeCount,
+	).Scan(&run.ID, &run.CreatedAt, &run.UpdatedAt)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	run.Status = core.PayrollStatusDraft
+
+	if err := insertPayrollLines(ctx, tx, run.ID, run.Lines); err != nil {
+		return core.PayrollRun{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.PayrollRun{}, err
+	}
+	return run, nil
+}
+
+// ReplaceLines swaps the line items and totals of a draft run.
+func (r *PayrollRepository) ReplaceLines(
+	ctx context.Context,
+	id string,
+	run core.PayrollRun,
+) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM payroll_runs WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("payroll run not found")
+		}
+		return err
+	}
+	if status != core.PayrollStatusDraft {
+		return core.ErrPayrollRunState
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM payroll_run_lines WHERE run_id = $1`, id); err != nil {
+		return err
+	}
+	if err := insertPayrollLines(ctx, tx, id, run.Lines); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payroll_runs
+		SET total = $1, employee_count = $2, updated_at = NOW()
+		WHERE id = $3
+	`, run.Total, run.EmployeeCount, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func insertPayrollLines(ctx context.Context, q querier, runID string, lines []core.PayrollLine) error {
+	for _, l := range lines {
+		_, err := q.Exec(ctx, `
+			INSERT INTO payroll_run_lines (run_id, user_id, employee_name, department_id, department_name,
+										   days_worked, period_days, amount, segments)
+			VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9)
+		`, runID, l.UserID, l.EmployeeName, l.DepartmentID, l.DepartmentName,
+			l.DaysWorked, l.PeriodDays, l.Amount, l.Segments)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const payrollRunColumns = `
+	id, period_type, period_start, period_end, status, currency, total, employee_count,
+	approved_at, locked_at, created_at, updated_at
+`
+
+func scanPayrollRun(row pgx.Row, run *core.PayrollRun) error {
+	return row.Scan(
+		&run.ID, &run.PeriodType, &run.PeriodStart, &run.PeriodEnd, &run.Status,
+		&run.Currency, &run.Total, &run.EmployeeCount, &run.ApprovedAt, &run.LockedAt,
+		&run.CreatedAt, &run.UpdatedAt,
+	)
+}
+
+func (r *PayrollRepository) ListRuns(
+	ctx context.Context,
+	page, limit int,
+) ([]core.PayrollRun, int64, error) {
+	offset := (page - 1) * limit
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM payroll_runs").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+payrollRunColumns+`
+		FROM payroll_runs
+		ORDER BY period_start DESC, created_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	runs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (core.PayrollRun, error) {
+		var run core.PayrollRun
+		err := scanPayrollRun(row, &run)
+		return run, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+// GetRun loads a run with its line items and the per-department totals.
+func (r *PayrollRepository) GetRun(
+	ctx context.Context,
+	id string,
+) (core.PayrollRun, error) {
+	var run core.PayrollRun
+	err := scanPayrollRun(r.pool.QueryRow(ctx, `
+		SELECT `+payrollRunColumns+`
+		FROM payroll_runs
+		WHERE id = $1
+	`, id), &run)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.PayrollRun{}, fmt.Errorf("payroll run not found")
+		}
+		return core.PayrollRun{}, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT COALESCE(department_id::text, ''), department_name, COUNT(*), SUM(amount)
+		FROM payroll_run_lines
+		WHERE run_id = $1
+		GROUP BY department_id, department_name
+		ORDER BY department_name
+	`, id)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	run.Departments, err = pgx.CollectRows(rows, pgx.RowToStructByPos[core.PayrollDepartmentTotal])
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+
+	rows, err = r.pool.Query(ctx, `
+		SELECT id, run_id, user_id, employee_name, COALESCE(department_id::text, ''), department_name,
+			days_worked, period_days, amount, segments
+		FROM payroll_run_lines
+		WHERE run_id = $1
+		ORDER BY department_name, employee_name
+	`, id)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	run.Lines, err = pgx.CollectRows(rows, pgx.RowToStructByPos[core.PayrollLine])
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+
+	return run, nil
+}
+
+// TransitionRun moves a run from one status to the next. It fails with
+// core.ErrPayrollRunState when the run is not currently in from.
+func (r *PayrollRepository) TransitionRun(
+	ctx context.Context,
+	id, from, to string,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE payroll_runs
+		SET status = $3,
+			approved_at = CASE WHEN $3 = 'approved' THEN NOW() ELSE approved_at END,
+			locked_at = CASE WHEN $3 = 'locked' THEN NOW() ELSE locked_at END,
+			updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`, id, from, to)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return r.runStateError(ctx, id)
+	}
+	return nil
+}
+
+func (r *PayrollRepository) DeleteRun(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM payroll_runs WHERE id = $1 AND status = 'draft'
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return r.runStateError(ctx, id)
+	}
+	return nil
+}
+
+func (r *PayrollRepository) runStateError(ctx context.Context, id string) error {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM payroll_runs WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("payroll run not found")
+	}
+	return core.ErrPayrollRunState
+}
@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const positionAssignmentSelect = `
+	SELECT a.id, a.user_id, COALESCE(a.position_id::text, ''), COALESCE(p.title, ''), COALESCE(p.level, 0),
+		COALESCE(a.department_id::text, ''), COALESCE(d.name, ''), a.start_date, a.end_date, a.reason,
+		COALESCE(a.salary_id::text, ''), a.note, a.created_at
+	FROM position_assignments a
+	LEFT JOIN positions p ON p.id = a.position_id
+	LEFT JOIN departments d ON d.id = a.department_id
+`
+
+// syncPositionAssignment brings the history of a user in line with the
+// position and department now stored on the user, effective from the given
+// date. Nothing is written when neither changed. An effective date on or
+// before the start of the open assignment corrects that assignment instead
+// of starting a new one. It returns the ID of the open assignment.
+func syncPositionAssignment(
+	ctx context.Context,
+	q querier,
+	userID string,
+	effective time.Time,
+	reason, salaryID, note string,
+) (string, error) {
+	effective = time.Date(effective.Year(), effective.Month(), effective.Day(), 0, 0, 0, 0, time.UTC)
+
+	var positionID, departmentID string
+	err := q.QueryRow(ctx, `
+		SELECT COALESCE(position_id::text, ''), COALESCE(department_id::text, '') FROM users WHERE id = $1
+	`, userID).Scan(&positionID, &departmentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", err
+	}
+
+	var openID, openPosition, openDepartment string
+	var openStart time.Time
+	err = q.QueryRow(ctx, `
+		SELECT id, COALESCE(position_id::text, ''), COALESCE(department_id::text, ''), start_date
+		FROM position_assignments
+		WHERE user_id = $1 AND end_date IS NULL
+		FOR UPDATE
+	`, userID).Scan(&openID, &openPosition, &openDepartment, &openStart)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		openID = ""
+	case err != nil:
+		return "", err
+	}
+
+	if openID != "" {
+		if openPosition == positionID && openDepartment == departmentID {
+			return openID, nil
+		}
+		if !effective.After(openStart) {
+			// A correction keeps the hire or rehire that opened the assignment.
+			_, err := q.Exec(ctx, `
+				UPDATE position_assignments
+				SET position_id = NULLIF($2, '')::uuid, department_id = NULLIF($3, '')::uuid,
+					reason = CASE WHEN reason IN ('hire', 'rehire') AND $4 = 'change' THEN reason ELSE $4 END,
+					salary_id = COALESCE(NULLIF($5, '')::uuid, salary_id),
+					note = CASE WHEN $6 = '' THEN note ELSE $6 END
+				WHERE id = $1
+			`, openID, positionID, departmentID, reason, salaryID, note)
+			return openID, err
+		}
+		_, err := q.Exec(ctx, `UPDATE position_assignments SET end_date = $2 WHERE id = $1`, openID, effective)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var id string
+	err = q.QueryRow(ctx, `
+		INSERT INTO position_assignments (user_id, position_id, department_id, start_date, reason, salary_id, note)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, NULLIF($6, '')::uuid, $7)
+		RETURNING id
+	`, userID, positionID, departmentID, effective, reason, salaryID, note).Scan(&id)
+	return id, err
+}
+
+// closePositionAssignment ends the open assignment of a user whose last
+// working day is lastDay.
+func closePositionAssignment(ctx context.Context, q querier, userID string, lastDay time.Time) error {
+	var openID string
+	var openStart time.Time
+	err := q.QueryRow(ctx, `
+		SELECT id, start_date FROM position_assignments
+		WHERE user_id = $1 AND end_date IS NULL
+		FOR UPDATE
+	`, userID).Scan(&openID, &openStart)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if lastDay.Before(openStart) {
+		return fmt.Errorf("termination_date must not be before the current position started on %s",
+			openStart.Format(time.DateOnly))
+	}
+
+	_, err = q.Exec(ctx, `
+		UPDATE position_assignments SET end_date = $2::date + 1 WHERE id = $1
+	`, openID, lastDay)
+	return err
+}
+
+func getPositionAssignment(ctx context.Context, q querier, id string) (core.PositionAssignment, error) {
+	rows, err := q.Query(ctx, positionAssignmentSelect+` WHERE a.id = $1`, id)
+	if err != nil {
+		return core.PositionAssignment{}, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToStructByPos[core.PositionAssignment])
+}
+
+// Promote moves a user to a new position, and optionally department, and
+// stores the new salary in the same transaction. The salary is checked
+// against the band of the new position.
+func (r *UserRepository) Promote(
+	ctx context.Context,
+	id string,
+	p core.Promotion,
+) (core.PromotionResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, _, err := lockEmployee(ctx, tx, id)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+	if status == core.EmploymentTerminated {
+		return core.PromotionResult{}, fmt.Errorf("%w: user has been offboarded", core.ErrEmploymentState)
+	}
+
+	var currentPosition, currentDepartment string
+	var openStart *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(u.position_id::text, ''), COALESCE(u.department_id::text, ''),
+			(SELECT start_date FROM position_assignments WHERE user_id = u.id AND end_date IS NULL)
+		FROM users u WHERE u.id = $1
+	`, id).Scan(&currentPosition, &currentDepartment, &openStart)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+
+	if p.DepartmentID == "" {
+		p.DepartmentID = currentDepartment
+	}
+	if p.PositionID == currentPosition && p.DepartmentID == currentDepartment {
+		return core.PromotionResult{}, fmt.Errorf("position_id or department_id must differ from the current ones")
+	}
+	if openStart != nil && p.EffectiveDate.Before(*openStart) {
+		return core.PromotionResult{}, fmt.Errorf("effective_date must not be before the current position started on %s",
+			openStart.Format(time.DateOnly))
+	}
+
+	var positionExists, departmentExists bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM positions WHERE id = $1),
+			EXISTS (SELECT 1 FROM departments WHERE id = $2)
+	`, p.PositionID, p.DepartmentID).Scan(&positionExists, &departmentExists)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+	if !positionExists {
+		return core.PromotionResult{}, fmt.Errorf("position not found")
+	}
+	if !departmentExists {
+		return core.PromotionResult{}, fmt.Errorf("department not found")
+	}
+
+	var result core.PromotionResult
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET position_id = $2, department_id = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+userColumns,
+		id, p.PositionID, p.DepartmentID,
+	).Scan(userDest(&result.User)...)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+
+	// The band check reads the position from users, so it sees the new one.
+	salary := core.Salary{UserID: id, Amount: p.Amount, Currency: p.Currency, EffectiveDate: p.EffectiveDate}
+	if err := checkSalaryDate(ctx, tx, id, salary.EffectiveDate, ""); err != nil {
+		return core.PromotionResult{}, err
+	}
+	salary.OutOfBand, err = checkSalaryBand(ctx, tx, salary, p.AllowOutOfBand)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+	if result.Salary, err = insertSalary(ctx, tx, salary); err != nil {
+		return core.PromotionResult{}, err
+	}
+
+	assignmentID, err := syncPositionAssignment(ctx, tx, id, p.EffectiveDate, core.AssignmentPromotion, result.Salary.ID, p.Note)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+	if result.Assignment, err = getPositionAssignment(ctx, tx, assignmentID); err != nil {
+		return core.PromotionResult{}, err
+	}
+
+	if err := syncDepartmentChannelsForEmployee(ctx, tx, id); err != nil {
+		return core.PromotionResult{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.PromotionResult{}, err
+	}
+	return result, nil
+}
+
+// ListPositionAssignments returns the position history of a user, oldest
+// first.
+func (r *UserRepository) ListPositionAssignments(
+	ctx context.Context,
+	id string,
+) ([]core.PositionAssignment, error) {
+	if err := r.requireUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, positionAssignmentSelect+`
+		WHERE a.user_id = $1
+		ORDER BY a.start_date
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.PositionAssignment])
+}
+
+// TimeInRole reports since when current staff have held their position,
+// longest first. A new role starts when the position changes or after a
+// gap in employment; department moves do not count. departmentID and
+// userID narrow the result down when set.
+func (r *UserRepository) TimeInRole(
+	ctx context.Context,
+	departmentID, userID string,
+) ([]core.TimeInRole, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH runs AS (
+			SELECT a.user_id, a.start_date,
+				LAG(a.position_id) OVER w IS DISTINCT FROM a.position_id
+					OR LAG(a.end_date) OVER w IS DISTINCT FROM a.start_date AS new_role
+			FROM position_assignments a
+			WHERE a.start_date <= CURRENT_DATE
+			WINDOW w AS (PARTITION BY a.user_id ORDER BY a.start_date)
+		), role_start AS (
+			SELECT user_id, MAX(start_date) AS since
+			FROM runs
+			WHERE new_role
+			GROUP BY user_id
+		)
+		SELECT u.id, u.first_name, u.last_name, COALESCE(u.department_id::text, ''),
+			COALESCE(u.position_id::text, ''), COALESCE(p.title, ''), rs.since,
+			CURRENT_DATE - rs.since, ROUND((CURRENT_DATE - rs.since) / 365.25, 2)::float8
+		FROM users u
+		JOIN role_start rs ON rs.user_id = u.id
+		LEFT JOIN positions p ON p.id = u.position_id
+		WHERE `+departmentFilter+` AND `+currentStaff+`
+			AND ($2::text = '' OR u.id::text = $2::text)
+		ORDER BY rs.since, u.last_name, u.first_name
+	`, departmentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.TimeInRole])
+}
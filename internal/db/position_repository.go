@@ -56,7 +56,7 @@ func (r *PositionRepository) Create(
 ) (core.Position, error) {
 	query := `
 		INSERT INTO positions (title, level, department_id)
-		VALUES ($1, $2)
+		VALUES ($1, $2, $3)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -105,16 +105,27 @@ func (r *PositionRepository) Update(
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return core.Position{}, fmt.Errorf("user not found")
+			return core.Position{}, fmt.Errorf("position not found")
 		}
 		return core.Position{}, err
 	}
 
-	_, err = r.pool.Exec(ctx, `
+	if update.Title != nil {
+		p.Title = *update.Title
+	}
+	if update.Level != nil {
+		p.Level = *update.Level
+	}
+	if update.DepartmentID != "" {
+		p.DepartmentID = update.DepartmentID
+	}
+
+	err = r.pool.QueryRow(ctx, `
 		UPDATE positions
 		SET title = $1, level = $2, department_id = $3, updated_at = NOW()
 		WHERE id = $4
-	`, p.Title, p.Level, p.DepartmentID, id)
+		RETURNING updated_at
+	`, p.Title, p.Level, p.DepartmentID, id).Scan(&p.UpdatedAt)
 	if err != nil {
 		return core.Position{}, err
 	}
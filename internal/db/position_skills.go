@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const positionSkillColumns = `
+	ps.position_id, ps.skill_id, s.name, ps.min_proficiency, ps.required, ps.created_at, ps.updated_at
+`
+
+func (r *PositionRepository) ListSkills(
+	ctx context.Context,
+	positionID string,
+) ([]core.PositionSkill, error) {
+	if err := r.requirePosition(ctx, positionID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+positionSkillColumns+`
+		FROM position_skills ps
+		JOIN skills s ON s.id = ps.skill_id
+		WHERE ps.position_id = $1
+		ORDER BY ps.required DESC, s.name
+	`, positionID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.PositionSkill])
+}
+
+// UpsertSkill creates or replaces the requirement of a position for one
+// skill.
+func (r *PositionRepository) UpsertSkill(
+	ctx context.Context,
+	positionID, skillID string,
+	in core.PositionSkillUpsert,
+) (core.PositionSkill, error) {
+	if err := r.requirePosition(ctx, positionID); err != nil {
+		return core.PositionSkill{}, err
+	}
+
+	required := true
+	if in.Required != nil {
+		required = *in.Required
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH ps AS (
+			INSERT INTO position_skills (position_id, skill_id, min_proficiency, required)
+			SELECT $1, s.id, $3, $4
+			FROM skills s
+			WHERE s.id::text = $2
+			ON CONFLICT (position_id, skill_id) DO UPDATE
+			SET min_proficiency = EXCLUDED.min_proficiency,
+				required = EXCLUDED.required,
+				updated_at = NOW()
+			RETURNING *
+		)
+		SELECT `+positionSkillColumns+`
+		FROM ps
+		JOIN skills s ON s.id = ps.skill_id
+	`, positionID, skillID, in.MinProficiency, required)
+	if err != nil {
+		return core.PositionSkill{}, err
+	}
+	ps, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByPos[core.PositionSkill])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.PositionSkill{}, fmt.Errorf("skill not found")
+		}
+		return core.PositionSkill{}, err
+	}
+	return ps, nil
+}
+
+func (r *PositionRepository) DeleteSkill(
+	ctx context.Context,
+	positionID, skillID string,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM position_skills WHERE position_id = $1 AND skill_id::text = $2
+	`, positionID, skillID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("position skill not found")
+	}
+	return nil
+}
+
+// UserPosition returns the id of the user's current position, empty when
+// they have none.
+func (r *PositionRepository) UserPosition(ctx context.Context, userID string) (string, error) {
+	var positionID string
+	err := r.pool.QueryRow(ctx, `
+		SELECT COALESCE(position_id::text, '') FROM users WHERE id::text = $1
+	`, userID).Scan(&positionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", err
+	}
+	return positionID, nil
+}
+
+// SkillGaps aggregates, per required skill, how far the employees of a
+// department fall short of what their current positions ask for. An empty
+// departmentID covers the whole company.
+func (r *DepartmentRepository) SkillGaps(
+	ctx context.Context,
+	departmentID string,
+) (core.DepartmentSkillGapReport, error) {
+	if departmentID != "" {
+		var exists bool
+		err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM departments WHERE id::text = $1)`, departmentID).Scan(&exists)
+		if err != nil {
+			return core.DepartmentSkillGapReport{}, err
+		}
+		if !exists {
+			return core.DepartmentSkillGapReport{}, fmt.Errorf("department not found")
+		}
+	}
+
+	report := core.DepartmentSkillGapReport{DepartmentID: departmentID}
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT u.id)
+		FROM users u
+		JOIN position_skills ps ON ps.position_id = u.position_id
+		WHERE `+departmentFilter+` AND `+currentStaff,
+		departmentID,
+	).Scan(&report.Employees)
+	if err != nil {
+		return core.DepartmentSkillGapReport{}, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		WITH gaps AS (
+			SELECT ps.skill_id, ps.required,
+				GREATEST(ps.min_proficiency - COALESCE(us.proficiency_level, 0), 0) AS gap
+			FROM users u
+			JOIN position_skills ps ON ps.position_id = u.position_id
+			LEFT JOIN user_skills us ON us.user_id = u.id AND us.skill_id = ps.skill_id
+			WHERE `+departmentFilter+` AND `+currentStaff+`
+		)
+		SELECT g.skill_id, s.name, g.required, COUNT(*)::int,
+			COUNT(*) FILTER (WHERE g.gap > 0)::int,
+			SUM(g.gap)::int,
+			ROUND(AVG(g.gap), 2)::float8
+		FROM gaps g
+		JOIN skills s ON s.id = g.skill_id
+		GROUP BY g.skill_id, s.name, g.required
+		ORDER BY g.required DESC, SUM(g.gap) DESC, s.name
+	`, departmentID)
+	if err != nil {
+		return core.DepartmentSkillGapReport{}, err
+	}
+	report.Gaps, err = pgx.CollectRows(rows, pgx.RowToStructByPos[core.DepartmentSkillGap])
+	if err != nil {
+		return core.DepartmentSkillGapReport{}, err
+	}
+	return report, nil
+}
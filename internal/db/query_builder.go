@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+)
+
+// sqlFilter collects AND-ed conditions and the values bound to them.
+// Conditions are fixed SQL written with ? for each value; the values are
+// only ever sent as parameters, never spliced into the statement. Other
+// parameters, such as LIMIT and OFFSET, are added with bind so that they
+// number on after the filter's own.
+type sqlFilter struct {
+	conds []string
+	args  []any
+}
+
+// where adds a condition. Every ? in cond takes the next of args, so a
+// value used twice is passed twice.
+func (f *sqlFilter) where(cond string, args ...any) {
+	if n := strings.Count(cond, "?"); n != len(args) {
+		panic(fmt.Sprintf("sqlFilter: %d placeholders for %d values in %q", n, len(args), cond))
+	}
+	var b strings.Builder
+	for _, part := range strings.SplitAfter(cond, "?") {
+		if !strings.HasSuffix(part, "?") {
+			b.WriteString(part)
+			continue
+		}
+		b.WriteString(part[:len(part)-1])
+		b.WriteString("$" + strconv.Itoa(f.bind(args[0])))
+		args = args[1:]
+	}
+	f.conds = append(f.conds, b.String())
+}
+
+// bind adds a parameter and returns its number.
+func (f *sqlFilter) bind(v any) int {
+	f.args = append(f.args, v)
+	return len(f.args)
+}
+
+// placeholder adds a parameter and returns it as $n.
+func (f *sqlFilter) placeholder(v any) string {
+	return "$" + strconv.Itoa(f.bind(v))
+}
+
+// clause renders the WHERE clause, or nothing without conditions.
+func (f *sqlFilter) clause() string {
+	if len(f.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(f.conds, "\n\t\t\tAND ")
+}
+
+// likePattern escapes the LIKE wildcards in s and wraps it in % so it
+// matches s literally anywhere in a value.
+func likePattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqlOrder builds an ORDER BY clause from fields looked up in columns, so
+// only known expressions reach the statement. tiebreak is appended to keep
+// pages stable.
+func sqlOrder(columns map[string]string, fields []core.SortField, tiebreak string) (string, error) {
+	terms := make([]string, 0, len(fields)+1)
+	for _, f := range fields {
+		expr, ok := columns[f.Field]
+		if !ok {
+			return "", fmt.Errorf("cannot sort by %q", f.Field)
+		}
+		dir := " ASC"
+		if f.Desc {
+			dir = " DESC"
+		}
+		terms = append(terms, expr+dir+" NULLS LAST")
+	}
+	terms = append(terms, tiebreak)
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
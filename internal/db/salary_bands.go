@@ -0,0 +1,217 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+const salaryBandColumns = `
+	b.id, b.position_id, b.level, b.currency, b.min_amount, b.mid_amount, b.max_amount,
+	b.created_at, b.updated_at
+`
+
+func salaryBandDest(b *core.SalaryBand) []any {
+	return []any{
+		&b.ID, &b.PositionID, &b.Level, &b.Currency, &b.Min, &b.Mid, &b.Max,
+		&b.CreatedAt, &b.UpdatedAt,
+	}
+}
+
+func (r *PositionRepository) ListBands(
+	ctx context.Context,
+	positionID string,
+) ([]core.SalaryBand, error) {
+	if err := r.requirePosition(ctx, positionID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+salaryBandColumns+`
+		FROM salary_bands b
+		WHERE b.position_id = $1
+		ORDER BY b.level
+	`, positionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bands := []core.SalaryBand{}
+	for rows.Next() {
+		var b core.SalaryBand
+		if err := rows.Scan(salaryBandDest(&b)...); err != nil {
+			return nil, err
+		}
+		bands = append(bands, b)
+	}
+	return bands, rows.Err()
+}
+
+// UpsertBand creates or replaces the band of a position at one level.
+func (r *PositionRepository) UpsertBand(
+	ctx context.Context,
+	positionID string,
+	level int,
+	band core.SalaryBandUpsert,
+) (core.SalaryBand, error) {
+	if err := r.requirePosition(ctx, positionID); err != nil {
+		return core.SalaryBand{}, err
+	}
+
+	var b core.SalaryBand
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO salary_bands AS b (position_id, level, currency, min_amount, mid_amount, max_amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (position_id, level) DO UPDATE
+		SET currency = EXCLUDED.currency,
+			min_amount = EXCLUDED.min_amount,
+			mid_amount = EXCLUDED.mid_amount,
+			max_amount = EXCLUDED.max_amount,
+			updated_at = NOW()
+		RETURNING `+salaryBandColumns,
+		positionID, level, band.Currency, band.Min, band.Mid, band.Max,
+	).Scan(salaryBandDest(&b)...)
+	if err != nil {
+		return core.SalaryBand{}, err
+	}
+	return b, nil
+}
+
+func (r *PositionRepository) DeleteBand(
+	ctx context.Context,
+	positionID string,
+	level int,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM salary_bands WHERE position_id = $1 AND level = $2
+	`, positionID, level)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("salary band not found")
+	}
+	return nil
+}
+
+func (r *PositionRepository) requirePosition(ctx context.Context, id string) error {
+	var exists bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM positions WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("position not found")
+	}
+	return nil
+}
+
+// bandStatus places an amount, already in the band currency, relative to
+// the band.
+func bandStatus(amount decimal.Decimal, band core.SalaryBand) string {
+	switch {
+	case amount.LessThan(band.Min):
+		return core.BandBelow
+	case amount.GreaterThan(band.Max):
+		return core.BandAbove
+	}
+	return core.BandWithin
+}
+
+// checkSalaryBand compares s with the band of the employee's position at
+// its current level. An amount outside the band fails with
+// core.ErrSalaryOutOfBand unless allow is set; the result reports whether
+// the salary has to be flagged. Employees without a band are not checked.
+func checkSalaryBand(ctx context.Context, q querier, s core.Salary, allow bool) (bool, error) {
+	var band core.SalaryBand
+	var amount decimal.NullDecimal
+	err := q.QueryRow(ctx, `
+		SELECT `+salaryBandColumns+`,
+			ROUND($2::numeric * exchange_rate_on(b.currency, $4::date) / exchange_rate_on($3, $4::date), 2)
+		FROM users u
+		JOIN positions p ON p.id = u.position_id
+		JOIN salary_bands b ON b.position_id = p.id AND b.level = p.level
+		WHERE u.id = $1
+	`, s.UserID, s.Amount, s.Currency, s.EffectiveDate).Scan(append(salaryBandDest(&band), &amount)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !amount.Valid {
+		return false, fmt.Errorf("%w to compare %s with the %s band", core.ErrNoExchangeRate, s.Currency, band.Currency)
+	}
+
+	status := bandStatus(amount.Decimal, band)
+	if status == core.BandWithin {
+		return false, nil
+	}
+	if !allow {
+		return false, fmt.Errorf("%w: %s %s is %s %s-%s %s",
+			core.ErrSalaryOutOfBand, amount.Decimal.StringFixed(2), band.Currency, status,
+			band.Min.StringFixed(2), band.Max.StringFixed(2), band.Currency)
+	}
+	return true, nil
+}
+
+// CompaRatios lists every current employee that has both a band and a
+// current salary, with the salary converted at today's rates.
+func (r *SalaryRepository) CompaRatios(ctx context.Context) ([]core.CompaRatio, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.email, u.first_name, u.last_name, p.id, p.title, p.level,
+			`+salaryBandColumns+`,
+			cs.id, cs.user_id, cs.amount, cs.currency, cs.effective_date, cs.out_of_band,
+			cs.created_at, cs.updated_at,
+			ROUND(cs.amount * exchange_rate_on(b.currency, CURRENT_DATE) / exchange_rate_on(cs.currency, CURRENT_DATE), 2)
+		FROM users u
+		JOIN positions p ON p.id = u.position_id
+		JOIN salary_bands b ON b.position_id = p.id AND b.level = p.level
+		JOIN LATERAL (
+			SELECT *
+			FROM salaries s
+			WHERE s.user_id = u.id AND s.effective_date <= CURRENT_DATE
+			ORDER BY s.effective_date DESC, s.created_at DESC
+			LIMIT 1
+		) cs ON true
+		WHERE `+currentStaff+`
+		ORDER BY p.title, u.last_name, u.first_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratios := []core.CompaRatio{}
+	for rows.Next() {
+		var c core.CompaRatio
+		dest := []any{
+			&c.UserID, &c.Email, &c.FirstName, &c.LastName, &c.PositionID, &c.PositionTitle, &c.Level,
+		}
+		dest = append(dest, salaryBandDest(&c.Band)...)
+		dest = append(dest,
+			&c.Salary.ID, &c.Salary.UserID, &c.Salary.Amount, &c.Salary.Currency, &c.Salary.EffectiveDate,
+			&c.Salary.OutOfBand, &c.Salary.CreatedAt, &c.Salary.UpdatedAt,
+			&c.Amount,
+		)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		c.Status = core.BandNoRate
+		if c.Amount.Valid {
+			c.Status = bandStatus(c.Amount.Decimal, c.Band)
+			if c.Band.Mid.IsPositive() {
+				c.Ratio = decimal.NewNullDecimal(c.Amount.Decimal.Div(c.Band.Mid).Round(3))
+			}
+		}
+		ratios = append(ratios, c)
+	}
+	return ratios, rows.Err()
+}
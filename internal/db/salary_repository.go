@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"multi-processing-backend/internal/core"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -30,7 +31,7 @@ func (r *SalaryRepository) List(
 	}
 
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, user_id, amount, effective_date, created_at, updated_at
+		SELECT id, user_id, amount, currency, effective_date, out_of_band, created_at, updated_at
 		FROM salaries
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -49,23 +50,50 @@ func (r *SalaryRepository) List(
 	return sals, total, nil
 }
 
+// Create stores a salary after checking it against the employee's band.
+// With allowOutOfBand an out-of-band amount is stored and flagged.
 func (r *SalaryRepository) Create(
 	ctx context.Context,
 	s core.Salary,
+	allowOutOfBand bool,
 ) (core.Salary, error) {
-	query := `
-		INSERT INTO salaries (user_id, amount, effective_date)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
-	`
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Salary{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkSalaryDate(ctx, tx, s.UserID, s.EffectiveDate, ""); err != nil {
+		return core.Salary{}, err
+	}
+
+	s.OutOfBand, err = checkSalaryBand(ctx, tx, s, allowOutOfBand)
+	if err != nil {
+		return core.Salary{}, err
+	}
 
-	err := r.pool.QueryRow(
-		ctx, query, s.UserID, s.Amount, s.EffectiveDate,
-	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	s, err = insertSalary(ctx, tx, s)
 	if err != nil {
 		return core.Salary{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return core.Salary{}, err
+	}
+
+	return s, nil
+}
+
+// insertSalary stores a salary that has passed the date and band checks.
+func insertSalary(ctx context.Context, q querier, s core.Salary) (core.Salary, error) {
+	err := q.QueryRow(ctx, `
+		INSERT INTO salaries (user_id, amount, currency, effective_date, out_of_band)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, s.UserID, s.Amount, s.Currency, s.EffectiveDate, s.OutOfBand).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return core.Salary{}, err
+	}
 	return s, nil
 }
 
@@ -75,11 +103,11 @@ func (r *SalaryRepository) Get(
 ) (core.Salary, error) {
 	var s core.Salary
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, user_id, amount, effective_date, created_at, updated_at
+		SELECT id, user_id, amount, currency, effective_date, out_of_band, created_at, updated_at
 		FROM salaries 
 		WHERE id = $1
 	`, id).Scan(
-		&s.ID, &s.UserID, &s.Amount, &s.EffectiveDate, &s.CreatedAt, &s.UpdatedAt,
+		&s.ID, &s.UserID, &s.Amount, &s.Currency, &s.EffectiveDate, &s.OutOfBand, &s.CreatedAt, &s.UpdatedAt,
 	)
 	if err != nil {
 		return core.Salary{}, err
@@ -91,15 +119,23 @@ func (r *SalaryRepository) Update(
 	ctx context.Context,
 	id string,
 	update core.SalaryUpdate,
+	allowOutOfBand bool,
 ) (core.Salary, error) {
 	var s core.Salary
 
-	err := r.pool.QueryRow(ctx, `
-		SELECT id, user_id, amount, effective_date, created_date, updated_at
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Salary{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		SELECT id, user_id, amount, currency, effective_date, out_of_band, created_at, updated_at
 		FROM salaries 
 		WHERE id = $1
+		FOR UPDATE
 	`, id).Scan(
-		&s.ID, &s.UserID, &s.Amount, &s.EffectiveDate, &s.CreatedAt, &s.UpdatedAt,
+		&s.ID, &s.UserID, &s.Amount, &s.Currency, &s.EffectiveDate, &s.OutOfBand, &s.CreatedAt, &s.UpdatedAt,
 	)
 
 	if err != nil {
@@ -109,19 +145,137 @@ func (r *SalaryRepository) Update(
 		return core.Salary{}, err
 	}
 
-	_, err = r.pool.Exec(ctx, `
+	if update.UserID != "" {
+		s.UserID = update.UserID
+	}
+	if !update.Amount.IsZero() {
+		s.Amount = update.Amount
+	}
+	if update.Currency != "" {
+		s.Currency = update.Currency
+	}
+	if !update.EffectiveDate.IsZero() {
+		s.EffectiveDate = update.EffectiveDate
+	}
+
+	if err := checkSalaryDate(ctx, tx, s.UserID, s.EffectiveDate, id); err != nil {
+		return core.Salary{}, err
+	}
+
+	s.OutOfBand, err = checkSalaryBand(ctx, tx, s, allowOutOfBand)
+	if err != nil {
+		return core.Salary{}, err
+	}
+
+	err = tx.QueryRow(ctx, `
 		UPDATE salaries
-		SET user_id = $1, amount = $2, effective_date = $3, updated_at = NOW()
-		WHERE id = $4
-	`, s.UserID, s.Amount, s.EffectiveDate, id)
+		SET user_id = $1, amount = $2, currency = $3, effective_date = $4, out_of_band = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at
+	`, s.UserID, s.Amount, s.Currency, s.EffectiveDate, s.OutOfBand, id).Scan(&s.UpdatedAt)
 	if err != nil {
 		return core.Salary{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return core.Salary{}, err
+	}
+
 	return s, nil
 }
 
 func (r *SalaryRepository) Delete(ctx context.Context, id string) error {
 	_, err := r.pool.Exec(ctx, `DELETE FROM salaries WHERE id = $1`, id)
 	return err
-}
\ No newline at end of file
+}
+
+// checkSalaryDate rejects a second salary for the same user and effective
+// date. The advisory lock serialises writers per user, since existing data
+// rules out a unique index.
+func checkSalaryDate(
+	ctx context.Context,
+	q querier,
+	userID string,
+	effectiveDate time.Time,
+	excludeID string,
+) error {
+	if _, err := q.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('salaries:' || $1::text))`, userID); err != nil {
+		return err
+	}
+
+	exists, err := userExists(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+
+	var duplicate bool
+	err = q.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM salaries
+			WHERE user_id = $1 AND effective_date = $2::date AND id::text <> $3
+		)
+	`, userID, effectiveDate, excludeID).Scan(&duplicate)
+	if err != nil {
+		return err
+	}
+	if duplicate {
+		return core.ErrDuplicateSalary
+	}
+	return nil
+}
+
+// ListByUser returns all salaries of a user in effective order.
+func (r *SalaryRepository) ListByUser(
+	ctx context.Context,
+	userID string,
+) ([]core.Salary, error) {
+	exists, err := userExists(ctx, r.pool, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, user_id, amount, currency, effective_date, out_of_band, created_at, updated_at
+		FROM salaries
+		WHERE user_id = $1
+		ORDER BY effective_date, created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.Salary])
+}
+
+// GetAsOf resolves the salary in effect on date: the latest one whose
+// effective date is not after it.
+func (r *SalaryRepository) GetAsOf(
+	ctx context.Context,
+	userID string,
+	date time.Time,
+) (core.Salary, error) {
+	var s core.Salary
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, user_id, amount, currency, effective_date, out_of_band, created_at, updated_at
+		FROM salaries
+		WHERE user_id = $1 AND effective_date <= $2::date
+		ORDER BY effective_date DESC, created_at DESC
+		LIMIT 1
+	`, userID, date).Scan(
+		&s.ID, &s.UserID, &s.Amount, &s.Currency, &s.EffectiveDate, &s.OutOfBand, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Salary{}, fmt.Errorf("salary not found")
+		}
+		return core.Salary{}, err
+	}
+	return s, nil
+}
@@ -186,18 +186,9 @@ func (s *Seeder) seedUsers(ctx context.Context, jsonPath string) error {
 
 	for _, u := range users {
 		posi := getRandomPosition()
-		var user core.User
+		u.DepartmentID, u.PositionID, u.ManagerID = posi.DepartmentID, posi.ID, ""
 
-		err = tx.QueryRow(ctx, `
-			INSERT INTO users (email, first_name, last_name, department_id, position_id, hire_date, phone, date_of_birth)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-			ON CONFLICT (email) DO NOTHING
-			RETURNING id, email, first_name, last_name, department_id, position_id, hire_date, phone, date_of_birth
-		`, u.Email, u.FirstName, u.LastName, posi.DepartmentID,
-			posi.ID, u.HireDate, u.Phone, u.DateOfBirth).Scan(
-			&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.DepartmentID,
-			&user.PositionID, &user.HireDate, &user.Phone, &user.DateOfBirth,
-		)
+		user, err := insertUser(ctx, tx, u)
 		if err != nil {
 			return err
 		}
@@ -233,9 +224,9 @@ func (s *Seeder) seedSalaries(ctx context.Context, jsonPath string) error {
 		err = tx.QueryRow(ctx, `
 			INSERT INTO salaries (user_id, amount, effective_date, created_at)
 			VALUES ($1, $2, $3, $4)
-			RETURNING id, user_id, amount, effective_date, created_at
+			RETURNING id, user_id, amount, currency, effective_date, created_at
 		`, user.ID, l.Amount, l.EffectiveDate, l.CreatedAt).Scan(
-			&sal.ID, &sal.UserID, &sal.Amount, &sal.EffectiveDate, &sal.CreatedAt,
+			&sal.ID, &sal.UserID, &sal.Amount, &sal.Currency, &sal.EffectiveDate, &sal.CreatedAt,
 		)
 		if err != nil {
 			return err
@@ -271,11 +262,12 @@ func (s *Seeder) seedAddresses(ctx context.Context, jsonPath string) error {
 		user := getRandomUser()
 		var add core.Address
 		err = tx.QueryRow(ctx, `
-			INSERT INTO addresses (user_id, street, city, zip_code, country, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6)
-			RETURNING id, user_id, street, city, zip_code, country, is_primary, created_at
+			INSERT INTO addresses (user_id, street, city, zip_code, country, created_at, is_primary)
+			SELECT $1, $2, $3, $4, $5, $6,
+				NOT EXISTS (SELECT 1 FROM addresses WHERE user_id = $1 AND is_primary)
+			RETURNING id, user_id, address_type, street, city, zip_code, country, is_primary, created_at
 		`, user.ID, a.Street, a.City, a.ZipCode, a.Country, a.CreatedAt).Scan(
-			&add.ID, &add.UserID, &add.Street, &add.City, &add.ZipCode,
+			&add.ID, &add.UserID, &add.Type, &add.Street, &add.City, &add.ZipCode,
 			&add.Country, &add.IsPrimary, &add.CreatedAt,
 		)
 		if err != nil {
@@ -418,7 +410,27 @@ func (s *Seeder) getAllSkills(ctx context.Context) []core.Skill {
 }
 
 func (s *Seeder) DeleteDevData(ctx context.Context) {
-	_, err := s.pool.Exec(ctx, `DROP TABLE departments CASCADE`)
+	_, err := s.pool.Exec(ctx, `DROP TABLE payroll_run_lines CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting payroll_run_lines")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE exchange_rates CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting exchange_rates")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE payroll_runs CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting payroll_runs")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE salary_bands CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting salary_bands")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE departments CASCADE`)
 	if err != nil {
 		slog.Warn("Seeder | DeleteDevData | error occurred while deleting departments")
 	}
@@ -433,16 +445,61 @@ func (s *Seeder) DeleteDevData(ctx context.Context) {
 		slog.Warn("Seeder | DeleteDevData | error occurred while deleting users")
 	}
 
+	_, err = s.pool.Exec(ctx, `DROP TABLE leave_requests CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting leave_requests")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE leave_entitlements CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting leave_entitlements")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE leave_types CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting leave_types")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE position_assignments CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting position_assignments")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE employment_periods CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting employment_periods")
+	}
+
 	_, err = s.pool.Exec(ctx, `DROP TABLE salaries CASCADE`)
 	if err != nil {
 		slog.Warn("Seeder | DeleteDevData | error occurred while deleting salaries")
 	}
 
+	_, err = s.pool.Exec(ctx, `DROP TABLE user_workplaces CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting user_workplaces")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE offices CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting offices")
+	}
+
 	_, err = s.pool.Exec(ctx, `DROP TABLE addresses CASCADE`)
 	if err != nil {
 		slog.Warn("Seeder | DeleteDevData | error occurred while deleting addresses")
 	}
 
+	_, err = s.pool.Exec(ctx, `DROP TABLE position_skills CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting position_skills")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE skill_aliases CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting skill_aliases")
+	}
+
 	_, err = s.pool.Exec(ctx, `DROP TABLE skills CASCADE`)
 	if err != nil {
 		slog.Warn("Seeder | DeleteDevData | error occurred while deleting skills")
@@ -452,4 +509,14 @@ func (s *Seeder) DeleteDevData(ctx context.Context) {
 	if err != nil {
 		slog.Warn("Seeder | DeleteDevData | error occurred while deleting user_skills")
 	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE skill_categories CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting skill_categories")
+	}
+
+	_, err = s.pool.Exec(ctx, `DROP TABLE audit_log CASCADE`)
+	if err != nil {
+		slog.Warn("Seeder | DeleteDevData | error occurred while deleting audit_log")
+	}
 }
@@ -14,6 +14,8 @@ import (
 	"golang.org/x/exp/slog"
 )
 
+const skillColumns = `id, name, category, COALESCE(category_id::text, ''), created_at, updated_at`
+
 type SkillRepository struct {
 	pool *pgxpool.Pool
 }
@@ -31,7 +33,7 @@ func (r *SkillRepository) List(
 	}
 
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, name, category, created_at, updated_at
+		SELECT `+skillColumns+`
 		FROM skills
 		ORDER BY created_at DESC
 	`)
@@ -49,23 +51,39 @@ func (r *SkillRepository) List(
 	return skills, total, nil
 }
 
+// Create adds a skill unless its name, folded by skill_key, already names
+// a skill or an alias.
 func (r *SkillRepository) Create(
 	ctx context.Context,
 	s core.Skill,
 ) (core.Skill, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Skill{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkSkillName(ctx, tx, s.Name, ""); err != nil {
+		return core.Skill{}, err
+	}
+
 	query := `
-		INSERT INTO skills (name, category)
-		VALUES ($1, $2)
-		RETURNING id, created_at, updated_at
+		INSERT INTO skills (name, category, category_id)
+		VALUES ($1, $2, NULLIF($3, '')::uuid)
+		RETURNING id, category, COALESCE(category_id::text, ''), created_at, updated_at
 	`
 
-	err := r.pool.QueryRow(
-		ctx, query, s.Name, s.Category,
-	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	err = tx.QueryRow(
+		ctx, query, s.Name, s.Category, s.CategoryID,
+	).Scan(&s.ID, &s.Category, &s.CategoryID, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return core.Skill{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return core.Skill{}, err
+	}
+
 	return s, nil
 }
 
@@ -75,13 +93,16 @@ func (r *SkillRepository) Get(
 ) (core.Skill, error) {
 	var s core.Skill
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, name, category, created_at, updated_at
+		SELECT `+skillColumns+`
 		FROM skills 
 		WHERE id = $1
 	`, id).Scan(
-		&s.ID, &s.Name, &s.Category, &s.CreatedAt, &s.UpdatedAt,
+		&s.ID, &s.Name, &s.Category, &s.CategoryID, &s.CreatedAt, &s.UpdatedAt,
 	)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Skill{}, fmt.Errorf("skill not found")
+		}
 		return core.Skill{}, err
 	}
 	return s, nil
@@ -92,7 +113,8 @@ func (r *SkillRepository) GetByUserId(
 	id string,
 ) ([]core.SkillWithDetails, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT s.*, us.proficiency_level, us.acquired_date 
+		SELECT s.id, s.name, s.category, COALESCE(s.category_id::text, ''), s.created_at, s.updated_at,
+			us.proficiency_level, us.acquired_date
 		FROM user_skills us
 		JOIN skills s ON us.skill_id = s.id
 		WHERE us.user_id = $1
@@ -106,7 +128,7 @@ func (r *SkillRepository) GetByUserId(
 	for rows.Next() {
 		var skill core.SkillWithDetails
 		err := rows.Scan(
-			&skill.ID, &skill.Name, &skill.Category,
+			&skill.ID, &skill.Name, &skill.Category, &skill.CategoryID,
 			&skill.CreatedAt, &skill.UpdatedAt,
 			&skill.ProficiencyLevel, &skill.AcquiredDate,
 		)
@@ -119,20 +141,149 @@ func (r *SkillRepository) GetByUserId(
 	return skills, nil
 }
 
+// AddSkillByUserId records a skill for a user, defaulting to level 1
+// acquired today. An existing entry is left untouched.
 func (r *SkillRepository) AddSkillByUserId(
 	ctx context.Context,
 	skill_id string,
 	user_id string,
+	input core.UserSkillUpdate,
 ) error {
+	level := 1
+	if input.ProficiencyLevel != nil {
+		level = *input.ProficiencyLevel
+	}
+	acquired := time.Now()
+	if input.AcquiredDate != nil {
+		acquired = *input.AcquiredDate
+	}
+
 	query := `
 		INSERT INTO user_skills (user_id, skill_id, proficiency_level, acquired_date)
 		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (user_id, skill_id) DO NOTHING
 	`
-	_, err := r.pool.Exec(ctx, query, user_id, skill_id, 1, time.Now())
+	_, err := r.pool.Exec(ctx, query, user_id, skill_id, level, acquired)
 	return err
 }
 
+func (r *SkillRepository) UpdateUserSkill(
+	ctx context.Context,
+	skillID, userID string,
+	update core.UserSkillUpdate,
+) (core.SkillWithDetails, error) {
+	var skill core.SkillWithDetails
+	err := r.pool.QueryRow(ctx, `
+		WITH updated AS (
+			UPDATE user_skills
+			SET proficiency_level = COALESCE($3, proficiency_level),
+				acquired_date = COALESCE($4::date, acquired_date)
+			WHERE user_id = $1 AND skill_id = $2
+			RETURNING skill_id, proficiency_level, acquired_date
+		)
+		SELECT s.id, s.name, s.category, COALESCE(s.category_id::text, ''), s.created_at, s.updated_at,
+			u.proficiency_level, u.acquired_date
+		FROM updated u
+		JOIN skills s ON s.id = u.skill_id
+	`, userID, skillID, update.ProficiencyLevel, update.AcquiredDate).Scan(
+		&skill.ID, &skill.Name, &skill.Category, &skill.CategoryID, &skill.CreatedAt, &skill.UpdatedAt,
+		&skill.ProficiencyLevel, &skill.AcquiredDate,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.SkillWithDetails{}, fmt.Errorf("user skill not found")
+		}
+		return core.SkillWithDetails{}, err
+	}
+	return skill, nil
+}
+
+// ResolveSkillNames maps each requested name to a skill, matching skill
+// names and aliases by skill_key. Unknown names are missing from the result.
+func (r *SkillRepository) ResolveSkillNames(
+	ctx context.Context,
+	names []string,
+) (map[string]core.Skill, error) {
+	return resolveSkillNames(ctx, r.pool, names)
+}
+
+func resolveSkillNames(ctx context.Context, q querier, names []string) (map[string]core.Skill, error) {
+	rows, err := q.Query(ctx, `
+		SELECT q.name, s.id, s.name, s.category, COALESCE(s.category_id::text, ''), s.created_at, s.updated_at
+		FROM UNNEST($1::text[]) AS q(name)
+		JOIN LATERAL (
+			SELECT 0 AS rank, sk.*
+			FROM skills sk
+			WHERE skill_key(sk.name) = skill_key(q.name)
+			UNION ALL
+			SELECT 1 AS rank, sk.*
+			FROM skill_aliases a
+			JOIN skills sk ON sk.id = a.skill_id
+			WHERE a.alias_key = skill_key(q.name)
+			ORDER BY rank
+			LIMIT 1
+		) s ON true
+	`, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resolved := make(map[string]core.Skill, len(names))
+	for rows.Next() {
+		var name string
+		var s core.Skill
+		if err := rows.Scan(&name, &s.ID, &s.Name, &s.Category, &s.CategoryID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		resolved[name] = s
+	}
+	return resolved, rows.Err()
+}
+
+// SkillSearchCandidates returns current employees, optionally limited to
+// some departments, with their levels in the given skills. With
+// holdersOnly only employees holding at least one of the skills are
+// returned; without it everyone is, so queries made of NOT terms can match.
+func (r *SkillRepository) SkillSearchCandidates(
+	ctx context.Context,
+	skillIDs, departmentIDs []string,
+	holdersOnly bool,
+) ([]core.SkillSearchResult, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT u.id, u.email, u.first_name, u.last_name,
+			COALESCE(d.id::text, ''), COALESCE(d.name, ''),
+			COALESCE(JSON_AGG(JSON_BUILD_OBJECT(
+				'skill_id', s.id::text,
+				'name', s.name,
+				'proficiency_level', us.proficiency_level
+			) ORDER BY s.name) FILTER (WHERE s.id IS NOT NULL), '[]'::json)
+		FROM users u
+		LEFT JOIN departments d ON d.id = u.department_id
+		LEFT JOIN user_skills us ON us.user_id = u.id AND us.skill_id::text = ANY($1)
+		LEFT JOIN skills s ON s.id = us.skill_id
+		WHERE (COALESCE(cardinality($2::text[]), 0) = 0 OR u.department_id::text = ANY($2))
+			AND (NOT $3::boolean OR EXISTS (
+				SELECT 1 FROM user_skills h
+				WHERE h.user_id = u.id AND h.skill_id::text = ANY($1)
+			))
+			AND `+currentStaff+`
+		GROUP BY u.id, d.id
+	`, skillIDs, departmentIDs, holdersOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (core.SkillSearchResult, error) {
+		var res core.SkillSearchResult
+		err := row.Scan(
+			&res.UserID, &res.Email, &res.FirstName, &res.LastName,
+			&res.DepartmentID, &res.DepartmentName, &res.Skills,
+		)
+		return res, err
+	})
+}
+
 func (r *SkillRepository) Update(
 	ctx context.Context,
 	id string,
@@ -140,30 +291,55 @@ func (r *SkillRepository) Update(
 ) (core.Skill, error) {
 	var s core.Skill
 
-	err := r.pool.QueryRow(ctx, `
-		SELECT id, name, category, created_at, updated_at
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.Skill{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		SELECT `+skillColumns+`
 		FROM skills 
 		WHERE id = $1
+		FOR UPDATE
 	`, id).Scan(
-		&s.ID, &s.Name, &s.Category, &s.CreatedAt, &s.UpdatedAt,
+		&s.ID, &s.Name, &s.Category, &s.CategoryID, &s.CreatedAt, &s.UpdatedAt,
 	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return core.Skill{}, fmt.Errorf("user not found")
+			return core.Skill{}, fmt.Errorf("skill not found")
 		}
 		return core.Skill{}, err
 	}
 
-	_, err = r.pool.Exec(ctx, `
+	if update.Name != nil {
+		if err := checkSkillName(ctx, tx, *update.Name, id); err != nil {
+			return core.Skill{}, err
+		}
+		s.Name = *update.Name
+	}
+	if update.Category != nil {
+		s.Category = *update.Category
+	}
+	if update.CategoryID != nil {
+		s.CategoryID = *update.CategoryID
+	}
+
+	err = tx.QueryRow(ctx, `
 		UPDATE skills
-		SET name = $1, category = $2, updated_at = NOW()
-		WHERE id = $9
-	`, s.Name, s.Category, id)
+		SET name = $1, category = $2, category_id = NULLIF($3, '')::uuid, updated_at = NOW()
+		WHERE id = $4
+		RETURNING category, COALESCE(category_id::text, ''), updated_at
+	`, s.Name, s.Category, s.CategoryID, id).Scan(&s.Category, &s.CategoryID, &s.UpdatedAt)
 	if err != nil {
 		return core.Skill{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return core.Skill{}, err
+	}
+
 	return s, nil
 }
 
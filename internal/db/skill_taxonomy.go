@@ -0,0 +1,428 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// lockSkillNames serialises writes that create skill names or aliases, so
+// two requests cannot both pass checkSkillName with the same spelling.
+func lockSkillNames(ctx context.Context, q querier) error {
+	_, err := q.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('skills.name'))`)
+	return err
+}
+
+// checkSkillName rejects name when it folds to the same skill_key as
+// another skill or one of its aliases.
+func checkSkillName(ctx context.Context, q querier, name, excludeID string) error {
+	if err := lockSkillNames(ctx, q); err != nil {
+		return err
+	}
+
+	var existing string
+	err := q.QueryRow(ctx, `
+		SELECT s.name
+		FROM skills s
+		WHERE skill_key(s.name) = skill_key($1) AND s.id::text <> $2
+		UNION ALL
+		SELECT s.name
+		FROM skill_aliases a
+		JOIN skills s ON s.id = a.skill_id
+		WHERE a.alias_key = skill_key($1) AND s.id::text <> $2
+		LIMIT 1
+	`, name, excludeID).Scan(&existing)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	return fmt.Errorf("%w: %q matches %q", core.ErrSkillExists, name, existing)
+}
+
+func (r *SkillRepository) ListAliases(
+	ctx context.Context,
+	skillID string,
+) ([]core.SkillAlias, error) {
+	if _, err := r.Get(ctx, skillID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT alias, skill_id, created_at
+		FROM skill_aliases
+		WHERE skill_id = $1
+		ORDER BY alias
+	`, skillID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[core.SkillAlias])
+}
+
+func (r *SkillRepository) AddAlias(
+	ctx context.Context,
+	skillID, alias string,
+) (core.SkillAlias, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.SkillAlias{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM skills WHERE id = $1)`, skillID).Scan(&exists); err != nil {
+		return core.SkillAlias{}, err
+	}
+	if !exists {
+		return core.SkillAlias{}, fmt.Errorf("skill not found")
+	}
+
+	if err := checkSkillName(ctx, tx, alias, ""); err != nil {
+		return core.SkillAlias{}, err
+	}
+
+	a := core.SkillAlias{Alias: alias, SkillID: skillID}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO skill_aliases (alias_key, alias, skill_id)
+		VALUES (skill_key($1), $1, $2)
+		RETURNING created_at
+	`, alias, skillID).Scan(&a.CreatedAt)
+	if err != nil {
+		return core.SkillAlias{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.SkillAlias{}, err
+	}
+	return a, nil
+}
+
+func (r *SkillRepository) DeleteAlias(
+	ctx context.Context,
+	skillID, alias string,
+) error {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM skill_aliases WHERE skill_id = $1 AND alias_key = skill_key($2)
+	`, skillID, alias)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("skill alias not found")
+	}
+	return nil
+}
+
+// Merge folds the source skills into targetID in one transaction. Users
+// holding several of them keep the highest proficiency and the earliest
+// acquired date, positions asking for several keep the strictest
+// requirement, and the source names live on as aliases of the target.
+func (r *SkillRepository) Merge(
+	ctx context.Context,
+	targetID string,
+	sourceIDs []string,
+) (core.SkillMergeResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSkillNames(ctx, tx); err != nil {
+		return core.SkillMergeResult{}, err
+	}
+
+	var result core.SkillMergeResult
+	target := &result.Skill
+	err = tx.QueryRow(ctx, `
+		SELECT `+skillColumns+`
+		FROM skills
+		WHERE id = $1
+		FOR UPDATE
+	`, targetID).Scan(
+		&target.ID, &target.Name, &target.Category, &target.CategoryID, &target.CreatedAt, &target.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.SkillMergeResult{}, fmt.Errorf("skill not found")
+		}
+		return core.SkillMergeResult{}, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT name
+		FROM skills
+		WHERE id::text = ANY($1)
+		ORDER BY name
+		FOR UPDATE
+	`, sourceIDs)
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+	result.MergedNames, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+	if len(result.MergedNames) != len(sourceIDs) {
+		return core.SkillMergeResult{}, fmt.Errorf("source skill not found")
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_skills (user_id, skill_id, proficiency_level, acquired_date)
+		SELECT user_id, $1, MAX(proficiency_level), MIN(acquired_date)
+		FROM user_skills
+		WHERE skill_id::text = ANY($2)
+		GROUP BY user_id
+		ON CONFLICT (user_id, skill_id) DO UPDATE
+		SET proficiency_level = GREATEST(user_skills.proficiency_level, EXCLUDED.proficiency_level),
+			acquired_date = LEAST(user_skills.acquired_date, EXCLUDED.acquired_date)
+	`, targetID, sourceIDs)
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM user_skills WHERE skill_id::text = ANY($1)`, sourceIDs)
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+	result.UserSkillsMoved = tag.RowsAffected()
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO position_skills (position_id, skill_id, min_proficiency, required)
+		SELECT position_id, $1, MAX(min_proficiency), BOOL_OR(required)
+		FROM position_skills
+		WHERE skill_id::text = ANY($2)
+		GROUP BY position_id
+		ON CONFLICT (position_id, skill_id) DO UPDATE
+		SET min_proficiency = GREATEST(position_skills.min_proficiency, EXCLUDED.min_proficiency),
+			required = position_skills.required OR EXCLUDED.required,
+			updated_at = NOW()
+	`, targetID, sourceIDs)
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM position_skills WHERE skill_id::text = ANY($1)`, sourceIDs); err != nil {
+		return core.SkillMergeResult{}, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE skill_aliases SET skill_id = $1 WHERE skill_id::text = ANY($2)`, targetID, sourceIDs)
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO skill_aliases (alias_key, alias, skill_id)
+		SELECT DISTINCT ON (skill_key(name)) skill_key(name), name, $1
+		FROM skills
+		WHERE id::text = ANY($2) AND skill_key(name) <> skill_key($3)
+		ORDER BY skill_key(name), name
+		ON CONFLICT (alias_key) DO UPDATE SET skill_id = EXCLUDED.skill_id
+	`, targetID, sourceIDs, target.Name)
+	if err != nil {
+		return core.SkillMergeResult{}, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM skills WHERE id::text = ANY($1)`, sourceIDs); err != nil {
+		return core.SkillMergeResult{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.SkillMergeResult{}, err
+	}
+	return result, nil
+}
+
+func (r *SkillRepository) ListCategories(ctx context.Context) ([]core.SkillCategory, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, name, COALESCE(parent_id::text, ''), created_at, updated_at
+		FROM skill_categories
+		ORDER BY LOWER(name)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (core.SkillCategory, error) {
+		var c core.SkillCategory
+		err := row.Scan(&c.ID, &c.Name, &c.ParentID, &c.CreatedAt, &c.UpdatedAt)
+		return c, err
+	})
+}
+
+func (r *SkillRepository) CreateCategory(
+	ctx context.Context,
+	in core.SkillCategoryCreate,
+) (core.SkillCategory, error) {
+	if in.ParentID != "" {
+		if err := requireSkillCategory(ctx, r.pool, in.ParentID); err != nil {
+			return core.SkillCategory{}, err
+		}
+	}
+
+	c := core.SkillCategory{Name: in.Name, ParentID: in.ParentID}
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO skill_categories (name, parent_id)
+		VALUES ($1, NULLIF($2, '')::uuid)
+		RETURNING id, created_at, updated_at
+	`, in.Name, in.ParentID).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return core.SkillCategory{}, fmt.Errorf("category already exists")
+		}
+		return core.SkillCategory{}, err
+	}
+	return c, nil
+}
+
+// UpdateCategory renames or moves a category. Skills filed under it get the
+// new name in skills.category.
+func (r *SkillRepository) UpdateCategory(
+	ctx context.Context,
+	id string,
+	update core.SkillCategoryUpdate,
+) (core.SkillCategory, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.SkillCategory{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('skill_categories.parent_id'))`); err != nil {
+		return core.SkillCategory{}, err
+	}
+
+	var c core.SkillCategory
+	err = tx.QueryRow(ctx, `
+		SELECT id, name, COALESCE(parent_id::text, ''), created_at, updated_at
+		FROM skill_categories
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&c.ID, &c.Name, &c.ParentID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.SkillCategory{}, fmt.Errorf("category not found")
+		}
+		return core.SkillCategory{}, err
+	}
+
+	if update.Name != nil {
+		c.Name = *update.Name
+	}
+	if update.ParentID != nil && *update.ParentID != c.ParentID {
+		if parent := *update.ParentID; parent != "" {
+			if err := requireSkillCategory(ctx, tx, parent); err != nil {
+				return core.SkillCategory{}, err
+			}
+			var cycle bool
+			err := tx.QueryRow(ctx, `
+				WITH RECURSIVE up AS (
+					SELECT id, parent_id FROM skill_categories WHERE id = $2
+					UNION
+					SELECT c.id, c.parent_id
+					FROM skill_categories c
+					JOIN up ON c.id = up.parent_id
+				)
+				SELECT EXISTS (SELECT 1 FROM up WHERE id = $1)
+			`, id, parent).Scan(&cycle)
+			if err != nil {
+				return core.SkillCategory{}, err
+			}
+			if cycle {
+				return core.SkillCategory{}, core.ErrCategoryCycle
+			}
+		}
+		c.ParentID = *update.ParentID
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE skill_categories
+		SET name = $1, parent_id = NULLIF($2, '')::uuid, updated_at = NOW()
+		WHERE id = $3
+		RETURNING updated_at
+	`, c.Name, c.ParentID, id).Scan(&c.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return core.SkillCategory{}, fmt.Errorf("category already exists")
+		}
+		return core.SkillCategory{}, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE skills SET category = $1, updated_at = NOW()
+		WHERE category_id = $2 AND category <> $1
+	`, c.Name, id)
+	if err != nil {
+		return core.SkillCategory{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.SkillCategory{}, err
+	}
+	return c, nil
+}
+
+// DeleteCategory removes a leaf category. Its skills become uncategorised;
+// their category text is cleared too, otherwise the sync trigger would file
+// them under a new category of the same name.
+func (r *SkillRepository) DeleteCategory(ctx context.Context, id string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := requireSkillCategory(ctx, tx, id); err != nil {
+		return err
+	}
+
+	var children bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM skill_categories WHERE parent_id = $1)`, id).Scan(&children)
+	if err != nil {
+		return err
+	}
+	if children {
+		return core.ErrCategoryInUse
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE skills SET category = '', category_id = NULL, updated_at = NOW()
+		WHERE category_id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM skill_categories WHERE id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func requireSkillCategory(ctx context.Context, q querier, id string) error {
+	var exists bool
+	err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM skill_categories WHERE id::text = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("category not found")
+	}
+	return nil
+}
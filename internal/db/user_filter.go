@@ -0,0 +1,147 @@
+package db
+
+import (
+	"slices"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+)
+
+// userListFrom joins everything userListFilter and userSortColumns refer
+// to: users as u, departments as d, positions as p, the primary address as
+// a and the current salary as cs, looked up only if the boolean parameter
+// salaryParam is true.
+func userListFrom(salaryParam int) string {
+	return `
+		FROM users u
+		LEFT JOIN departments d ON u.department_id = d.id
+		LEFT JOIN positions p ON u.position_id = p.id
+		LEFT JOIN LATERAL (
+			SELECT *
+			FROM addresses a
+			WHERE a.user_id = u.id AND a.is_primary
+		) a ON true
+		` + currentSalaryJoin(salaryParam)
+}
+
+// userListFilter turns filter into the conditions shared by List and
+// Export.
+func userListFilter(filter core.UserFilter) *sqlFilter {
+	var f sqlFilter
+
+	switch filter.Status {
+	case "":
+		f.where(currentStaff)
+	case core.EmploymentAll:
+	default:
+		f.where("u.employment_status = ?", filter.Status)
+	}
+
+	// Every word has to turn up somewhere; phone numbers also match
+	// without their formatting.
+	for _, word := range strings.Fields(filter.Search) {
+		f.where(`EXISTS (
+				SELECT 1
+				FROM unnest(ARRAY[u.first_name, u.last_name, u.email, u.phone,
+					regexp_replace(u.phone, '\D', '', 'g')]) AS v(value)
+				WHERE v.value ILIKE ?
+			)`, likePattern(word))
+	}
+
+	if filter.Department != "" {
+		f.where("d.name ILIKE ?", likePattern(filter.Department))
+	}
+	if filter.Position != "" {
+		f.where("p.title ILIKE ?", likePattern(filter.Position))
+	}
+	if filter.MinLevel != nil {
+		f.where("p.level >= ?", *filter.MinLevel)
+	}
+	if filter.MaxLevel != nil {
+		f.where("p.level <= ?", *filter.MaxLevel)
+	}
+	if filter.HiredFrom != nil {
+		f.where("u.hire_date >= ?::date", *filter.HiredFrom)
+	}
+	if filter.HiredTo != nil {
+		f.where("u.hire_date <= ?::date", *filter.HiredTo)
+	}
+
+	// Someone is N until the day before their N+1th birthday.
+	if filter.MinAge != nil {
+		f.where("u.date_of_birth <= CURRENT_DATE - make_interval(years => ?::int)", *filter.MinAge)
+	}
+	if filter.MaxAge != nil {
+		f.where("u.date_of_birth > CURRENT_DATE - make_interval(years => ?::int + 1)", *filter.MaxAge)
+	}
+
+	if filter.City != "" {
+		f.where("a.city ILIKE ?", likeEscaper.Replace(filter.City))
+	}
+	if filter.Country != "" {
+		f.where("a.country = ?", filter.Country)
+	}
+
+	if filter.Skill != "" {
+		f.where(`EXISTS (
+				SELECT 1
+				FROM user_skills us
+				JOIN skills sk ON sk.id = us.skill_id
+				LEFT JOIN skill_aliases sa ON sa.skill_id = sk.id
+				WHERE us.user_id = u.id
+					AND skill_key(?) IN (skill_key(sk.name), sa.alias_key)
+					AND us.proficiency_level >= ?
+			)`, filter.Skill, max(filter.MinProficiency, 1))
+	}
+
+	// Without a rate for either currency the salary cannot be compared and
+	// the employee does not match.
+	if filter.MinSalary != nil {
+		f.where(`cs.amount * exchange_rate_on(?, CURRENT_DATE) / exchange_rate_on(cs.currency, CURRENT_DATE) >= ?::numeric`,
+			filter.SalaryCurrency, *filter.MinSalary)
+	}
+	if filter.MaxSalary != nil {
+		f.where(`cs.amount * exchange_rate_on(?, CURRENT_DATE) / exchange_rate_on(cs.currency, CURRENT_DATE) <= ?::numeric`,
+			filter.SalaryCurrency, *filter.MaxSalary)
+	}
+
+	return &f
+}
+
+// userFilterNeedsSalary tells whether filter filters or sorts by the
+// current salary.
+func userFilterNeedsSalary(filter core.UserFilter) bool {
+	return filter.MinSalary != nil || filter.MaxSalary != nil ||
+		slices.ContainsFunc(filter.Sort, func(s core.SortField) bool { return s.Field == "salary" })
+}
+
+// userSortColumns maps core.UserSortFields to their expressions. Salaries
+// are compared in the pivot currency.
+var userSortColumns = map[string]string{
+	"id":                "u.id",
+	"email":             "u.email",
+	"first_name":        "u.first_name",
+	"last_name":         "u.last_name",
+	"phone":             "u.phone",
+	"date_of_birth":     "u.date_of_birth",
+	"age":               "age(u.date_of_birth)",
+	"hire_date":         "u.hire_date",
+	"employment_status": "u.employment_status",
+	"termination_date":  "u.termination_date",
+	"created_at":        "u.created_at",
+	"updated_at":        "u.updated_at",
+	"department":        "d.name",
+	"position":          "p.title",
+	"level":             "p.level",
+	"city":              "a.city",
+	"country":           "a.country",
+	"salary":            "cs.amount / exchange_rate_on(cs.currency, CURRENT_DATE)",
+}
+
+// userListOrder orders by sort, or by fallback when sort is empty.
+func userListOrder(sort []core.SortField, fallback string) (string, error) {
+	if len(sort) == 0 {
+		return "ORDER BY " + fallback + ", u.id", nil
+	}
+	return sqlOrder(userSortColumns, sort, "u.id")
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// importRefs resolves the names used in an import file.
+type importRefs struct {
+	departments map[string]string
+	positions   map[string]core.Position
+	skills      map[string]core.Skill
+}
+
+// Import writes validated rows in a single transaction. Every row runs in
+// its own savepoint, so a row the database rejects is reported without
+// hiding problems in the rows after it. The transaction is committed only
+// when commit is set and every row went in; dry runs never commit.
+func (r *UserRepository) Import(
+	ctx context.Context,
+	rows []core.UserImport,
+	commit bool,
+) ([]core.UserImportResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	refs, err := loadImportRefs(ctx, tx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]core.UserImportResult, len(rows))
+	failed := false
+	for i, row := range rows {
+		results[i] = core.UserImportResult{Line: row.Line, Email: row.User.Email, Status: core.ImportValid}
+
+		id, err := importRow(ctx, tx, row, refs)
+		var verr *core.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			failed = true
+			results[i].Status = core.ImportInvalid
+			results[i].Errors = verr.Fields
+		case err != nil:
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		default:
+			results[i].UserID = id
+		}
+	}
+
+	if !commit || failed {
+		for i := range results {
+			results[i].UserID = ""
+		}
+		return results, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Status = core.ImportCreated
+	}
+	return results, nil
+}
+
+func loadImportRefs(ctx context.Context, q querier, rows []core.UserImport) (importRefs, error) {
+	refs := importRefs{
+		departments: map[string]string{},
+		positions:   map[string]core.Position{},
+	}
+
+	deps, err := q.Query(ctx, `SELECT id, LOWER(name) FROM departments`)
+	if err != nil {
+		return importRefs{}, err
+	}
+	for deps.Next() {
+		var id, name string
+		if err := deps.Scan(&id, &name); err != nil {
+			deps.Close()
+			return importRefs{}, err
+		}
+		refs.departments[name] = id
+	}
+	deps.Close()
+	if err := deps.Err(); err != nil {
+		return importRefs{}, err
+	}
+
+	pos, err := q.Query(ctx, `SELECT id, title, level, COALESCE(department_id::text, '') FROM positions`)
+	if err != nil {
+		return importRefs{}, err
+	}
+	for pos.Next() {
+		var p core.Position
+		if err := pos.Scan(&p.ID, &p.Title, &p.Level, &p.DepartmentID); err != nil {
+			pos.Close()
+			return importRefs{}, err
+		}
+		refs.positions[strings.ToLower(p.Title)] = p
+	}
+	pos.Close()
+	if err := pos.Err(); err != nil {
+		return importRefs{}, err
+	}
+
+	var names []string
+	for _, row := range rows {
+		for _, s := range row.Skills {
+			names = append(names, s.Name)
+		}
+	}
+	refs.skills, err = resolveSkillNames(ctx, q, names)
+	if err != nil {
+		return importRefs{}, err
+	}
+	return refs, nil
+}
+
+// importRow writes one employee with their address, salary and skills and
+// returns the new user ID. Problems with the row come back as a
+// *core.ValidationError.
+func importRow(ctx context.Context, tx pgx.Tx, row core.UserImport, refs importRefs) (string, error) {
+	verr := &core.ValidationError{}
+	u := row.User
+
+	id, ok := refs.departments[strings.ToLower(row.Department)]
+	if !ok {
+		verr.Add("department", fmt.Sprintf("%q does not match any department", row.Department))
+	}
+	u.DepartmentID = id
+	p, ok := refs.positions[strings.ToLower(row.Position)]
+	if !ok {
+		verr.Add("position", fmt.Sprintf("%q does not match any position", row.Position))
+	}
+	u.PositionID = p.ID
+
+	skillIDs := make([]string, 0, len(row.Skills))
+	seen := map[string]bool{}
+	for _, s := range row.Skills {
+		skill, ok := refs.skills[s.Name]
+		switch {
+		case !ok:
+			verr.Add("skills", fmt.Sprintf("%q does not match any skill or alias", s.Name))
+		case seen[skill.ID]:
+			verr.Add("skills", fmt.Sprintf("%q lists %s more than once", s.Name, skill.Name))
+		}
+		seen[skill.ID] = true
+		skillIDs = append(skillIDs, skill.ID)
+	}
+
+	var taken bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = LOWER($1))`, u.Email).Scan(&taken)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		verr.Add("email", "already belongs to an employee")
+	}
+	if err := verr.Err(); err != nil {
+		return "", err
+	}
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer sp.Rollback(ctx)
+
+	if u, err = insertUser(ctx, sp, u); err != nil {
+		if isUniqueViolation(err) {
+			verr.Add("email", "already belongs to an employee")
+			return "", verr
+		}
+		return "", err
+	}
+
+	if row.Address != nil {
+		a := *row.Address
+		a.UserID, a.IsPrimary = u.ID, true
+		if _, err := insertAddress(ctx, sp, a); err != nil {
+			return "", err
+		}
+	}
+
+	if row.Salary != nil {
+		s := *row.Salary
+		s.UserID = u.ID
+		s.OutOfBand, err = checkSalaryBand(ctx, sp, s, false)
+		switch {
+		case errors.Is(err, core.ErrSalaryOutOfBand), errors.Is(err, core.ErrNoExchangeRate):
+			verr.Add("salary", err.Error())
+			return "", verr
+		case err != nil:
+			return "", err
+		}
+		if _, err := insertSalary(ctx, sp, s); err != nil {
+			return "", err
+		}
+	}
+
+	for i, s := range row.Skills {
+		_, err := sp.Exec(ctx, `
+			INSERT INTO user_skills (user_id, skill_id, proficiency_level)
+			VALUES ($1, $2, $3)
+		`, u.ID, skillIDs[i], s.Level)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := linkVerifiedForumUser(ctx, sp, u.ID, u.Email); err != nil {
+		return "", err
+	}
+	if err := syncDepartmentChannelsForEmployee(ctx, sp, u.ID); err != nil {
+		return "", err
+	}
+
+	if err := sp.Commit(ctx); err != nil {
+		return "", err
+	}
+	return u.ID, nil
+}
@@ -7,11 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"multi-processing-backend/internal/core"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
 	"golang.org/x/exp/slog"
 )
 
@@ -26,39 +28,25 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 func (r *UserRepository) List(
 	ctx context.Context,
 	page, limit int,
-	searchName, departmentName string,
+	filter core.UserFilter,
+	includes core.UserIncludes,
 ) ([]core.UserWithDetails, int64, error) {
 	offset := (page - 1) * limit
 
-	whereClause := "WHERE 1=1"
-	params := []interface{}{}
-	paramCount := 0
-
-	if searchName != "" {
-		paramCount++
-		whereClause += fmt.Sprintf(" AND (u.first_name ILIKE $%d OR u.last_name ILIKE $%d)", paramCount, paramCount)
-		params = append(params, "%"+searchName+"%")
-	}
-
-	if departmentName != "" {
-		paramCount++
-		whereClause += fmt.Sprintf(" AND d.name ILIKE $%d", paramCount)
-		params = append(params, "%"+departmentName+"%")
+	f := userListFilter(filter)
+	from := userListFrom(f.bind(includes.Salary || userFilterNeedsSalary(filter)))
+	orderBy, err := userListOrder(filter.Sort, "u.created_at DESC")
+	if err != nil {
+		return nil, 0, err
 	}
-
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*)
-		FROM users u
-		LEFT JOIN departments d ON u.department_id = d.id
-		%s
-	`, whereClause)
+	countParams := f.args
 
 	var total int64
-	if err := r.pool.QueryRow(ctx, countQuery, params...).Scan(&total); err != nil {
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) `+from+` `+f.clause(), countParams...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
-	query := fmt.Sprintf(`
+	query := `
 		SELECT
 			u.id,
 			u.email,
@@ -66,11 +54,15 @@ func (r *UserRepository) List(
 			u.last_name,
 			u.department_id,
 			u.position_id,
+			COALESCE(u.manager_id::text, '') AS manager_id,
 			u.hire_date,
 			u.phone,
 			u.date_of_birth,
 			u.created_at,
 			u.updated_at,
+			u.employment_status,
+			u.termination_date,
+			COALESCE(u.termination_reason, ''),
 
 			COALESCE(d.id::text, '00000000-0000-0000-0000-000000000000') AS dept_id,
 			COALESCE(d.name, '') AS dept_name,
@@ -87,6 +79,7 @@ func (r *UserRepository) List(
 
 			COALESCE(a.id::text, '') AS a_id,
 			COALESCE(a.user_id::text, '') AS a_user_id,
+			COALESCE(a.address_type, '') AS a_type,
 			COALESCE(a.street, '') AS a_street,
 			COALESCE(a.city, '') AS a_city,
 			COALESCE(a.zip_code, '') AS a_zip_code,
@@ -108,27 +101,21 @@ func (r *UserRepository) List(
 				FROM user_skills us
 				JOIN skills s ON us.skill_id = s.id
 				WHERE us.user_id = u.id
-			), '[]'::json) AS skills
-
-		FROM users u
-		LEFT JOIN departments d ON u.department_id = d.id
-		LEFT JOIN positions p ON u.position_id = p.id
-		LEFT JOIN LATERAL (
-			SELECT *
-			FROM addresses a
-			WHERE a.user_id = u.id
-			LIMIT 1
-		) a ON true
-
-		%s
-
-		ORDER BY u.created_at DESC
-		LIMIT $%d OFFSET $%d;
-    `, whereClause, paramCount+1, paramCount+2)
-
-	params = append(params, limit, offset)
-
-	rows, err := r.pool.Query(ctx, query, params...)
+			), '[]'::json) AS skills,
+
+			COALESCE(cs.id::text, '') AS cs_id,
+			cs.amount AS cs_amount,
+			cs.currency AS cs_currency,
+			cs.effective_date AS cs_effective_date,
+			COALESCE(cs.out_of_band, false) AS cs_out_of_band,
+			cs.created_at AS cs_created_at,
+			cs.updated_at AS cs_updated_at
+		` + from + `
+		` + f.clause() + `
+		` + orderBy + `
+		LIMIT ` + f.placeholder(limit) + ` OFFSET ` + f.placeholder(offset)
+
+	rows, err := r.pool.Query(ctx, query, f.args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -139,9 +126,10 @@ func (r *UserRepository) List(
 		var user core.UserWithDetails
 		var skillsJSON []byte
 
-		var addID, addUserID, addStreet, addCity, addZipCode, addCountry sql.NullString
+		var addID, addUserID, addType, addStreet, addCity, addZipCode, addCountry sql.NullString
 		var addIsPrimary sql.NullBool
 		var addCreatedAt, addUpdatedAt sql.NullTime
+		var current currentSalaryColumns
 
 		user.Departments = &core.Departments{}
 		user.Position = &core.Position{}
@@ -149,8 +137,9 @@ func (r *UserRepository) List(
 
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.FirstName, &user.LastName,
-			&user.DepartmentID, &user.PositionID, &user.HireDate,
+			&user.DepartmentID, &user.PositionID, &user.ManagerID, &user.HireDate,
 			&user.Phone, &user.DateOfBirth, &user.CreatedAt, &user.UpdatedAt,
+			&user.EmploymentStatus, &user.TerminationDate, &user.TerminationReason,
 
 			&user.Departments.ID, &user.Departments.Name, &user.Departments.Description,
 			&user.Departments.CreatedAt, &user.Departments.UpdatedAt,
@@ -158,10 +147,12 @@ func (r *UserRepository) List(
 			&user.Position.ID, &user.Position.Title, &user.Position.Level, &user.Position.DepartmentID,
 			&user.Position.CreatedAt, &user.Position.UpdatedAt,
 
-			&addID, &addUserID, &addStreet, &addCity, &addZipCode,
+			&addID, &addUserID, &addType, &addStreet, &addCity, &addZipCode,
 			&addCountry, &addIsPrimary, &addCreatedAt, &addUpdatedAt,
 
 			&skillsJSON,
+
+			&current.id, &current.amount, &current.currency, &current.effectiveDate, &current.outOfBand, &current.createdAt, &current.updatedAt,
 		)
 		if err != nil {
 			slog.Warn("ListWithDetails | Error occurred within Scan()", "error", err.Error())
@@ -171,6 +162,7 @@ func (r *UserRepository) List(
 		if addID.Valid {
 			user.Address.ID = addID.String
 			user.Address.UserID = addUserID.String
+			user.Address.Type = addType.String
 			user.Address.Street = addStreet.String
 			user.Address.City = addCity.String
 			user.Address.ZipCode = addZipCode.String
@@ -191,6 +183,8 @@ func (r *UserRepository) List(
 			user.Skill = []core.Skill{}
 		}
 
+		user.CurrentSalary = current.salary(user.ID)
+
 		users = append(users, user)
 	}
 	return users, total, nil
@@ -200,30 +194,61 @@ func (r *UserRepository) Create(
 	ctx context.Context,
 	u core.User,
 ) (core.User, error) {
-	query := `
-		INSERT INTO users (email, first_name, last_name, department_id, position_id, hire_date, phone, date_of_birth)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, created_at, updated_at
-	`
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	u, err = insertUser(ctx, tx, u)
+	if err != nil {
+		return core.User{}, err
+	}
+	if err := linkVerifiedForumUser(ctx, tx, u.ID, u.Email); err != nil {
+		return core.User{}, err
+	}
+	if err := syncDepartmentChannelsForEmployee(ctx, tx, u.ID); err != nil {
+		return core.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.User{}, err
+	}
+
+	return u, nil
+}
 
-	err := r.pool.QueryRow(ctx, query, u.Email, u.FirstName, u.LastName, u.DepartmentID, u.PositionID, u.HireDate, u.Phone, u.DateOfBirth).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+// insertUser stores a new employee and opens their position history with
+// the hire.
+func insertUser(ctx context.Context, q querier, u core.User) (core.User, error) {
+	err := q.QueryRow(ctx, `
+		INSERT INTO users (email, first_name, last_name, department_id, position_id, hire_date, phone, date_of_birth, manager_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::uuid)
+		RETURNING id, employment_status, created_at, updated_at
+	`, u.Email, u.FirstName, u.LastName, u.DepartmentID, u.PositionID, u.HireDate, u.Phone, u.DateOfBirth, u.ManagerID,
+	).Scan(&u.ID, &u.EmploymentStatus, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		return core.User{}, err
 	}
 
+	if _, err := syncPositionAssignment(ctx, q, u.ID, u.HireDate, core.AssignmentHire, "", ""); err != nil {
+		return core.User{}, err
+	}
 	return u, nil
 }
 
 func (r *UserRepository) Get(
 	ctx context.Context,
 	id string,
+	includes core.UserIncludes,
 ) (core.UserWithDetails, error) {
 	var user core.UserWithDetails
 	var skillsJSON []byte
 
-	var addID, addUserID, addStreet, addCity, addZipCode, addCountry sql.NullString
+	var addID, addUserID, addType, addStreet, addCity, addZipCode, addCountry sql.NullString
 	var addIsPrimary sql.NullBool
 	var addCreatedAt, addUpdatedAt sql.NullTime
+	var current currentSalaryColumns
 
 	user.Departments = &core.Departments{}
 	user.Position = &core.Position{}
@@ -236,11 +261,15 @@ func (r *UserRepository) Get(
 			u.last_name,
 			u.department_id,
 			u.position_id,
+			COALESCE(u.manager_id::text, '') AS manager_id,
 			u.hire_date,
 			u.phone,
 			u.date_of_birth,
 			u.created_at,
 			u.updated_at,
+			u.employment_status,
+			u.termination_date,
+			COALESCE(u.termination_reason, ''),
 
 			COALESCE(d.id::text, '00000000-0000-0000-0000-000000000000') AS dept_id,
 			COALESCE(d.name, '') AS dept_name,
@@ -257,6 +286,7 @@ func (r *UserRepository) Get(
 
 			COALESCE(a.id::text, '') AS a_id,
 			COALESCE(a.user_id::text, '') AS a_user_id,
+			COALESCE(a.address_type, '') AS a_type,
 			COALESCE(a.street, '') AS a_street,
 			COALESCE(a.city, '') AS a_city,
 			COALESCE(a.zip_code, '') AS a_zip_code,
@@ -278,7 +308,15 @@ func (r *UserRepository) Get(
 				FROM user_skills us
 				JOIN skills s ON us.skill_id = s.id
 				WHERE us.user_id = u.id
-			), '[]'::json) AS skills
+			), '[]'::json) AS skills,
+
+			COALESCE(cs.id::text, '') AS cs_id,
+			cs.amount AS cs_amount,
+			cs.currency AS cs_currency,
+			cs.effective_date AS cs_effective_date,
+			COALESCE(cs.out_of_band, false) AS cs_out_of_band,
+			cs.created_at AS cs_created_at,
+			cs.updated_at AS cs_updated_at
 
 		FROM users u
 		LEFT JOIN departments d ON u.department_id = d.id
@@ -286,14 +324,15 @@ func (r *UserRepository) Get(
 		LEFT JOIN LATERAL (
 			SELECT *
 			FROM addresses a
-			WHERE a.user_id = u.id
-			LIMIT 1
+			WHERE a.user_id = u.id AND a.is_primary
 		) a ON true
+		`+currentSalaryJoin(2)+`
 		WHERE u.id = $1
-	`, id).Scan(
+	`, id, includes.Salary).Scan(
 		&user.ID, &user.Email, &user.FirstName, &user.LastName,
-		&user.DepartmentID, &user.PositionID, &user.HireDate,
+		&user.DepartmentID, &user.PositionID, &user.ManagerID, &user.HireDate,
 		&user.Phone, &user.DateOfBirth, &user.CreatedAt, &user.UpdatedAt,
+		&user.EmploymentStatus, &user.TerminationDate, &user.TerminationReason,
 
 		&user.Departments.ID, &user.Departments.Name, &user.Departments.Description,
 		&user.Departments.CreatedAt, &user.Departments.UpdatedAt,
@@ -301,10 +340,12 @@ func (r *UserRepository) Get(
 		&user.Position.ID, &user.Position.Title, &user.Position.Level, &user.Position.DepartmentID,
 		&user.Position.CreatedAt, &user.Position.UpdatedAt,
 
-		&addID, &addUserID, &addStreet, &addCity, &addZipCode,
+		&addID, &addUserID, &addType, &addStreet, &addCity, &addZipCode,
 		&addCountry, &addIsPrimary, &addCreatedAt, &addUpdatedAt,
 
 		&skillsJSON,
+
+		&current.id, &current.amount, &current.currency, &current.effectiveDate, &current.outOfBand, &current.createdAt, &current.updatedAt,
 	)
 	if err != nil {
 		return core.UserWithDetails{}, err
@@ -313,6 +354,7 @@ func (r *UserRepository) Get(
 	if addID.Valid {
 		user.Address.ID = addID.String
 		user.Address.UserID = addUserID.String
+		user.Address.Type = addType.String
 		user.Address.Street = addStreet.String
 		user.Address.City = addCity.String
 		user.Address.ZipCode = addZipCode.String
@@ -333,6 +375,8 @@ func (r *UserRepository) Get(
 		user.Skill = []core.Skill{}
 	}
 
+	user.CurrentSalary = current.salary(user.ID)
+
 	return user, nil
 }
 
@@ -344,11 +388,13 @@ func (r *UserRepository) Update(
 	var user core.User
 
 	err := r.pool.QueryRow(ctx, `
-		SELECT id, email, first_name, last_name, department_id, position_id, hire_date, phone, date_of_birth, created_at, updated_at
+		SELECT id, email, first_name, last_name, department_id, position_id, COALESCE(manager_id::text, ''), hire_date, phone, date_of_birth, created_at, updated_at,
+			employment_status, termination_date, COALESCE(termination_reason, '')
 		FROM users WHERE id = $1
 	`, id).Scan(
-		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.DepartmentID, &user.PositionID,
+		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.DepartmentID, &user.PositionID, &user.ManagerID,
 		&user.HireDate, &user.Phone, &user.DateOfBirth, &user.CreatedAt, &user.UpdatedAt,
+		&user.EmploymentStatus, &user.TerminationDate, &user.TerminationReason,
 	)
 
 	if err != nil {
@@ -367,8 +413,20 @@ func (r *UserRepository) Update(
 	if update.LastName != nil {
 		user.LastName = *update.LastName
 	}
+	if update.DepartmentID != "" {
+		user.DepartmentID = update.DepartmentID
+	}
+	if update.PositionID != "" {
+		user.PositionID = update.PositionID
+	}
 
-	_, err = r.pool.Exec(ctx, `
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return core.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
 		UPDATE users
 		SET email = $1, first_name = $2, last_name = $3, department_id = $4, position_id = $5, hire_date = $6, phone = $7, date_of_birth = $8, updated_at = NOW()
 		WHERE id = $9
@@ -377,12 +435,51 @@ func (r *UserRepository) Update(
 		return core.User{}, err
 	}
 
+	// Plain edits take effect today; use Promote for a dated move with a
+	// new salary. Offboarded users keep their closed history.
+	if user.EmploymentStatus != core.EmploymentTerminated {
+		if _, err := syncPositionAssignment(ctx, tx, id, time.Now(), core.AssignmentChange, "", ""); err != nil {
+			return core.User{}, err
+		}
+	}
+
+	if err := syncDepartmentChannelsForEmployee(ctx, tx, id); err != nil {
+		return core.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return core.User{}, err
+	}
+
 	return user, nil
 }
 
-func (r *UserRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
-	return err
+// Delete removes an employee. Direct reports block the delete unless
+// reassignTo names their new manager: a user ID, or core.ReassignToManager to hand
+// them to the deleted user's own manager.
+func (r *UserRepository) Delete(ctx context.Context, id, reassignTo string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := reassignDirectReports(ctx, tx, id, reassignTo); err != nil {
+		return err
+	}
+
+	if err := deactivateLinkedForumUser(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := syncDepartmentChannelsForEmployee(ctx, tx, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *UserRepository) SeedUsersIfEmpty(
@@ -446,3 +543,43 @@ func (r *UserRepository) DeleteDevData(ctx context.Context) {
 	}
 	slog.Info("Dropped Users Table successfully")
 }
+
+// currentSalaryJoin resolves the salary in effect today. The boolean
+// parameter at index param switches the lookup off when it is not requested.
+func currentSalaryJoin(param int) string {
+	return fmt.Sprintf(`
+		LEFT JOIN LATERAL (
+			SELECT id, amount, currency, effective_date, out_of_band, created_at, updated_at
+			FROM salaries s
+			WHERE $%d::boolean AND s.user_id = u.id AND s.effective_date <= CURRENT_DATE
+			ORDER BY s.effective_date DESC, s.created_at DESC
+			LIMIT 1
+		) cs ON true
+	`, param)
+}
+
+type currentSalaryColumns struct {
+	id            string
+	amount        decimal.NullDecimal
+	currency      *string
+	effectiveDate sql.NullTime
+	outOfBand     bool
+	createdAt     sql.NullTime
+	updatedAt     sql.NullTime
+}
+
+func (c currentSalaryColumns) salary(userID string) *core.Salary {
+	if c.id == "" || !c.amount.Valid || c.currency == nil {
+		return nil
+	}
+	return &core.Salary{
+		ID:            c.id,
+		UserID:        userID,
+		Amount:        c.amount.Decimal,
+		Currency:      *c.currency,
+		EffectiveDate: c.effectiveDate.Time,
+		OutOfBand:     c.outOfBand,
+		CreatedAt:     c.createdAt.Time,
+		UpdatedAt:     c.updatedAt.Time,
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"multi-processing-backend/internal/core"
+)
+
+const (
+	maxStreetLength = 200
+	maxCityLength   = 100
+)
+
+// countryByName indexes iso3166 and countryAliases by lower-case name.
+var countryByName = func() map[string]string {
+	byName := make(map[string]string, len(iso3166)+len(countryAliases))
+	for code, name := range iso3166 {
+		byName[strings.ToLower(name)] = code
+	}
+	for alias, code := range countryAliases {
+		byName[alias] = code
+	}
+	return byName
+}()
+
+// postalRule describes the postal codes of one country. normalize runs
+// on the upper-cased, trimmed input before pattern is matched.
+type postalRule struct {
+	pattern   *regexp.Regexp
+	example   string
+	normalize func(string) string
+}
+
+var postalRules = map[string]postalRule{
+	"DE": {regexp.MustCompile(`^\d{5}$`), "10115", stripCountryPrefix},
+	"AT": {regexp.MustCompile(`^[1-9]\d{3}$`), "1010", stripCountryPrefix},
+	"CH": {regexp.MustCompile(`^[1-9]\d{3}$`), "8001", stripCountryPrefix},
+	"FR": {regexp.MustCompile(`^\d{5}$`), "75008", stripCountryPrefix},
+	"US": {regexp.MustCompile(`^\d{5}(-\d{4})?$`), "94105 or 94105-1234", normalizeZIP},
+	"GB": {
+		regexp.MustCompile(`^([A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}|GIR 0AA)$`), "SW1A 1AA",
+		func(s string) string { return spaceBeforeLast(s, 3) },
+	},
+	"NL": {
+		regexp.MustCompile(`^[1-9]\d{3} ([A-RT-Z][A-Z]|S[BCE-RT-Z])$`), "1012 AB",
+		func(s string) string { return spaceBeforeLast(stripCountryPrefix(s), 2) },
+	},
+}
+
+// genericPostalCode is checked for countries without a rule of their own.
+var genericPostalCode = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,11}$`)
+
+// numberLastCountries write the house number after the street name.
+var numberLastCountries = map[string]bool{"DE": true, "AT": true, "CH": true, "NL": true}
+
+var (
+	spaces             = regexp.MustCompile(`\s+`)
+	countryPrefix      = regexp.MustCompile(`^[A-Z]{1,2}-\s*(\d.*)$`)
+	streetAbbrev       = regexp.MustCompile(`([Ss])tr\.\s*`)
+	trailingNumber     = regexp.MustCompile(`^(.*?[^\d\s,])[\s,]*(\d+\s*[A-Za-z]?(?:\s*[-/]\s*\d+\s*[A-Za-z]?)?)$`)
+	leadingNumberComma = regexp.MustCompile(`^(\d+\s*[A-Za-z]?)\s*,\s*`)
+)
+
+// NormalizeAddress checks an address against the rules of its country and
+// returns it in canonical form: the country as ISO 3166-1 alpha-2 code,
+// the postal code in its national format and the street with a single
+// space before the house number. All problems are reported together in a
+// *core.ValidationError.
+func NormalizeAddress(a core.Address) (core.Address, error) {
+	verr := &core.ValidationError{}
+
+	a.Type = strings.ToLower(strings.TrimSpace(a.Type))
+	switch a.Type {
+	case "":
+		a.Type = core.AddressHome
+	case core.AddressHome, core.AddressMailing, core.AddressWork:
+	default:
+		verr.Add("type", "must be home, mailing or work")
+	}
+
+	country, countryOK := normalizeCountry(a.Country)
+	switch {
+	case strings.TrimSpace(a.Country) == "":
+		verr.Add("country", "must not be empty")
+	case !countryOK:
+		verr.Add("country", "must be an ISO 3166 country code such as DE, or a country name")
+	default:
+		a.Country = country
+	}
+
+	a.ZipCode = strings.ToUpper(collapseSpaces(a.ZipCode))
+	if a.ZipCode == "" {
+		verr.Add("zip_code", "must not be empty")
+	} else if countryOK {
+		if rule, ok := postalRules[country]; ok {
+			a.ZipCode = rule.normalize(a.ZipCode)
+			if !rule.pattern.MatchString(a.ZipCode) {
+				verr.Add("zip_code", "must be a postal code of "+iso3166[country]+" like "+rule.example)
+			}
+		} else if !genericPostalCode.MatchString(a.ZipCode) {
+			verr.Add("zip_code", "must only contain letters, digits, spaces and dashes")
+		}
+	}
+
+	a.City = collapseSpaces(a.City)
+	switch {
+	case a.City == "":
+		verr.Add("city", "must not be empty")
+	case utf8.RuneCountInString(a.City) > maxCityLength:
+		verr.Add("city", "must not be longer than 100 characters")
+	}
+
+	street, hasNumber := normalizeStreet(country, a.Street)
+	a.Street = street
+	switch {
+	case a.Street == "":
+		verr.Add("street", "must not be empty")
+	case utf8.RuneCountInString(a.Street) > maxStreetLength:
+		verr.Add("street", "must not be longer than 200 characters")
+	case numberLastCountries[country] && !hasNumber:
+		verr.Add("street", "must end with a house number, e.g. Hauptstraße 12")
+	}
+
+	if err := verr.Err(); err != nil {
+		return core.Address{}, err
+	}
+	return a, nil
+}
+
+// normalizeCountry resolves an alpha-2 code or a country name to the
+// alpha-2 code.
+func normalizeCountry(in string) (string, bool) {
+	in = collapseSpaces(in)
+	if code := strings.ToUpper(in); len(code) == 2 {
+		if _, ok := iso3166[code]; ok {
+			return code, true
+		}
+	}
+	code, ok := countryByName[strings.ToLower(in)]
+	return code, ok
+}
+
+// normalizeStreet tidies the whitespace of a street line. In countries
+// that put the house number last, "str." is spelled out and the number is
+// separated from the name by exactly one space, e.g. "Hauptstr.12 A"
+// becomes "Hauptstraße 12a". It also reports whether a trailing house
+// number was found.
+func normalizeStreet(country, street string) (string, bool) {
+	street = collapseSpaces(street)
+	if !numberLastCountries[country] {
+		return leadingNumberComma.ReplaceAllString(street, "$1 "), false
+	}
+
+	switch country {
+	case "DE", "AT":
+		street = streetAbbrev.ReplaceAllString(street, "${1}traße ")
+	case "CH":
+		// Swiss German does not use ß.
+		street = streetAbbrev.ReplaceAllString(street, "${1}trasse ")
+	}
+	street = strings.TrimSpace(street)
+
+	m := trailingNumber.FindStringSubmatch(street)
+	if m == nil {
+		return street, false
+	}
+	number := strings.ToLower(strings.Join(strings.Fields(m[2]), ""))
+	return m[1] + " " + number, true
+}
+
+func collapseSpaces(s string) string {
+	return strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+}
+
+// stripCountryPrefix drops the old European "D-10115" style prefix.
+func stripCountryPrefix(s string) string {
+	if m := countryPrefix.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return s
+}
+
+// normalizeZIP turns a nine digit ZIP code into ZIP+4 notation.
+func normalizeZIP(s string) string {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) == 9 && strings.Trim(s, "0123456789") == "" {
+		return s[:5] + "-" + s[5:]
+	}
+	return s
+}
+
+// spaceBeforeLast removes all spaces from s and puts one back before its
+// last n characters.
+func spaceBeforeLast(s string, n int) string {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) <= n {
+		return s
+	}
+	return s[:len(s)-n] + " " + s[len(s)-n:]
+}
@@ -13,6 +13,8 @@ type AddressRepository interface {
 	Get(ctx context.Context, id string) (core.Address, error)
 	Update(ctx context.Context, id string, update core.AddressUpdate) (core.Address, error)
 	Delete(ctx context.Context, id string) error
+	ListByUser(ctx context.Context, userID string) ([]core.Address, error)
+	MakePrimary(ctx context.Context, id string) (core.Address, error)
 }
 
 type AddressService struct {
@@ -31,6 +33,10 @@ func (s *AddressService) Create(
 	ctx context.Context, 
 	add core.Address,
 ) (core.Address, error) {
+	add, err := NormalizeAddress(add)
+	if err != nil {
+		return core.Address{}, err
+	}
 	return s.repo.Create(ctx, add)
 }
 
@@ -38,10 +44,51 @@ func (s *AddressService) Get(ctx context.Context, id string) (core.Address, erro
 	return s.repo.Get(ctx, id)
 }
 
+// Update validates the address as it will look after the update, so a
+// new postal code is checked against the country already on file.
 func (s *AddressService) Update(ctx context.Context, id string, updates core.AddressUpdate) (core.Address, error) {
+	current, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return core.Address{}, err
+	}
+	if updates.Type != nil {
+		current.Type = *updates.Type
+	}
+	if updates.Street != nil {
+		current.Street = *updates.Street
+	}
+	if updates.City != nil {
+		current.City = *updates.City
+	}
+	if updates.ZipCode != nil {
+		current.ZipCode = *updates.ZipCode
+	}
+	if updates.Country != nil {
+		current.Country = *updates.Country
+	}
+
+	normalized, err := NormalizeAddress(current)
+	if err != nil {
+		return core.Address{}, err
+	}
+	updates.Type = &normalized.Type
+	updates.Street = &normalized.Street
+	updates.City = &normalized.City
+	updates.ZipCode = &normalized.ZipCode
+	updates.Country = &normalized.Country
 	return s.repo.Update(ctx, id, updates)
 }
 
 func (s *AddressService) Delete(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
 }
+
+func (s *AddressService) ListByUser(ctx context.Context, userID string) ([]core.Address, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// MakePrimary makes an address the primary one of its user, demoting the
+// previous primary.
+func (s *AddressService) MakePrimary(ctx context.Context, id string) (core.Address, error) {
+	return s.repo.MakePrimary(ctx, id)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+)
+
+// auditedEntities are the entity names the audit triggers record.
+var auditedEntities = []string{
+	"user", "department", "position", "salary", "address", "skill",
+	"skill_alias", "skill_category", "user_skill", "position_skill", "salary_band",
+}
+
+type AuditRepository interface {
+	List(ctx context.Context, f core.AuditFilter, page, limit int) ([]core.AuditEntry, int64, error)
+}
+
+type AuditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// List returns matching entries. Without salary access the row contents of
+// salary entries are left out; who changed which salary and when stays.
+func (s *AuditService) List(
+	ctx context.Context,
+	f core.AuditFilter,
+	page, limit int,
+) ([]core.AuditEntry, int64, error) {
+	f.Entity = strings.ToLower(strings.TrimSpace(f.Entity))
+	if f.Entity != "" && !slices.Contains(auditedEntities, f.Entity) {
+		return nil, 0, fmt.Errorf("entity must be one of %s", strings.Join(auditedEntities, ", "))
+	}
+	f.Action = strings.ToLower(strings.TrimSpace(f.Action))
+	switch f.Action {
+	case "", core.AuditCreate, core.AuditUpdate, core.AuditDelete:
+	default:
+		return nil, 0, fmt.Errorf("action must be create, update or delete")
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return nil, 0, fmt.Errorf("from must be before to")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		return nil, 0, fmt.Errorf("limit must be between 1 and 500")
+	}
+	entries, total, err := s.repo.List(ctx, f, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !core.HasSalaryAccess(ctx) {
+		for i := range entries {
+			if entries[i].Entity == "salary" {
+				entries[i].Before, entries[i].After, entries[i].Changes = nil, nil, nil
+			}
+		}
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	avatarSize         = 256
+	maxAvatarBytes     = 5 << 20
+	maxAvatarDimension = 4096
+)
+
+// AvatarStore keeps resized avatars on local disk and hands out the URL
+// under which the API serves them.
+type AvatarStore struct {
+	dir       string
+	urlPrefix string
+}
+
+func NewAvatarStore(dir, urlPrefix string) *AvatarStore {
+	return &AvatarStore{dir: dir, urlPrefix: urlPrefix}
+}
+
+func (s *AvatarStore) Dir() string {
+	return s.dir
+}
+
+func (s *AvatarStore) Save(userID string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxAvatarBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxAvatarBytes {
+		return "", fmt.Errorf("avatar exceeds %d bytes", maxAvatarBytes)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unsupported or invalid image: %w", err)
+	}
+	if cfg.Width > maxAvatarDimension || cfg.Height > maxAvatarDimension {
+		return "", fmt.Errorf("avatar dimensions exceed %dpx", maxAvatarDimension)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("unsupported or invalid image: %w", err)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, avatarSize, avatarSize))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, centerSquare(src.Bounds()), draw.Over, nil)
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	name := userID + ".png"
+	tmp, err := os.CreateTemp(s.dir, name+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := png.Encode(tmp, dst); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s?v=%d", s.urlPrefix, name, time.Now().Unix()), nil
+}
+
+func centerSquare(b image.Rectangle) image.Rectangle {
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
@@ -3,6 +3,15 @@ package services
 import (
 	"context"
 	"multi-processing-backend/internal/core"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	departmentStatsTTL    = time.Minute
+	departmentStatsMonths = 12
 )
 
 type DepartmentRepository interface {
@@ -12,14 +21,30 @@ type DepartmentRepository interface {
 	Get(ctx context.Context, id string) (core.Departments, error)
 	Update(ctx context.Context, id string, update core.DepartmentUpdate) (core.Departments, error)
 	Delete(ctx context.Context, id string) error
+
+	Stats(ctx context.Context, departmentID, currency string, months int) (core.DepartmentStats, error)
+	SkillGaps(ctx context.Context, departmentID string) (core.DepartmentSkillGapReport, error)
 }
 
 type DepartmentService struct {
-	repo DepartmentRepository
+	repo         DepartmentRepository
+	baseCurrency string
+
+	mu    sync.Mutex
+	stats map[string]cachedDepartmentStats
 }
 
-func NewDepartmentService(repo DepartmentRepository) *DepartmentService {
-	return &DepartmentService{repo: repo}
+type cachedDepartmentStats struct {
+	stats   core.DepartmentStats
+	expires time.Time
+}
+
+func NewDepartmentService(repo DepartmentRepository, baseCurrency string) *DepartmentService {
+	return &DepartmentService{
+		repo:         repo,
+		baseCurrency: baseCurrency,
+		stats:        make(map[string]cachedDepartmentStats),
+	}
 }
 
 func (s *DepartmentService) List(ctx context.Context, searchName string) ([]core.Departments, int64, error) {
@@ -54,3 +79,62 @@ func (s *DepartmentService) Delete(
 ) error {
 	return s.repo.Delete(ctx, id)
 }
+
+// Stats returns the analytics for one department, or the whole company when
+// departmentID is empty. Results are cached briefly since every call runs
+// several aggregates over all employees.
+func (s *DepartmentService) Stats(
+	ctx context.Context,
+	departmentID, currency string,
+) (core.DepartmentStats, error) {
+	if currency == "" {
+		currency = s.baseCurrency
+	}
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+
+	key := departmentID + "|" + currency
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.stats[key]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return statsForCaller(ctx, cached.stats), nil
+	}
+
+	stats, err := s.repo.Stats(ctx, departmentID, currency, departmentStatsMonths)
+	if err != nil {
+		return core.DepartmentStats{}, err
+	}
+
+	s.mu.Lock()
+	for k, c := range s.stats {
+		if now.After(c.expires) {
+			delete(s.stats, k)
+		}
+	}
+	s.stats[key] = cachedDepartmentStats{stats: stats, expires: now.Add(departmentStatsTTL)}
+	s.mu.Unlock()
+
+	return statsForCaller(ctx, stats), nil
+}
+
+// statsForCaller leaves out the salary figures unless ctx has salary
+// access; in a small department they give away individual pay.
+func statsForCaller(ctx context.Context, stats core.DepartmentStats) core.DepartmentStats {
+	if !core.HasSalaryAccess(ctx) {
+		stats.Salaries.Min = decimal.NullDecimal{}
+		stats.Salaries.Median = decimal.NullDecimal{}
+		stats.Salaries.P90 = decimal.NullDecimal{}
+	}
+	return stats
+}
+
+// SkillGaps reports where a department, or the whole company when
+// departmentID is empty, falls short of its positions' skill requirements.
+func (s *DepartmentService) SkillGaps(ctx context.Context, departmentID string) (core.DepartmentSkillGapReport, error) {
+	return s.repo.SkillGaps(ctx, departmentID)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/shopspring/decimal"
+)
+
+type ExchangeRateRepository interface {
+	Upsert(ctx context.Context, rates []core.ExchangeRate) (int, error)
+	List(ctx context.Context, date time.Time) ([]core.ExchangeRate, error)
+	RateOn(ctx context.Context, currency string, date time.Time) (decimal.Decimal, error)
+}
+
+type ExchangeRateService struct {
+	repo ExchangeRateRepository
+	file string
+}
+
+func NewExchangeRateService(repo ExchangeRateRepository, file string) *ExchangeRateService {
+	return &ExchangeRateService{repo: repo, file: file}
+}
+
+// Reload reads the local rates file and upserts every rate in it.
+func (s *ExchangeRateService) Reload(ctx context.Context) (int, error) {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return 0, err
+	}
+
+	var file core.ExchangeRateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, fmt.Errorf("parse %s: %w", s.file, err)
+	}
+	if !strings.EqualFold(file.Base, core.PivotCurrency) {
+		return 0, fmt.Errorf("rates file base must be %s, got %q", core.PivotCurrency, file.Base)
+	}
+
+	rates := make([]core.ExchangeRate, 0, len(file.Rates))
+	for i, entry := range file.Rates {
+		currency, err := normalizeCurrency(entry.Currency)
+		if err != nil || currency == core.PivotCurrency {
+			return 0, fmt.Errorf("rates file entry %d: invalid currency %q", i, entry.Currency)
+		}
+		date, err := time.Parse(time.DateOnly, entry.Date)
+		if err != nil {
+			return 0, fmt.Errorf("rates file entry %d: date must be YYYY-MM-DD", i)
+		}
+		if !entry.Rate.IsPositive() {
+			return 0, fmt.Errorf("rates file entry %d: rate must be positive", i)
+		}
+		rates = append(rates, core.ExchangeRate{Currency: currency, RateDate: date, Rate: entry.Rate})
+	}
+
+	return s.repo.Upsert(ctx, rates)
+}
+
+func (s *ExchangeRateService) List(ctx context.Context, date time.Time) ([]core.ExchangeRate, error) {
+	return s.repo.List(ctx, date)
+}
+
+// Factor returns the multiplier that turns an amount in from into to, using
+// the rates in effect on date.
+func (s *ExchangeRateService) Factor(ctx context.Context, from, to string, date time.Time) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+	fromRate, err := s.repo.RateOn(ctx, from, date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	toRate, err := s.repo.RateOn(ctx, to, date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return toRate.Div(fromRate), nil
+}
+
+// Convert turns amount from one currency into another, rounded to cents.
+func (s *ExchangeRateService) Convert(
+	ctx context.Context,
+	amount decimal.Decimal,
+	from, to string,
+	date time.Time,
+) (decimal.Decimal, error) {
+	factor, err := s.Factor(ctx, from, to, date)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return amount.Mul(factor).Round(2), nil
+}
+
+// normalizeCurrency upper-cases a currency code and checks it looks like an
+// ISO 4217 code.
+func normalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("currency must be a three-letter ISO 4217 code")
+	}
+	return code, nil
+}
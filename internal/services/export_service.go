@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+)
+
+type UserExporter interface {
+	ExportColumns() []core.ExportColumn
+	Export(ctx context.Context, filter core.UserFilter, columns []string, fn func([]string) error) error
+}
+
+type SalaryExporter interface {
+	ExportColumns() []core.ExportColumn
+	Export(ctx context.Context, columns []string, fn func([]string) error) error
+}
+
+type DepartmentExporter interface {
+	ExportColumns() []core.ExportColumn
+	Export(ctx context.Context, searchName string, columns []string, fn func([]string) error) error
+}
+
+// ExportService streams users, salaries and departments as CSV, JSON
+// Lines or XLSX. Salary columns are only exported with salary access, see
+// core.HasSalaryAccess.
+type ExportService struct {
+	users       UserExporter
+	salaries    SalaryExporter
+	departments DepartmentExporter
+}
+
+func NewExportService(
+	users UserExporter,
+	salaries SalaryExporter,
+	departments DepartmentExporter,
+) *ExportService {
+	return &ExportService{users: users, salaries: salaries, departments: departments}
+}
+
+// ExportUsers writes the users List returns for filter to w.
+func (s *ExportService) ExportUsers(
+	ctx context.Context,
+	w io.Writer,
+	opts core.ExportOptions,
+	filter core.UserFilter,
+) error {
+	if err := normalizeUserFilter(&filter); err != nil {
+		return err
+	}
+	if err := checkSalaryFilterAccess(ctx, filter); err != nil {
+		return err
+	}
+	columns, err := s.pickColumns(ctx, s.users.ExportColumns(), opts.Columns)
+	if err != nil {
+		return err
+	}
+	return s.export(w, opts.Format, columns, func(fn func([]string) error) error {
+		return s.users.Export(ctx, filter, columnNames(columns), fn)
+	})
+}
+
+// ExportSalaries needs salary access for every column, like the /salary
+// routes.
+func (s *ExportService) ExportSalaries(ctx context.Context, w io.Writer, opts core.ExportOptions) error {
+	if !core.HasSalaryAccess(ctx) {
+		return core.ErrSalaryForbidden
+	}
+	columns, err := s.pickColumns(ctx, s.salaries.ExportColumns(), opts.Columns)
+	if err != nil {
+		return err
+	}
+	return s.export(w, opts.Format, columns, func(fn func([]string) error) error {
+		return s.salaries.Export(ctx, columnNames(columns), fn)
+	})
+}
+
+func (s *ExportService) ExportDepartments(
+	ctx context.Context,
+	w io.Writer,
+	opts core.ExportOptions,
+	searchName string,
+) error {
+	columns, err := s.pickColumns(ctx, s.departments.ExportColumns(), opts.Columns)
+	if err != nil {
+		return err
+	}
+	return s.export(w, opts.Format, columns, func(fn func([]string) error) error {
+		return s.departments.Export(ctx, searchName, columnNames(columns), fn)
+	})
+}
+
+// pickColumns resolves the requested column names. Without a request
+// every column the caller may see is used; asking for a sensitive column
+// without permission is an error rather than a silent omission.
+func (s *ExportService) pickColumns(
+	ctx context.Context,
+	available []core.ExportColumn,
+	requested []string,
+) ([]core.ExportColumn, error) {
+	privileged := core.HasSalaryAccess(ctx)
+
+	if len(requested) == 0 {
+		var columns []core.ExportColumn
+		for _, c := range available {
+			if !c.Sensitive || privileged {
+				columns = append(columns, c)
+			}
+		}
+		return columns, nil
+	}
+
+	columns := make([]core.ExportColumn, 0, len(requested))
+	for _, name := range requested {
+		i := slices.IndexFunc(available, func(c core.ExportColumn) bool { return c.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("columns must only name %s; %q is unknown",
+				strings.Join(columnNames(available), ", "), name)
+		}
+		if slices.ContainsFunc(columns, func(c core.ExportColumn) bool { return c.Name == name }) {
+			return nil, fmt.Errorf("columns must not name %q twice", name)
+		}
+		if available[i].Sensitive && !privileged {
+			return nil, fmt.Errorf("%w: %s", core.ErrSalaryForbidden, name)
+		}
+		columns = append(columns, available[i])
+	}
+	return columns, nil
+}
+
+// export writes a header and then every row fetch hands over.
+func (s *ExportService) export(
+	w io.Writer,
+	format string,
+	columns []core.ExportColumn,
+	fetch func(fn func([]string) error) error,
+) error {
+	ew, err := newExportWriter(w, format, columns)
+	if err != nil {
+		return err
+	}
+	if err := fetch(ew.Write); err != nil {
+		return err
+	}
+	return ew.Close()
+}
+
+func columnNames(columns []core.ExportColumn) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// exportWriter writes one record per row in some file format.
+type exportWriter interface {
+	Write(record []string) error
+	Close() error
+}
+
+func newExportWriter(w io.Writer, format string, columns []core.ExportColumn) (exportWriter, error) {
+	switch format {
+	case core.ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columnNames(columns)); err != nil {
+			return nil, err
+		}
+		return &csvExportWriter{w: cw, columns: columns}, nil
+	case core.ExportJSONL:
+		return &jsonlExportWriter{w: bufio.NewWriter(w), columns: columns}, nil
+	case core.ExportXLSX:
+		xw, err := newXLSXWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		ew := &xlsxExportWriter{w: xw, columns: columns, cells: make([]xlsxCell, len(columns))}
+		header := make([]xlsxCell, len(columns))
+		for i, c := range columns {
+			header[i] = xlsxCell{value: c.Name, kind: core.ExportText, style: xlsxStyleHeader}
+		}
+		if err := xw.writeRow(header); err != nil {
+			return nil, err
+		}
+		return ew, nil
+	default:
+		return nil, fmt.Errorf("format must be csv, jsonl or xlsx")
+	}
+}
+
+type csvExportWriter struct {
+	w       *csv.Writer
+	columns []core.ExportColumn
+	escaped []string
+}
+
+// Write neutralizes text that a spreadsheet would run as a formula.
+func (c *csvExportWriter) Write(record []string) error {
+	c.escaped = append(c.escaped[:0], record...)
+	for i, v := range c.escaped {
+		if c.columns[i].Kind == core.ExportText && v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			c.escaped[i] = "'" + v
+		}
+	}
+	return c.w.Write(c.escaped)
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlExportWriter struct {
+	w       *bufio.Writer
+	columns []core.ExportColumn
+}
+
+// Write emits one JSON object per line with the columns in order. Numbers
+// stay numbers, empty dates and numbers become null.
+func (j *jsonlExportWriter) Write(record []string) error {
+	j.w.WriteByte('{')
+	for i, c := range j.columns {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		name, _ := json.Marshal(c.Name)
+		j.w.Write(name)
+		j.w.WriteByte(':')
+
+		v := record[i]
+		switch {
+		case v == "" && c.Kind != core.ExportText:
+			j.w.WriteString("null")
+		case c.Kind == core.ExportNumber && json.Valid([]byte(v)):
+			j.w.WriteString(v)
+		default:
+			quoted, _ := json.Marshal(v)
+			j.w.Write(quoted)
+		}
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlExportWriter) Close() error {
+	return j.w.Flush()
+}
+
+type xlsxExportWriter struct {
+	w       *xlsxWriter
+	columns []core.ExportColumn
+	cells   []xlsxCell
+}
+
+func (x *xlsxExportWriter) Write(record []string) error {
+	for i, c := range x.columns {
+		x.cells[i] = xlsxCell{value: record[i], kind: c.Kind}
+	}
+	return x.w.writeRow(x.cells)
+}
+
+func (x *xlsxExportWriter) Close() error {
+	return x.w.Close()
+}
@@ -0,0 +1,485 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"multi-processing-backend/internal/core"
+)
+
+type ForumCommand interface {
+	Spec() core.ForumCommandSpec
+	Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error)
+}
+
+type ForumCommandContext struct {
+	ChannelID       string
+	UserID          string
+	ParentMessageID string
+	Args            map[string]string
+}
+
+type ForumCommandRegistry struct {
+	mu       sync.RWMutex
+	commands map[string]ForumCommand
+}
+
+func NewForumCommandRegistry() *ForumCommandRegistry {
+	return &ForumCommandRegistry{commands: make(map[string]ForumCommand)}
+}
+
+func (r *ForumCommandRegistry) Register(cmd ForumCommand) error {
+	name := strings.ToLower(cmd.Spec().Name)
+	if name == "" {
+		return fmt.Errorf("command name required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.commands[name]; exists {
+		return fmt.Errorf("command /%s already registered", name)
+	}
+	r.commands[name] = cmd
+	return nil
+}
+
+func (r *ForumCommandRegistry) Lookup(name string) (ForumCommand, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, ok := r.commands[strings.ToLower(name)]
+	return cmd, ok
+}
+
+func (r *ForumCommandRegistry) Specs() []core.ForumCommandSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	specs := make([]core.ForumCommandSpec, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		specs = append(specs, cmd.Spec())
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// IsCommand reports whether content should be routed to a command handler.
+// A leading "//" escapes the slash and is posted as plain text.
+func IsCommand(content string) bool {
+	return strings.HasPrefix(content, "/") && !strings.HasPrefix(content, "//")
+}
+
+func (r *ForumCommandRegistry) Dispatch(
+	ctx context.Context,
+	channelID, userID, content, parentMessageID string,
+) (*core.ForumMessage, error) {
+	name, rest := splitCommand(content)
+	cmd, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown command /%s", name)
+	}
+
+	spec := cmd.Spec()
+	args, err := parseCommandArgs(spec, rest)
+	if err != nil {
+		return nil, err
+	}
+
+	return cmd.Execute(ctx, ForumCommandContext{
+		ChannelID:       channelID,
+		UserID:          userID,
+		ParentMessageID: parentMessageID,
+		Args:            args,
+	})
+}
+
+func splitCommand(content string) (string, string) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(content), "/")
+	name, rest, _ := strings.Cut(trimmed, " ")
+	return name, strings.TrimSpace(rest)
+}
+
+type commandToken struct {
+	value string
+	start int
+}
+
+func tokenizeCommandArgs(input string) ([]commandToken, error) {
+	var tokens []commandToken
+	i := 0
+	for i < len(input) {
+		if input[i] == ' ' || input[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		if input[i] == '"' {
+			end := strings.IndexByte(input[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in command arguments")
+			}
+			tokens = append(tokens, commandToken{value: input[i+1 : i+1+end], start: start})
+			i += end + 2
+			continue
+		}
+
+		for i < len(input) && input[i] != ' ' && input[i] != '\t' {
+			i++
+		}
+		tokens = append(tokens, commandToken{value: input[start:i], start: start})
+	}
+	return tokens, nil
+}
+
+func parseCommandArgs(spec core.ForumCommandSpec, input string) (map[string]string, error) {
+	tokens, err := tokenizeCommandArgs(input)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make(map[string]string, len(spec.Args))
+	pos := 0
+	for _, arg := range spec.Args {
+		if pos >= len(tokens) {
+			if arg.Required {
+				return nil, fmt.Errorf("missing argument <%s>, usage: %s", arg.Name, spec.Usage)
+			}
+			continue
+		}
+
+		if arg.Variadic {
+			args[arg.Name] = strings.TrimSpace(input[tokens[pos].start:])
+			pos = len(tokens)
+			continue
+		}
+
+		value := tokens[pos].value
+		if arg.Type == core.CommandArgUser {
+			value = strings.TrimPrefix(value, "@")
+		}
+		args[arg.Name] = value
+		pos++
+	}
+
+	if pos < len(tokens) {
+		return nil, fmt.Errorf("too many arguments, usage: %s", spec.Usage)
+	}
+	return args, nil
+}
+
+func parseReminderDelay(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
+
+func commandResponse(cmd ForumCommandContext, content string) *core.ForumMessage {
+	now := time.Now()
+	return &core.ForumMessage{
+		ChannelID:   cmd.ChannelID,
+		UserID:      cmd.UserID,
+		Content:     content,
+		MessageType: core.MessageTypeCommandResponse,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// canManageChannel tells whether userID may change the name or topic of
+// channel: its creator and members with the admin role may.
+func canManageChannel(ctx context.Context, repo ForumUserRepository, channel core.ForumChannel, userID string) (bool, error) {
+	if channel.CreatedBy != "" && channel.CreatedBy == userID {
+		return true, nil
+	}
+	role, err := repo.GetChannelMemberRole(ctx, channel.ID, userID)
+	if err != nil {
+		return false, err
+	}
+	return role == core.ChannelRoleAdmin, nil
+}
+
+type topicCommand struct {
+	repo ForumUserRepository
+}
+
+func (c *topicCommand) Spec() core.ForumCommandSpec {
+	return core.ForumCommandSpec{
+		Name:        "topic",
+		Usage:       "/topic [text]",
+		Description: "Show or change the channel topic",
+		Args: []core.ForumCommandArg{
+			{Name: "topic", Type: core.CommandArgText, Description: "New topic", Variadic: true},
+		},
+	}
+}
+
+func (c *topicCommand) Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error) {
+	channel, err := c.repo.GetChannel(ctx, cmd.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	topic, ok := cmd.Args["topic"]
+	if !ok {
+		if channel.Description == "" {
+			return commandResponse(cmd, "This channel has no topic"), nil
+		}
+		return commandResponse(cmd, "Topic: "+channel.Description), nil
+	}
+
+	allowed, err := canManageChannel(ctx, c.repo, channel, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("only the channel creator or an admin can change the topic")
+	}
+
+	if err := c.repo.UpdateChannelTopic(ctx, cmd.ChannelID, topic); err != nil {
+		return nil, err
+	}
+	return c.repo.CreateSystemMessage(ctx, cmd.ChannelID, core.ForumSystemEvent{
+		Event:    core.SystemEventTopicChanged,
+		ActorID:  cmd.UserID,
+		OldValue: channel.Description,
+		NewValue: topic,
+	})
+}
+
+type inviteCommand struct {
+	repo ForumUserRepository
+}
+
+func (c *inviteCommand) Spec() core.ForumCommandSpec {
+	return core.ForumCommandSpec{
+		Name:        "invite",
+		Usage:       "/invite @user",
+		Description: "Invite a user to this channel",
+		Args: []core.ForumCommandArg{
+			{Name: "user", Type: core.CommandArgUser, Description: "Username to invite", Required: true},
+		},
+	}
+}
+
+func (c *inviteCommand) Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error) {
+	channel, err := c.repo.GetChannel(ctx, cmd.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel.IsDirectMessage {
+		return nil, fmt.Errorf("cannot invite users to a direct message")
+	}
+	if channel.DepartmentID != "" {
+		return nil, fmt.Errorf("department channel members follow the HR records and cannot be invited")
+	}
+	allowed, err := canManageChannel(ctx, c.repo, channel, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("only the channel creator or an admin can invite users")
+	}
+
+	invitee, err := c.repo.GetByUsername(ctx, cmd.Args["user"])
+	if err != nil {
+		return nil, fmt.Errorf("user @%s not found", cmd.Args["user"])
+	}
+
+	isMember, err := c.repo.IsChannelMember(ctx, cmd.ChannelID, invitee.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return commandResponse(cmd, "@"+invitee.Username+" is already a member"), nil
+	}
+
+	if err := c.repo.AddChannelMember(ctx, cmd.ChannelID, invitee.ID, core.ChannelRoleMember); err != nil {
+		return nil, err
+	}
+	return c.repo.CreateSystemMessage(ctx, cmd.ChannelID, core.ForumSystemEvent{
+		Event:    core.SystemEventMemberJoined,
+		ActorID:  cmd.UserID,
+		TargetID: invitee.ID,
+	})
+}
+
+type leaveCommand struct {
+	repo ForumUserRepository
+}
+
+func (c *leaveCommand) Spec() core.ForumCommandSpec {
+	return core.ForumCommandSpec{
+		Name:        "leave",
+		Usage:       "/leave",
+		Description: "Leave this channel",
+		Args:        []core.ForumCommandArg{},
+	}
+}
+
+func (c *leaveCommand) Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error) {
+	channel, err := c.repo.GetChannel(ctx, cmd.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel.IsDirectMessage {
+		return nil, fmt.Errorf("cannot leave a direct message")
+	}
+
+	if err := c.repo.RemoveChannelMember(ctx, cmd.ChannelID, cmd.UserID); err != nil {
+		return nil, err
+	}
+	if _, err := c.repo.CreateSystemMessage(ctx, cmd.ChannelID, core.ForumSystemEvent{
+		Event:   core.SystemEventMemberLeft,
+		ActorID: cmd.UserID,
+	}); err != nil {
+		return nil, err
+	}
+	return commandResponse(cmd, "You left "+channel.Name), nil
+}
+
+type remindCommand struct {
+	repo ForumUserRepository
+}
+
+func (c *remindCommand) Spec() core.ForumCommandSpec {
+	return core.ForumCommandSpec{
+		Name:        "remind",
+		Usage:       "/remind <duration> <text>",
+		Description: "Post a reminder to this channel after a delay",
+		Args: []core.ForumCommandArg{
+			{Name: "in", Type: core.CommandArgDuration, Description: "Delay such as 10m, 2h or 1d", Required: true, Suggestions: []string{"10m", "30m", "1h", "1d"}},
+			{Name: "text", Type: core.CommandArgText, Description: "Reminder text", Required: true, Variadic: true},
+		},
+	}
+}
+
+func (c *remindCommand) Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error) {
+	delay, err := parseReminderDelay(cmd.Args["in"])
+	if err != nil {
+		return nil, err
+	}
+	if delay <= 0 || delay > 30*24*time.Hour {
+		return nil, fmt.Errorf("reminder delay must be between 1s and 30d")
+	}
+
+	reminder := &core.ForumReminder{
+		ChannelID: cmd.ChannelID,
+		UserID:    cmd.UserID,
+		Content:   cmd.Args["text"],
+		RemindAt:  time.Now().Add(delay),
+	}
+	if err := c.repo.CreateReminder(ctx, reminder); err != nil {
+		return nil, err
+	}
+	return commandResponse(cmd, "Reminder set for "+reminder.RemindAt.Format(time.RFC3339)), nil
+}
+
+type meCommand struct {
+	repo ForumUserRepository
+}
+
+func (c *meCommand) Spec() core.ForumCommandSpec {
+	return core.ForumCommandSpec{
+		Name:        "me",
+		Usage:       "/me <action>",
+		Description: "Post an action message",
+		Args: []core.ForumCommandArg{
+			{Name: "action", Type: core.CommandArgText, Description: "What you are doing", Required: true, Variadic: true},
+		},
+	}
+}
+
+func (c *meCommand) Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error) {
+	return c.repo.CreateMessageOfType(ctx, cmd.ChannelID, cmd.UserID, cmd.Args["action"], core.MessageTypeAction, cmd.ParentMessageID)
+}
+
+type shrugCommand struct {
+	repo ForumUserRepository
+}
+
+func (c *shrugCommand) Spec() core.ForumCommandSpec {
+	return core.ForumCommandSpec{
+		Name:        "shrug",
+		Usage:       "/shrug [text]",
+		Description: `Append ¯\_(ツ)_/¯ to your message`,
+		Args: []core.ForumCommandArg{
+			{Name: "text", Type: core.CommandArgText, Description: "Message text", Variadic: true},
+		},
+	}
+}
+
+func (c *shrugCommand) Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error) {
+	content := strings.TrimSpace(cmd.Args["text"] + ` ¯\_(ツ)_/¯`)
+	return c.repo.CreateMessageOfType(ctx, cmd.ChannelID, cmd.UserID, content, core.MessageTypeText, cmd.ParentMessageID)
+}
+
+type cryptoCommand struct {
+	repo       ForumUserRepository
+	cryptoRepo CryptoRepository
+}
+
+func (c *cryptoCommand) Spec() core.ForumCommandSpec {
+	return core.ForumCommandSpec{
+		Name:        "crypto",
+		Usage:       "/crypto <symbol>",
+		Description: "Quote the latest price of a cryptocurrency",
+		Args: []core.ForumCommandArg{
+			{Name: "symbol", Type: core.CommandArgCrypto, Description: "Symbol or name, e.g. BTC", Required: true},
+		},
+	}
+}
+
+func (c *cryptoCommand) Execute(ctx context.Context, cmd ForumCommandContext) (*core.ForumMessage, error) {
+	symbol := cmd.Args["symbol"]
+	name := symbol
+
+	known, err := c.cryptoRepo.ListInitial(ctx, 100)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range known {
+		if strings.EqualFold(k.Initial, symbol) || strings.EqualFold(k.Name, symbol) {
+			name = k.Name
+			break
+		}
+	}
+
+	latest, err := c.cryptoRepo.GetLatestByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("no price found for %s", symbol)
+	}
+
+	content := fmt.Sprintf("%s (%s): %.4f (%+.2f%%)", latest.Initial, latest.Name, latest.CurrentValue, latest.Percent)
+	return c.repo.CreateMessageOfType(ctx, cmd.ChannelID, cmd.UserID, content, core.MessageTypeCommand, cmd.ParentMessageID)
+}
+
+func registerBuiltinCommands(registry *ForumCommandRegistry, repo ForumUserRepository, cryptoRepo CryptoRepository) {
+	builtins := []ForumCommand{
+		&topicCommand{repo: repo},
+		&inviteCommand{repo: repo},
+		&leaveCommand{repo: repo},
+		&remindCommand{repo: repo},
+		&meCommand{repo: repo},
+		&shrugCommand{repo: repo},
+		&cryptoCommand{repo: repo, cryptoRepo: cryptoRepo},
+	}
+	for _, cmd := range builtins {
+		if err := registry.Register(cmd); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"golang.org/x/exp/slog"
+)
+
+const (
+	forumEventPollInterval = 3 * time.Second
+	forumEventBufferSize   = 256
+	// forumEventRetention is how long a user's log outlives their last
+	// subscriber, so a reconnect can still replay from Last-Event-ID.
+	forumEventRetention = 5 * time.Minute
+)
+
+type ForumEventSource interface {
+	GetUnreadCount(ctx context.Context, userID string) (map[string]int, error)
+	GetMentionCounts(ctx context.Context, userID string) (map[string]int, error)
+	GetDirectMessagePartners(ctx context.Context, userID string) ([]core.ForumUser, error)
+}
+
+// ForumEventStream polls counters per subscribed user and turns changes
+// into numbered events. Event IDs have the form "<epoch>-<seq>", where the
+// epoch names the process and the user's log, so a client reconnecting after
+// a restart or after its log was evicted gets a fresh snapshot instead of a
+// bad replay.
+type ForumEventStream struct {
+	source   ForumEventSource
+	interval time.Duration
+	epoch    string
+	logCount uint64
+
+	mu   sync.Mutex
+	logs map[string]*forumEventLog
+}
+
+type forumEventState struct {
+	unread   map[string]int
+	mentions map[string]int
+	presence map[string]core.ForumPresenceChange
+}
+
+type forumEventLog struct {
+	userID      string
+	epoch       string
+	seq         uint64
+	events      []core.ForumEvent
+	state       *forumEventState
+	subscribers map[chan core.ForumEvent]struct{}
+	stop        context.CancelFunc
+	// evict drops the log once it has had no subscribers for
+	// forumEventRetention.
+	evict *time.Timer
+}
+
+func NewForumEventStream(source ForumEventSource) *ForumEventStream {
+	return &ForumEventStream{
+		source:   source,
+		interval: forumEventPollInterval,
+		epoch:    strconv.FormatInt(time.Now().Unix(), 36),
+		logs:     make(map[string]*forumEventLog),
+	}
+}
+
+func (s *ForumEventStream) Subscribe(
+	ctx context.Context,
+	userID, lastEventID string,
+) (*core.ForumEventSubscription, error) {
+	fresh, err := s.loadState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, ok := s.logs[userID]
+	if !ok {
+		s.logCount++
+		log = &forumEventLog{
+			userID:      userID,
+			epoch:       s.epoch + "." + strconv.FormatUint(s.logCount, 36),
+			subscribers: make(map[chan core.ForumEvent]struct{}),
+		}
+		s.logs[userID] = log
+	}
+	if log.evict != nil {
+		log.evict.Stop()
+		log.evict = nil
+	}
+	s.apply(log, fresh)
+
+	var replay []core.ForumEvent
+	if events, ok := s.replaySince(log, lastEventID); ok {
+		replay = events
+	} else {
+		replay = []core.ForumEvent{s.snapshot(log)}
+	}
+
+	ch := make(chan core.ForumEvent, 64)
+	log.subscribers[ch] = struct{}{}
+
+	if log.stop == nil {
+		pollCtx, cancel := context.WithCancel(context.Background())
+		log.stop = cancel
+		go s.poll(pollCtx, userID, log)
+	}
+
+	return &core.ForumEventSubscription{
+		Replay: replay,
+		Events: ch,
+		Close:  func() { s.unsubscribe(log, ch) },
+	}, nil
+}
+
+func (s *ForumEventStream) unsubscribe(log *forumEventLog, ch chan core.ForumEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := log.subscribers[ch]; ok {
+		delete(log.subscribers, ch)
+		close(ch)
+	}
+	if len(log.subscribers) == 0 && log.stop != nil {
+		log.stop()
+		log.stop = nil
+		log.evict = time.AfterFunc(forumEventRetention, func() { s.evict(log) })
+	}
+}
+
+// evict forgets log unless someone subscribed again in the meantime.
+func (s *ForumEventStream) evict(log *forumEventLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(log.subscribers) == 0 && s.logs[log.userID] == log {
+		delete(s.logs, log.userID)
+	}
+}
+
+func (s *ForumEventStream) poll(ctx context.Context, userID string, log *forumEventLog) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fresh, err := s.loadState(ctx, userID)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error("failed to poll forum events", "userID", userID, "error", err)
+				}
+				continue
+			}
+			s.mu.Lock()
+			s.apply(log, fresh)
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *ForumEventStream) loadState(ctx context.Context, userID string) (*forumEventState, error) {
+	unread, err := s.source.GetUnreadCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := s.source.GetMentionCounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	partners, err := s.source.GetDirectMessagePartners(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	presence := make(map[string]core.ForumPresenceChange, len(partners))
+	for _, p := range partners {
+		presence[p.ID] = core.ForumPresenceChange{
+			UserID:   p.ID,
+			Username: p.Username,
+			IsOnline: p.IsOnline,
+			LastSeen: p.LastSeen,
+		}
+	}
+
+	return &forumEventState{unread: unread, mentions: mentions, presence: presence}, nil
+}
+
+// apply diffs fresh against the last known state and publishes one event per
+// change. Must be called with s.mu held.
+func (s *ForumEventStream) apply(log *forumEventLog, fresh *forumEventState) {
+	if log.state == nil {
+		log.state = fresh
+		return
+	}
+
+	for _, change := range diffCounters(log.state.unread, fresh.unread) {
+		s.publish(log, core.ForumEventUnread, change)
+	}
+	for _, change := range diffCounters(log.state.mentions, fresh.mentions) {
+		s.publish(log, core.ForumEventMentions, change)
+	}
+	for id, p := range fresh.presence {
+		if old, ok := log.state.presence[id]; !ok || old.IsOnline != p.IsOnline {
+			s.publish(log, core.ForumEventPresence, p)
+		}
+	}
+
+	log.state = fresh
+}
+
+func (s *ForumEventStream) publish(log *forumEventLog, eventType string, data any) {
+	log.seq++
+	event := core.ForumEvent{
+		ID:   s.eventID(log, log.seq),
+		Type: eventType,
+		Data: data,
+	}
+
+	log.events = append(log.events, event)
+	if len(log.events) > forumEventBufferSize {
+		log.events = log.events[len(log.events)-forumEventBufferSize:]
+	}
+
+	for ch := range log.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// A slow client is dropped; it reconnects with Last-Event-ID and
+			// catches up from the buffer.
+			delete(log.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *ForumEventStream) replaySince(log *forumEventLog, lastEventID string) ([]core.ForumEvent, bool) {
+	epoch, seqText, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != log.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > log.seq {
+		return nil, false
+	}
+
+	oldest := log.seq - uint64(len(log.events))
+	if seq < oldest {
+		return nil, false
+	}
+	return append([]core.ForumEvent(nil), log.events[len(log.events)-int(log.seq-seq):]...), true
+}
+
+func (s *ForumEventStream) snapshot(log *forumEventLog) core.ForumEvent {
+	presence := make([]core.ForumPresenceChange, 0, len(log.state.presence))
+	for _, p := range log.state.presence {
+		presence = append(presence, p)
+	}
+	sort.Slice(presence, func(i, j int) bool { return presence[i].Username < presence[j].Username })
+
+	return core.ForumEvent{
+		ID:   s.eventID(log, log.seq),
+		Type: core.ForumEventSnapshot,
+		Data: core.ForumCounterSnapshot{
+			Unread:   log.state.unread,
+			Mentions: log.state.mentions,
+			Presence: presence,
+		},
+	}
+}
+
+func (s *ForumEventStream) eventID(log *forumEventLog, seq uint64) string {
+	return fmt.Sprintf("%s-%d", log.epoch, seq)
+}
+
+func diffCounters(old, fresh map[string]int) []core.ForumCounterChange {
+	var changes []core.ForumCounterChange
+	for channelID, count := range fresh {
+		if old[channelID] != count {
+			changes = append(changes, core.ForumCounterChange{ChannelID: channelID, Count: count})
+		}
+	}
+	for channelID := range old {
+		if _, ok := fresh[channelID]; !ok {
+			changes = append(changes, core.ForumCounterChange{ChannelID: channelID, Count: 0})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ChannelID < changes[j].ChannelID })
+	return changes
+}
@@ -2,13 +2,24 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
 
 	"multi-processing-backend/internal/core"
+
+	"golang.org/x/exp/slog"
 )
 
 type ForumUserRepository interface {
 	GetByID(ctx context.Context, id string) (*core.ForumUser, error)
 	GetByEmail(ctx context.Context, id string) (*core.ForumUser, error)
+	GetByUsername(ctx context.Context, username string) (*core.ForumUser, error)
 	Create(ctx context.Context, user *core.ForumUser) error
 	Update(ctx context.Context, user *core.ForumUser) error
 	IsChannelMember(ctx context.Context, channelID, userID string) (bool, error)
@@ -16,27 +27,77 @@ type ForumUserRepository interface {
 	GetUserChannels(ctx context.Context, userID string) ([]core.ForumChannel, error)
 	GetChannelMessages(ctx context.Context, channelID, userID string) ([]core.ForumMessage, error)
 	CreateMessage(ctx context.Context, channelID, userID, content, parentMessageID string) (*core.ForumMessage, error)
+	CreateMessageOfType(ctx context.Context, channelID, userID, content, messageType, parentMessageID string) (*core.ForumMessage, error)
 	MarkMessagesAsRead(ctx context.Context, channelID, userID string) error
 	GetPublicChannelMessages(ctx context.Context, page, limit int) (*core.ForumChannelMessages, error)
 
-	GetOrCreateDirectMessageChannel(ctx context.Context, user1ID, user2ID string) (string, error)
-	GetOnlineUsers(ctx context.Context, userID string) ([]core.ForumUser, error)
-	SearchUsers(ctx context.Context, query string, currentUserID string) ([]core.ForumUser, error)
+	GetOrCreateDirectMessageChannel(ctx context.Context, user1ID, user2ID string) (string, bool, error)
+	GetOnlineUsers(ctx context.Context, userID string) ([]core.ForumMember, error)
+	SearchUsers(ctx context.Context, query string, currentUserID string) ([]core.ForumMember, error)
 	GetUnreadCount(ctx context.Context, userID string) (map[string]int, error)
+	GetMentionCounts(ctx context.Context, userID string) (map[string]int, error)
+	GetDirectMessagePartners(ctx context.Context, userID string) ([]core.ForumUser, error)
 	UpdateUserPresence(ctx context.Context, userID string, isOnline bool) error
-	GetChannelMembers(ctx context.Context, channelID string) ([]core.ForumUser, error)
+	GetChannelMembers(ctx context.Context, channelID string) ([]core.ForumMember, error)
 	EditMessage(ctx context.Context, messageID, userID, newContent string) error
 	DeleteMessage(ctx context.Context, messageID, userID string) error
 	AddReaction(ctx context.Context, messageID, userID, emoji string) error
 	RemoveReaction(ctx context.Context, messageID, userID, emoji string) error
+
+	GetChannel(ctx context.Context, channelID string) (core.ForumChannel, error)
+	UpdateChannelTopic(ctx context.Context, channelID, topic string) error
+	AddChannelMember(ctx context.Context, channelID, userID, role string) error
+	GetChannelMemberRole(ctx context.Context, channelID, userID string) (string, error)
+	RemoveChannelMember(ctx context.Context, channelID, userID string) error
+	CreateReminder(ctx context.Context, reminder *core.ForumReminder) error
+	ListDueReminders(ctx context.Context, limit int) ([]core.ForumReminder, error)
+	MarkReminderDelivered(ctx context.Context, reminderID string) error
+
+	CreateSystemMessage(ctx context.Context, channelID string, event core.ForumSystemEvent) (*core.ForumMessage, error)
+	RenameChannel(ctx context.Context, channelID, name string) error
+	PinMessage(ctx context.Context, messageID, userID string) (string, error)
+	UnpinMessage(ctx context.Context, messageID, userID string) (string, error)
+	GetChannelPins(ctx context.Context, channelID string) ([]core.PinnedMessage, error)
+
+	GetProfile(ctx context.Context, userID string) (core.ForumUserProfile, error)
+	UpdateProfile(ctx context.Context, userID string, update core.ForumUserProfileUpdate) (core.ForumUserProfile, error)
+	SetStatus(ctx context.Context, userID string, status core.ForumUserStatus) error
+	SetAvatarURL(ctx context.Context, userID, avatarURL string) error
+
+	CreateEmailVerification(ctx context.Context, userID, codeHash string, expiresAt time.Time) error
+	ConfirmEmailVerification(ctx context.Context, userID, codeHash string) error
+	LinkHRUser(ctx context.Context, userID string) (string, error)
+	UnlinkHRUser(ctx context.Context, userID string) error
+
+	ReconcileDepartmentChannels(ctx context.Context) (core.DepartmentChannelSync, error)
 }
 
 type ForumUserService struct {
-	repo ForumUserRepository
+	repo     ForumUserRepository
+	commands *ForumCommandRegistry
+	events   *ForumEventStream
+	avatars  *AvatarStore
+	mailer   Mailer
+}
+
+func NewForumUserService(repo ForumUserRepository, cryptoRepo CryptoRepository, avatars *AvatarStore, mailer Mailer) *ForumUserService {
+	commands := NewForumCommandRegistry()
+	registerBuiltinCommands(commands, repo, cryptoRepo)
+	return &ForumUserService{
+		repo:     repo,
+		commands: commands,
+		events:   NewForumEventStream(repo),
+		avatars:  avatars,
+		mailer:   mailer,
+	}
+}
+
+func (s *ForumUserService) RegisterCommand(cmd ForumCommand) error {
+	return s.commands.Register(cmd)
 }
 
-func NewForumUserService(repo ForumUserRepository) *ForumUserService {
-	return &ForumUserService{repo: repo}
+func (s *ForumUserService) ListCommands() []core.ForumCommandSpec {
+	return s.commands.Specs()
 }
 
 func (s *ForumUserService) GetByID(ctx context.Context, id string) (*core.ForumUser, error) {
@@ -72,7 +133,54 @@ func (s *ForumUserService) GetChannelMessages(ctx context.Context, channelID, us
 }
 
 func (s *ForumUserService) CreateMessage(ctx context.Context, channelID, userID, content, parentMessageID string) (*core.ForumMessage, error) {
-	return s.repo.CreateMessage(ctx, channelID, userID, content, parentMessageID)
+	if !IsCommand(content) {
+		content = strings.TrimPrefix(content, "/")
+		return s.repo.CreateMessage(ctx, channelID, userID, content, parentMessageID)
+	}
+
+	isMember, err := s.repo.IsChannelMember(ctx, channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	return s.commands.Dispatch(ctx, channelID, userID, content, parentMessageID)
+}
+
+func (s *ForumUserService) StartReminderTicker(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("forum reminder ticker stopped")
+			return
+		case <-ticker.C:
+			s.deliverDueReminders(ctx)
+		}
+	}
+}
+
+func (s *ForumUserService) deliverDueReminders(ctx context.Context) {
+	reminders, err := s.repo.ListDueReminders(ctx, 50)
+	if err != nil {
+		slog.Error("failed to load due reminders", "error", err)
+		return
+	}
+
+	for _, r := range reminders {
+		_, err := s.repo.CreateMessageOfType(ctx, r.ChannelID, r.UserID, "Reminder: "+r.Content, core.MessageTypeReminder, "")
+		if err != nil {
+			slog.Error("failed to deliver reminder", "id", r.ID, "error", err)
+			continue
+		}
+		if err := s.repo.MarkReminderDelivered(ctx, r.ID); err != nil {
+			slog.Error("failed to mark reminder delivered", "id", r.ID, "error", err)
+		}
+	}
 }
 
 func (s *ForumUserService) MarkMessagesAsRead(ctx context.Context, channelID, userID string) error {
@@ -84,14 +192,115 @@ func (s *ForumUserService) GetPublicChannelMessages(ctx context.Context, page, l
 }
 
 func (s *ForumUserService) GetOrCreateDirectMessageChannel(ctx context.Context, user1ID, user2ID string) (string, error) {
-	return s.repo.GetOrCreateDirectMessageChannel(ctx, user1ID, user2ID)
+	channelID, created, err := s.repo.GetOrCreateDirectMessageChannel(ctx, user1ID, user2ID)
+	if err != nil {
+		return "", err
+	}
+
+	if created {
+		s.emitSystemMessage(ctx, channelID, core.ForumSystemEvent{
+			Event:    core.SystemEventDirectCreated,
+			ActorID:  user1ID,
+			TargetID: user2ID,
+		})
+	}
+	return channelID, nil
+}
+
+func (s *ForumUserService) RenameChannel(ctx context.Context, channelID, userID, name string) (core.ForumChannel, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return core.ForumChannel{}, fmt.Errorf("channel name required")
+	}
+
+	channel, err := s.repo.GetChannel(ctx, channelID)
+	if err != nil {
+		return core.ForumChannel{}, err
+	}
+	if channel.IsDirectMessage {
+		return core.ForumChannel{}, fmt.Errorf("direct messages cannot be renamed")
+	}
+
+	allowed, err := canManageChannel(ctx, s.repo, channel, userID)
+	if err != nil {
+		return core.ForumChannel{}, err
+	}
+	if !allowed {
+		return core.ForumChannel{}, fmt.Errorf("access denied")
+	}
+
+	if err := s.repo.RenameChannel(ctx, channelID, name); err != nil {
+		return core.ForumChannel{}, err
+	}
+
+	s.emitSystemMessage(ctx, channelID, core.ForumSystemEvent{
+		Event:    core.SystemEventChannelRenamed,
+		ActorID:  userID,
+		OldValue: channel.Name,
+		NewValue: name,
+	})
+
+	channel.Name = name
+	return channel, nil
+}
+
+func (s *ForumUserService) PinMessage(ctx context.Context, messageID, userID string) error {
+	channelID, err := s.repo.PinMessage(ctx, messageID, userID)
+	if err != nil {
+		return err
+	}
+
+	s.emitSystemMessage(ctx, channelID, core.ForumSystemEvent{
+		Event:     core.SystemEventMessagePinned,
+		ActorID:   userID,
+		MessageID: messageID,
+	})
+	return nil
+}
+
+func (s *ForumUserService) UnpinMessage(ctx context.Context, messageID, userID string) error {
+	channelID, err := s.repo.UnpinMessage(ctx, messageID, userID)
+	if err != nil {
+		return err
+	}
+
+	s.emitSystemMessage(ctx, channelID, core.ForumSystemEvent{
+		Event:     core.SystemEventMessageUnpinned,
+		ActorID:   userID,
+		MessageID: messageID,
+	})
+	return nil
+}
+
+// GetChannelPins returns the pinned messages of a channel to its members,
+// the same readers GetChannelMessages allows.
+func (s *ForumUserService) GetChannelPins(ctx context.Context, channelID, userID string) ([]core.PinnedMessage, error) {
+	isMember, err := s.repo.IsChannelMember(ctx, channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, fmt.Errorf("access denied")
+	}
+	return s.repo.GetChannelPins(ctx, channelID)
+}
+
+// emitSystemMessage records a channel event. The triggering action has
+// already succeeded, so failures are logged rather than returned.
+func (s *ForumUserService) emitSystemMessage(ctx context.Context, channelID string, event core.ForumSystemEvent) *core.ForumMessage {
+	msg, err := s.repo.CreateSystemMessage(ctx, channelID, event)
+	if err != nil {
+		slog.Error("failed to create system message", "channelID", channelID, "event", event.Event, "error", err)
+		return nil
+	}
+	return msg
 }
 
-func (s *ForumUserService) GetOnlineUsers(ctx context.Context, userID string) ([]core.ForumUser, error) {
+func (s *ForumUserService) GetOnlineUsers(ctx context.Context, userID string) ([]core.ForumMember, error) {
 	return s.repo.GetOnlineUsers(ctx, userID)
 }
 
-func (s *ForumUserService) SearchUsers(ctx context.Context, query string, currentUserID string) ([]core.ForumUser, error) {
+func (s *ForumUserService) SearchUsers(ctx context.Context, query string, currentUserID string) ([]core.ForumMember, error) {
 	return s.repo.SearchUsers(ctx, query, currentUserID)
 }
 
@@ -99,11 +308,18 @@ func (s *ForumUserService) GetUnreadCount(ctx context.Context, userID string) (m
 	return s.repo.GetUnreadCount(ctx, userID)
 }
 
+func (s *ForumUserService) SubscribeEvents(ctx context.Context, userID, lastEventID string) (*core.ForumEventSubscription, error) {
+	if _, err := s.repo.GetByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return s.events.Subscribe(ctx, userID, lastEventID)
+}
+
 func (s *ForumUserService) UpdateUserPresence(ctx context.Context, userID string, isOnline bool) error {
 	return s.repo.UpdateUserPresence(ctx, userID, isOnline)
 }
 
-func (s *ForumUserService) GetChannelMembers(ctx context.Context, channelID string) ([]core.ForumUser, error) {
+func (s *ForumUserService) GetChannelMembers(ctx context.Context, channelID string) ([]core.ForumMember, error) {
 	return s.repo.GetChannelMembers(ctx, channelID)
 }
 
@@ -122,3 +338,196 @@ func (s *ForumUserService) AddReaction(ctx context.Context, messageID, userID, e
 func (s *ForumUserService) RemoveReaction(ctx context.Context, messageID, userID, emoji string) error {
 	return s.repo.RemoveReaction(ctx, messageID, userID, emoji)
 }
+
+func (s *ForumUserService) GetProfile(ctx context.Context, userID string) (core.ForumUserProfile, error) {
+	return s.repo.GetProfile(ctx, userID)
+}
+
+func (s *ForumUserService) UpdateProfile(
+	ctx context.Context,
+	userID string,
+	update core.ForumUserProfileUpdate,
+) (core.ForumUserProfile, error) {
+	if update.DisplayName != nil {
+		name := strings.TrimSpace(*update.DisplayName)
+		if name == "" || len(name) > 64 {
+			return core.ForumUserProfile{}, fmt.Errorf("display_name must be between 1 and 64 characters")
+		}
+		update.DisplayName = &name
+	}
+	if update.Bio != nil && len(*update.Bio) > 500 {
+		return core.ForumUserProfile{}, fmt.Errorf("bio must be at most 500 characters")
+	}
+	if update.Pronouns != nil && len(*update.Pronouns) > 40 {
+		return core.ForumUserProfile{}, fmt.Errorf("pronouns must be at most 40 characters")
+	}
+	if update.Timezone != nil {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil || *update.Timezone == "" {
+			return core.ForumUserProfile{}, fmt.Errorf("unknown timezone %q", *update.Timezone)
+		}
+	}
+
+	return s.repo.UpdateProfile(ctx, userID, update)
+}
+
+func (s *ForumUserService) SetStatus(
+	ctx context.Context,
+	userID string,
+	update core.ForumUserStatusUpdate,
+) (core.ForumUserProfile, error) {
+	if len(update.Text) > 100 {
+		return core.ForumUserProfile{}, fmt.Errorf("status text must be at most 100 characters")
+	}
+
+	status := core.ForumUserStatus{
+		Text:      strings.TrimSpace(update.Text),
+		Emoji:     strings.TrimSpace(update.Emoji),
+		ExpiresAt: update.ExpiresAt,
+	}
+	if update.ExpiresIn != "" {
+		d, err := time.ParseDuration(update.ExpiresIn)
+		if err != nil || d <= 0 {
+			return core.ForumUserProfile{}, fmt.Errorf("invalid expires_in %q", update.ExpiresIn)
+		}
+		expiresAt := time.Now().Add(d)
+		status.ExpiresAt = &expiresAt
+	}
+	if status.ExpiresAt != nil && !status.ExpiresAt.After(time.Now()) {
+		return core.ForumUserProfile{}, fmt.Errorf("expires_at must be in the future")
+	}
+
+	if err := s.repo.SetStatus(ctx, userID, status); err != nil {
+		return core.ForumUserProfile{}, err
+	}
+	return s.repo.GetProfile(ctx, userID)
+}
+
+func (s *ForumUserService) ClearStatus(ctx context.Context, userID string) error {
+	return s.repo.SetStatus(ctx, userID, core.ForumUserStatus{})
+}
+
+func (s *ForumUserService) UploadAvatar(
+	ctx context.Context,
+	userID string,
+	r io.Reader,
+) (core.ForumUserProfile, error) {
+	if _, err := s.repo.GetProfile(ctx, userID); err != nil {
+		return core.ForumUserProfile{}, err
+	}
+
+	url, err := s.avatars.Save(userID, r)
+	if err != nil {
+		return core.ForumUserProfile{}, err
+	}
+	if err := s.repo.SetAvatarURL(ctx, userID, url); err != nil {
+		return core.ForumUserProfile{}, err
+	}
+	return s.repo.GetProfile(ctx, userID)
+}
+
+const emailVerificationTTL = 15 * time.Minute
+
+// RequestEmailVerification issues a one-time code and mails it to the
+// account's email.
+func (s *ForumUserService) RequestEmailVerification(ctx context.Context, userID string) error {
+	profile, err := s.repo.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if profile.EmailVerified {
+		return fmt.Errorf("email already verified")
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found")
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	if err := s.repo.CreateEmailVerification(ctx, userID, hashVerificationCode(code), time.Now().Add(emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(emailVerificationTTL.Minutes()))
+	if err := s.mailer.Send(ctx, user.Email, "Verify your email address", body); err != nil {
+		return fmt.Errorf("could not send verification email: %w", err)
+	}
+	slog.Info("forum email verification code issued", "userID", userID)
+	return nil
+}
+
+// ConfirmEmail marks the email as verified and links the matching employee
+// record when there is one.
+func (s *ForumUserService) ConfirmEmail(ctx context.Context, userID, code string) (core.ForumUserProfile, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return core.ForumUserProfile{}, fmt.Errorf("code is required")
+	}
+
+	if err := s.repo.ConfirmEmailVerification(ctx, userID, hashVerificationCode(code)); err != nil {
+		return core.ForumUserProfile{}, err
+	}
+
+	if _, err := s.repo.LinkHRUser(ctx, userID); err != nil && !strings.Contains(err.Error(), "not found") {
+		slog.Warn("failed to link forum user to employee record", "userID", userID, "error", err)
+	}
+
+	return s.repo.GetProfile(ctx, userID)
+}
+
+func (s *ForumUserService) LinkHRUser(ctx context.Context, userID string) (core.ForumUserProfile, error) {
+	if _, err := s.repo.LinkHRUser(ctx, userID); err != nil {
+		return core.ForumUserProfile{}, err
+	}
+	return s.repo.GetProfile(ctx, userID)
+}
+
+func (s *ForumUserService) UnlinkHRUser(ctx context.Context, userID string) error {
+	return s.repo.UnlinkHRUser(ctx, userID)
+}
+
+func hashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+const departmentChannelSyncInterval = 10 * time.Minute
+
+// StartDepartmentChannelSync reconciles department channels once at startup
+// and then periodically, so drift from bulk changes is repaired.
+func (s *ForumUserService) StartDepartmentChannelSync(ctx context.Context) {
+	s.syncDepartmentChannels(ctx)
+
+	ticker := time.NewTicker(departmentChannelSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("department channel sync stopped")
+			return
+		case <-ticker.C:
+			s.syncDepartmentChannels(ctx)
+		}
+	}
+}
+
+func (s *ForumUserService) syncDepartmentChannels(ctx context.Context) {
+	result, err := s.repo.ReconcileDepartmentChannels(ctx)
+	if err != nil {
+		slog.Error("failed to reconcile department channels", "error", err)
+		return
+	}
+	if result.Joined > 0 || result.Left > 0 {
+		slog.Info("reconciled department channels", "channels", result.Channels, "joined", result.Joined, "left", result.Left)
+	}
+}
+
+func (s *ForumUserService) ReconcileDepartmentChannels(ctx context.Context) (core.DepartmentChannelSync, error) {
+	return s.repo.ReconcileDepartmentChannels(ctx)
+}
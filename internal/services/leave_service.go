@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/shopspring/decimal"
+)
+
+// leaveCalendarMaxDays bounds the window of a department absence calendar.
+const leaveCalendarMaxDays = 366
+
+type LeaveRepository interface {
+	ListTypes(ctx context.Context) ([]core.LeaveType, error)
+	CreateRequest(ctx context.Context, req core.LeaveRequest) (core.LeaveRequest, error)
+	GetRequest(ctx context.Context, id string) (core.LeaveRequest, error)
+	ListRequests(ctx context.Context, userID, status string, page, limit int) ([]core.LeaveRequest, int64, error)
+	TransitionRequest(ctx context.Context, id string, from []string, to string, decision core.LeaveDecision) (core.LeaveRequest, error)
+	SetEntitlement(ctx context.Context, userID, typeCode string, in core.LeaveEntitlementUpdate) error
+	Balances(ctx context.Context, userID string, year int) ([]core.LeaveBalance, error)
+	Calendar(ctx context.Context, departmentID string, from, to time.Time, includePending bool) ([]core.Absence, error)
+}
+
+type LeaveService struct {
+	repo LeaveRepository
+}
+
+func NewLeaveService(repo LeaveRepository) *LeaveService {
+	return &LeaveService{repo: repo}
+}
+
+func (s *LeaveService) ListTypes(ctx context.Context) ([]core.LeaveType, error) {
+	return s.repo.ListTypes(ctx)
+}
+
+// CreateRequest files a pending request after working out how many working
+// days it takes. Requests may not cross the turn of the year so that each
+// one counts against a single year's entitlement.
+func (s *LeaveService) CreateRequest(ctx context.Context, in core.LeaveRequestCreate) (core.LeaveRequest, error) {
+	start, end := dateOnly(in.StartDate), dateOnly(in.EndDate)
+	if end.Before(start) {
+		return core.LeaveRequest{}, fmt.Errorf("end_date must not be before start_date")
+	}
+	if start.Year() != end.Year() {
+		return core.LeaveRequest{}, fmt.Errorf("leave must not span the turn of the year, file one request per year")
+	}
+	if start.Equal(end) && in.HalfDayStart && in.HalfDayEnd {
+		return core.LeaveRequest{}, fmt.Errorf("a one-day request must be either half_day_start or half_day_end, not both")
+	}
+
+	days := leaveDays(start, end, in.HalfDayStart, in.HalfDayEnd)
+	if !days.IsPositive() {
+		return core.LeaveRequest{}, fmt.Errorf("leave must cover at least one working day")
+	}
+
+	return s.repo.CreateRequest(ctx, core.LeaveRequest{
+		UserID:       in.UserID,
+		LeaveType:    strings.ToLower(strings.TrimSpace(in.LeaveType)),
+		StartDate:    start,
+		EndDate:      end,
+		HalfDayStart: in.HalfDayStart,
+		HalfDayEnd:   in.HalfDayEnd,
+		Days:         days,
+		Reason:       strings.TrimSpace(in.Reason),
+	})
+}
+
+func (s *LeaveService) GetRequest(ctx context.Context, id string) (core.LeaveRequest, error) {
+	return s.repo.GetRequest(ctx, id)
+}
+
+func (s *LeaveService) ListRequests(
+	ctx context.Context,
+	userID, status string,
+	page, limit int,
+) ([]core.LeaveRequest, int64, error) {
+	switch status {
+	case "", core.LeaveStatusPending, core.LeaveStatusApproved, core.LeaveStatusRejected, core.LeaveStatusCancelled:
+	default:
+		return nil, 0, fmt.Errorf("status must be one of pending, approved, rejected or cancelled")
+	}
+	return s.repo.ListRequests(ctx, userID, status, page, limit)
+}
+
+func (s *LeaveService) Approve(ctx context.Context, id string, decision core.LeaveDecision) (core.LeaveRequest, error) {
+	return s.repo.TransitionRequest(ctx, id, []string{core.LeaveStatusPending}, core.LeaveStatusApproved, decision)
+}
+
+func (s *LeaveService) Reject(ctx context.Context, id string, decision core.LeaveDecision) (core.LeaveRequest, error) {
+	return s.repo.TransitionRequest(ctx, id, []string{core.LeaveStatusPending}, core.LeaveStatusRejected, decision)
+}
+
+// Cancel withdraws a request that is pending or already approved.
+func (s *LeaveService) Cancel(ctx context.Context, id string, decision core.LeaveDecision) (core.LeaveRequest, error) {
+	return s.repo.TransitionRequest(ctx, id,
+		[]string{core.LeaveStatusPending, core.LeaveStatusApproved}, core.LeaveStatusCancelled, decision)
+}
+
+func (s *LeaveService) SetEntitlement(
+	ctx context.Context,
+	userID, typeCode string,
+	in core.LeaveEntitlementUpdate,
+) (core.LeaveBalances, error) {
+	if in.Days.IsNegative() {
+		return core.LeaveBalances{}, fmt.Errorf("days must not be negative")
+	}
+	if !in.Days.Mul(decimal.NewFromInt(2)).IsInteger() {
+		return core.LeaveBalances{}, fmt.Errorf("days must be a multiple of 0.5")
+	}
+	if err := s.repo.SetEntitlement(ctx, userID, strings.ToLower(typeCode), in); err != nil {
+		return core.LeaveBalances{}, err
+	}
+	return s.Balances(ctx, userID, in.Year)
+}
+
+// Balances reports the leave of a user for a year; year 0 means the
+// current one.
+func (s *LeaveService) Balances(ctx context.Context, userID string, year int) (core.LeaveBalances, error) {
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	balances, err := s.repo.Balances(ctx, userID, year)
+	if err != nil {
+		return core.LeaveBalances{}, err
+	}
+	return core.LeaveBalances{UserID: userID, Year: year, Balances: balances}, nil
+}
+
+func (s *LeaveService) Calendar(
+	ctx context.Context,
+	departmentID string,
+	from, to time.Time,
+	includePending bool,
+) (core.AbsenceCalendar, error) {
+	from, to = dateOnly(from), dateOnly(to)
+	if to.Before(from) {
+		return core.AbsenceCalendar{}, fmt.Errorf("to must not be before from")
+	}
+	if daysBetween(from, to) > leaveCalendarMaxDays {
+		return core.AbsenceCalendar{}, fmt.Errorf("calendar must not span more than %d days", leaveCalendarMaxDays)
+	}
+
+	absences, err := s.repo.Calendar(ctx, departmentID, from, to, includePending)
+	if err != nil {
+		return core.AbsenceCalendar{}, err
+	}
+	return core.AbsenceCalendar{DepartmentID: departmentID, From: from, To: to, Absences: absences}, nil
+}
+
+// leaveDays counts the weekdays in [start, end], less half a day for each
+// half-day end that falls on a weekday.
+func leaveDays(start, end time.Time, halfDayStart, halfDayEnd bool) decimal.Decimal {
+	half := decimal.NewFromFloat(0.5)
+	var days decimal.Decimal
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if isWeekend(d) {
+			continue
+		}
+		days = days.Add(decimal.NewFromInt(1))
+		if (halfDayStart && d.Equal(start)) || (halfDayEnd && d.Equal(end)) {
+			days = days.Sub(half)
+		}
+	}
+	return days
+}
+
+func isWeekend(d time.Time) bool {
+	return d.Weekday() == time.Saturday || d.Weekday() == time.Sunday
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"multi-processing-backend/internal/core"
+)
+
+// Mailer delivers email to users.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NoMailer stands in until real delivery is configured. Every Send fails
+// with core.ErrMailUnavailable, so callers don't report mail as sent.
+type NoMailer struct{}
+
+func (NoMailer) Send(ctx context.Context, to, subject, body string) error {
+	return core.ErrMailUnavailable
+}
+
+// FileMailer writes every message as a file into a directory, for local
+// development without a mail server.
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+func (m *FileMailer) Send(ctx context.Context, to, subject, body string) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + ".eml"
+	msg := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		to, subject, time.Now().Format(time.RFC1123Z), body)
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(msg), 0o600)
+}
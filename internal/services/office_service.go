@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // validate time zones without relying on the host's zoneinfo
+
+	"multi-processing-backend/internal/core"
+)
+
+// officeWeekdays are the days a hybrid worker can pick, in calendar order.
+var officeWeekdays = []string{"mon", "tue", "wed", "thu", "fri"}
+
+type OfficeRepository interface {
+	List(ctx context.Context) ([]core.Office, error)
+	Create(ctx context.Context, o core.Office) (core.Office, error)
+	Get(ctx context.Context, id string) (core.Office, error)
+	Update(ctx context.Context, id string, o core.Office) (core.Office, error)
+	Delete(ctx context.Context, id string) error
+
+	GetWorkplace(ctx context.Context, userID string) (core.Workplace, error)
+	SetWorkplace(ctx context.Context, w core.Workplace) (core.Workplace, error)
+	Headcount(ctx context.Context, officeID string) ([]core.OfficeHeadcount, error)
+	Members(ctx context.Context, officeID, day string) ([]core.OfficeMember, error)
+}
+
+type OfficeService struct {
+	repo OfficeRepository
+}
+
+func NewOfficeService(repo OfficeRepository) *OfficeService {
+	return &OfficeService{repo: repo}
+}
+
+func (s *OfficeService) List(ctx context.Context) ([]core.Office, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *OfficeService) Create(ctx context.Context, o core.Office) (core.Office, error) {
+	o, err := normalizeOffice(o)
+	if err != nil {
+		return core.Office{}, err
+	}
+	return s.repo.Create(ctx, o)
+}
+
+func (s *OfficeService) Get(ctx context.Context, id string) (core.Office, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *OfficeService) Update(ctx context.Context, id string, update core.OfficeUpdate) (core.Office, error) {
+	o, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return core.Office{}, err
+	}
+	if update.Name != nil {
+		o.Name = *update.Name
+	}
+	if update.Street != nil {
+		o.Street = *update.Street
+	}
+	if update.City != nil {
+		o.City = *update.City
+	}
+	if update.ZipCode != nil {
+		o.ZipCode = *update.ZipCode
+	}
+	if update.Country != nil {
+		o.Country = *update.Country
+	}
+	if update.Timezone != nil {
+		o.Timezone = *update.Timezone
+	}
+	if update.Capacity != nil {
+		o.Capacity = *update.Capacity
+	}
+
+	o, err = normalizeOffice(o)
+	if err != nil {
+		return core.Office{}, err
+	}
+	return s.repo.Update(ctx, id, o)
+}
+
+func (s *OfficeService) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *OfficeService) GetWorkplace(ctx context.Context, userID string) (core.Workplace, error) {
+	return s.repo.GetWorkplace(ctx, userID)
+}
+
+// SetWorkplace assigns a user to an office, to remote work, or to an
+// office on some weekdays.
+func (s *OfficeService) SetWorkplace(ctx context.Context, userID string, in core.WorkplaceUpdate) (core.Workplace, error) {
+	verr := &core.ValidationError{}
+	w := core.Workplace{
+		UserID:     userID,
+		Mode:       strings.ToLower(strings.TrimSpace(in.Mode)),
+		OfficeID:   strings.TrimSpace(in.OfficeID),
+		OfficeDays: []string{},
+	}
+
+	switch w.Mode {
+	case core.WorkplaceRemote:
+		if w.OfficeID != "" {
+			verr.Add("office_id", "must be empty for remote work")
+		}
+	case core.WorkplaceOffice, core.WorkplaceHybrid:
+		if w.OfficeID == "" {
+			verr.Add("office_id", "must be set for office and hybrid work")
+		}
+	default:
+		verr.Add("mode", "must be office, remote or hybrid")
+	}
+
+	if w.Mode == core.WorkplaceHybrid {
+		days, err := normalizeOfficeDays(in.OfficeDays)
+		switch {
+		case err != nil:
+			verr.Add("office_days", err.Error())
+		case len(days) == 0:
+			verr.Add("office_days", "must name at least one weekday for hybrid work")
+		default:
+			w.OfficeDays = days
+		}
+	} else if len(in.OfficeDays) > 0 {
+		verr.Add("office_days", "must only be set for hybrid work")
+	}
+
+	if err := verr.Err(); err != nil {
+		return core.Workplace{}, err
+	}
+	return s.repo.SetWorkplace(ctx, w)
+}
+
+// Headcount compares assignments with capacity for every office.
+func (s *OfficeService) Headcount(ctx context.Context) ([]core.OfficeHeadcount, error) {
+	counts, err := s.repo.Headcount(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range counts {
+		summarizeHeadcount(&counts[i])
+	}
+	return counts, nil
+}
+
+func (s *OfficeService) OfficeHeadcount(ctx context.Context, officeID string) (core.OfficeHeadcount, error) {
+	counts, err := s.repo.Headcount(ctx, officeID)
+	if err != nil {
+		return core.OfficeHeadcount{}, err
+	}
+	if len(counts) == 0 {
+		return core.OfficeHeadcount{}, fmt.Errorf("office not found")
+	}
+	summarizeHeadcount(&counts[0])
+	return counts[0], nil
+}
+
+// Members lists who works at an office; day ("mon" to "fri") narrows it
+// down to who is expected in that day.
+func (s *OfficeService) Members(ctx context.Context, officeID, day string) ([]core.OfficeMember, error) {
+	day = strings.ToLower(strings.TrimSpace(day))
+	if day != "" && !slices.Contains(officeWeekdays, day) {
+		return nil, fmt.Errorf("day must be one of mon, tue, wed, thu or fri")
+	}
+	return s.repo.Members(ctx, officeID, day)
+}
+
+// normalizeOffice validates an office. Its address follows the same country
+// rules as employee addresses.
+func normalizeOffice(o core.Office) (core.Office, error) {
+	verr := &core.ValidationError{}
+
+	o.Name = collapseSpaces(o.Name)
+	if o.Name == "" {
+		verr.Add("name", "must not be empty")
+	}
+
+	addr, err := NormalizeAddress(core.Address{
+		Type:    core.AddressWork,
+		Street:  o.Street,
+		City:    o.City,
+		ZipCode: o.ZipCode,
+		Country: o.Country,
+	})
+	var addrErr *core.ValidationError
+	switch {
+	case errors.As(err, &addrErr):
+		verr.Fields = append(verr.Fields, addrErr.Fields...)
+	case err != nil:
+		return core.Office{}, err
+	default:
+		o.Street, o.City, o.ZipCode, o.Country = addr.Street, addr.City, addr.ZipCode, addr.Country
+	}
+
+	o.Timezone = strings.TrimSpace(o.Timezone)
+	if o.Timezone == "" || o.Timezone == "Local" {
+		verr.Add("timezone", "must be an IANA time zone such as Europe/Berlin")
+	} else if _, err := time.LoadLocation(o.Timezone); err != nil {
+		verr.Add("timezone", "must be an IANA time zone such as Europe/Berlin")
+	}
+
+	if o.Capacity < 0 {
+		verr.Add("capacity", "must not be negative")
+	}
+
+	if err := verr.Err(); err != nil {
+		return core.Office{}, err
+	}
+	return o, nil
+}
+
+// normalizeOfficeDays lower-cases, de-duplicates and sorts weekdays.
+func normalizeOfficeDays(in []string) ([]string, error) {
+	picked := make(map[string]bool, len(in))
+	for _, d := range in {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if !slices.Contains(officeWeekdays, d) {
+			return nil, fmt.Errorf("must only contain mon, tue, wed, thu or fri")
+		}
+		picked[d] = true
+	}
+
+	days := []string{}
+	for _, d := range officeWeekdays {
+		if picked[d] {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+func summarizeHeadcount(h *core.OfficeHeadcount) {
+	for _, d := range h.Daily {
+		h.Peak = max(h.Peak, d.Expected)
+	}
+	if h.Capacity > 0 {
+		h.Utilization = math.Round(float64(h.Peak)/float64(h.Capacity)*100) / 100
+	}
+	h.OverCapacity = h.Peak > h.Capacity
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/shopspring/decimal"
+)
+
+type PayrollRepository interface {
+	LoadEmployees(ctx context.Context, start, end time.Time) ([]core.PayrollEmployee, error)
+	CreateRun(ctx context.Context, run core.PayrollRun) (core.PayrollRun, error)
+	ReplaceLines(ctx context.Context, id string, run core.PayrollRun) error
+	ListRuns(ctx context.Context, page, limit int) ([]core.PayrollRun, int64, error)
+	GetRun(ctx context.Context, id string) (core.PayrollRun, error)
+	TransitionRun(ctx context.Context, id, from, to string) error
+	DeleteRun(ctx context.Context, id string) error
+}
+
+type PayrollService struct {
+	repo         PayrollRepository
+	rates        *ExchangeRateService
+	baseCurrency string
+}
+
+func NewPayrollService(repo PayrollRepository, rates *ExchangeRateService, baseCurrency string) *PayrollService {
+	return &PayrollService{repo: repo, rates: rates, baseCurrency: baseCurrency}
+}
+
+func (s *PayrollService) CreateRun(ctx context.Context, req core.PayrollRunCreate) (core.PayrollRun, error) {
+	start, end, err := parsePayrollPeriod(req.PeriodType, req.Period)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+
+	if req.Currency == "" {
+		req.Currency = s.baseCurrency
+	}
+	currency, err := normalizeCurrency(req.Currency)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+
+	run, err := s.calculate(ctx, start, end, currency)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	run.PeriodType = req.PeriodType
+
+	created, err := s.repo.CreateRun(ctx, run)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	return s.repo.GetRun(ctx, created.ID)
+}
+
+// Recalculate rebuilds the lines of a draft run from current HR data.
+func (s *PayrollService) Recalculate(ctx context.Context, id string) (core.PayrollRun, error) {
+	existing, err := s.repo.GetRun(ctx, id)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	if existing.Status != core.PayrollStatusDraft {
+		return core.PayrollRun{}, core.ErrPayrollRunState
+	}
+
+	run, err := s.calculate(ctx, existing.PeriodStart, existing.PeriodEnd, existing.Currency)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+	if err := s.repo.ReplaceLines(ctx, id, run); err != nil {
+		return core.PayrollRun{}, err
+	}
+	return s.repo.GetRun(ctx, id)
+}
+
+func (s *PayrollService) ListRuns(ctx context.Context, page, limit int) ([]core.PayrollRun, int64, error) {
+	return s.repo.ListRuns(ctx, page, limit)
+}
+
+func (s *PayrollService) GetRun(ctx context.Context, id string) (core.PayrollRun, error) {
+	return s.repo.GetRun(ctx, id)
+}
+
+func (s *PayrollService) Approve(ctx context.Context, id string) (core.PayrollRun, error) {
+	if err := s.repo.TransitionRun(ctx, id, core.PayrollStatusDraft, core.PayrollStatusApproved); err != nil {
+		return core.PayrollRun{}, err
+	}
+	return s.repo.GetRun(ctx, id)
+}
+
+func (s *PayrollService) Lock(ctx context.Context, id string) (core.PayrollRun, error) {
+	if err := s.repo.TransitionRun(ctx, id, core.PayrollStatusApproved, core.PayrollStatusLocked); err != nil {
+		return core.PayrollRun{}, err
+	}
+	return s.repo.GetRun(ctx, id)
+}
+
+func (s *PayrollService) DeleteRun(ctx context.Context, id string) error {
+	return s.repo.DeleteRun(ctx, id)
+}
+
+// calculate builds the lines of a run in currency. Salaries in other
+// currencies are converted at the rates in effect on the last day of the
+// period, so a recalculation of the same period gives the same result.
+func (s *PayrollService) calculate(ctx context.Context, start, end time.Time, currency string) (core.PayrollRun, error) {
+	employees, err := s.repo.LoadEmployees(ctx, start, end)
+	if err != nil {
+		return core.PayrollRun{}, err
+	}
+
+	factors := map[string]decimal.Decimal{}
+	factor := func(from string) (decimal.Decimal, error) {
+		if f, ok := factors[from]; ok {
+			return f, nil
+		}
+		f, err := s.rates.Factor(ctx, from, currency, end)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		factors[from] = f
+		return f, nil
+	}
+
+	run := core.PayrollRun{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Currency:    currency,
+		Lines:       []core.PayrollLine{},
+	}
+	for _, e := range employees {
+		line, ok, err := payrollLine(e, start, end, factor)
+		if err != nil {
+			return core.PayrollRun{}, err
+		}
+		if !ok {
+			continue
+		}
+		run.Lines = append(run.Lines, line)
+		run.Total = run.Total.Add(line.Amount)
+	}
+	run.EmployeeCount = len(run.Lines)
+	return run, nil
+}
+
+// payrollLine pays each salary segment that overlaps the period as
+// annual/12 per month, pro-rated by calendar days within each month. Days
+// before the hire date or the first salary, and after the termination
+// date, are not paid.
+func payrollLine(
+	e core.PayrollEmployee,
+	start, end time.Time,
+	factor func(currency string) (decimal.Decimal, error),
+) (core.PayrollLine, bool, error) {
+	line := core.PayrollLine{
+		UserID:         e.UserID,
+		EmployeeName:   e.Name,
+		DepartmentID:   e.DepartmentID,
+		DepartmentName: e.DepartmentName,
+		PeriodDays:     daysBetween(start, end),
+		Segments:       []core.PayrollSegment{},
+	}
+
+	from := start
+	if hire := dateOnly(e.HireDate); hire.After(from) {
+		from = hire
+	}
+	until := end
+	if e.TerminationDate != nil && dateOnly(*e.TerminationDate).Before(until) {
+		until = dateOnly(*e.TerminationDate)
+	}
+
+	var total decimal.Decimal
+	for i, sal := range e.Salaries {
+		segStart := dateOnly(sal.EffectiveDate)
+		segEnd := until
+		if i+1 < len(e.Salaries) {
+			segEnd = dateOnly(e.Salaries[i+1].EffectiveDate).AddDate(0, 0, -1)
+		}
+		if segStart.Before(from) {
+			segStart = from
+		}
+		if segEnd.After(until) {
+			segEnd = until
+		}
+		if segEnd.Before(segStart) {
+			continue
+		}
+
+		rate, err := factor(sal.Currency)
+		if err != nil {
+			return core.PayrollLine{}, false, err
+		}
+		amount := proRatedAmount(sal.Amount, segStart, segEnd).Mul(rate)
+		days := daysBetween(segStart, segEnd)
+		line.Segments = append(line.Segments, core.PayrollSegment{
+			From:         segStart,
+			To:           segEnd,
+			Currency:     sal.Currency,
+			AnnualAmount: sal.Amount,
+			Rate:         rate,
+			Days:         days,
+			Amount:       amount.Round(2),
+		})
+		line.DaysWorked += days
+		total = total.Add(amount)
+	}
+
+	line.Amount = total.Round(2)
+	return line, len(line.Segments) > 0, nil
+}
+
+// proRatedAmount walks the months touched by [from, to] and pays the share of
+// each month's annual/12 that falls inside the range.
+func proRatedAmount(annual decimal.Decimal, from, to time.Time) decimal.Decimal {
+	monthly := annual.Div(decimal.NewFromInt(12))
+	var amount decimal.Decimal
+	for monthStart := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !monthStart.After(to); monthStart = monthStart.AddDate(0, 1, 0) {
+		monthEnd := monthStart.AddDate(0, 1, -1)
+		a, b := monthStart, monthEnd
+		if from.After(a) {
+			a = from
+		}
+		if to.Before(b) {
+			b = to
+		}
+		days := decimal.NewFromInt(int64(daysBetween(a, b)))
+		amount = amount.Add(monthly.Mul(days).Div(decimal.NewFromInt(int64(monthEnd.Day()))))
+	}
+	return amount
+}
+
+// parsePayrollPeriod turns "2025-03" or "2025-Q1" into an inclusive date
+// range.
+func parsePayrollPeriod(periodType, period string) (time.Time, time.Time, error) {
+	switch periodType {
+	case core.PayrollPeriodMonth:
+		start, err := time.Parse("2006-01", period)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("period must look like 2025-03")
+		}
+		return start, start.AddDate(0, 1, -1), nil
+	case core.PayrollPeriodQuarter:
+		year, quarter, ok := strings.Cut(strings.ToUpper(period), "-Q")
+		y, yErr := strconv.Atoi(year)
+		q, qErr := strconv.Atoi(quarter)
+		if !ok || yErr != nil || qErr != nil || q < 1 || q > 4 {
+			return time.Time{}, time.Time{}, fmt.Errorf("period must look like 2025-Q1")
+		}
+		start := time.Date(y, time.Month((q-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 3, -1), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("period_type must be month or quarter")
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween counts calendar days in the inclusive range [from, to].
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours()/24)) + 1
+}
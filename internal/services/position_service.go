@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"multi-processing-backend/internal/core"
 )
 
@@ -12,14 +13,30 @@ type PositionRepository interface {
 	Get(ctx context.Context, id string) (core.Position, error)
 	Update(ctx context.Context, id string, update core.PositionUpdate) (core.Position, error)
 	Delete(ctx context.Context, id string) error
+
+	ListBands(ctx context.Context, positionID string) ([]core.SalaryBand, error)
+	UpsertBand(ctx context.Context, positionID string, level int, band core.SalaryBandUpsert) (core.SalaryBand, error)
+	DeleteBand(ctx context.Context, positionID string, level int) error
+
+	ListSkills(ctx context.Context, positionID string) ([]core.PositionSkill, error)
+	UpsertSkill(ctx context.Context, positionID, skillID string, in core.PositionSkillUpsert) (core.PositionSkill, error)
+	DeleteSkill(ctx context.Context, positionID, skillID string) error
+	UserPosition(ctx context.Context, userID string) (string, error)
+}
+
+// UserSkillReader is the part of the skill repository the gap analysis
+// reads an employee's skills from.
+type UserSkillReader interface {
+	GetByUserId(ctx context.Context, id string) ([]core.SkillWithDetails, error)
 }
 
 type PositionService struct {
-	repo PositionRepository
+	repo   PositionRepository
+	skills UserSkillReader
 }
 
-func NewPositionService(repo PositionRepository) *PositionService {
-	return &PositionService{repo: repo}
+func NewPositionService(repo PositionRepository, skills UserSkillReader) *PositionService {
+	return &PositionService{repo: repo, skills: skills}
 }
 
 func (s *PositionService) List(ctx context.Context) ([]core.Position, int64, error) {
@@ -43,4 +60,119 @@ func (s *PositionService) Update(ctx context.Context, id string, updates core.Po
 
 func (s *PositionService) Delete(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
-}
\ No newline at end of file
+}
+
+func (s *PositionService) ListBands(ctx context.Context, positionID string) ([]core.SalaryBand, error) {
+	return s.repo.ListBands(ctx, positionID)
+}
+
+func (s *PositionService) UpsertBand(
+	ctx context.Context,
+	positionID string,
+	level int,
+	band core.SalaryBandUpsert,
+) (core.SalaryBand, error) {
+	if band.Currency == "" {
+		band.Currency = core.PivotCurrency
+	}
+	currency, err := normalizeCurrency(band.Currency)
+	if err != nil {
+		return core.SalaryBand{}, err
+	}
+	band.Currency = currency
+
+	if !band.Min.IsPositive() {
+		return core.SalaryBand{}, fmt.Errorf("min must be positive")
+	}
+	if band.Mid.LessThan(band.Min) || band.Max.LessThan(band.Mid) {
+		return core.SalaryBand{}, fmt.Errorf("band must satisfy min <= mid <= max")
+	}
+	band.Min, band.Mid, band.Max = band.Min.Round(2), band.Mid.Round(2), band.Max.Round(2)
+
+	return s.repo.UpsertBand(ctx, positionID, level, band)
+}
+
+func (s *PositionService) DeleteBand(ctx context.Context, positionID string, level int) error {
+	return s.repo.DeleteBand(ctx, positionID, level)
+}
+
+func (s *PositionService) ListSkills(ctx context.Context, positionID string) ([]core.PositionSkill, error) {
+	return s.repo.ListSkills(ctx, positionID)
+}
+
+func (s *PositionService) UpsertSkill(
+	ctx context.Context,
+	positionID, skillID string,
+	in core.PositionSkillUpsert,
+) (core.PositionSkill, error) {
+	if in.MinProficiency < 1 || in.MinProficiency > 5 {
+		return core.PositionSkill{}, fmt.Errorf("min_proficiency must be between 1 and 5")
+	}
+	return s.repo.UpsertSkill(ctx, positionID, skillID, in)
+}
+
+func (s *PositionService) DeleteSkill(ctx context.Context, positionID, skillID string) error {
+	return s.repo.DeleteSkill(ctx, positionID, skillID)
+}
+
+// SkillGaps compares the skills of a user with the requirements of
+// positionID, or of their current position when positionID is empty.
+func (s *PositionService) SkillGaps(ctx context.Context, userID, positionID string) (core.SkillGapReport, error) {
+	current, err := s.repo.UserPosition(ctx, userID)
+	if err != nil {
+		return core.SkillGapReport{}, err
+	}
+	if positionID == "" {
+		if current == "" {
+			return core.SkillGapReport{}, fmt.Errorf("position_id must be given for a user without a position")
+		}
+		positionID = current
+	}
+
+	requirements, err := s.repo.ListSkills(ctx, positionID)
+	if err != nil {
+		return core.SkillGapReport{}, err
+	}
+	position, err := s.repo.Get(ctx, positionID)
+	if err != nil {
+		return core.SkillGapReport{}, err
+	}
+	held, err := s.skills.GetByUserId(ctx, userID)
+	if err != nil {
+		return core.SkillGapReport{}, err
+	}
+
+	levels := make(map[string]int, len(held))
+	for _, skill := range held {
+		levels[skill.ID] = skill.ProficiencyLevel
+	}
+
+	report := core.SkillGapReport{
+		UserID:          userID,
+		PositionID:      positionID,
+		PositionTitle:   position.Title,
+		CurrentPosition: positionID == current,
+		Ready:           true,
+		Gaps:            []core.SkillGap{},
+		Met:             []core.SkillGap{},
+	}
+	for _, req := range requirements {
+		gap := core.SkillGap{
+			SkillID:          req.SkillID,
+			SkillName:        req.SkillName,
+			Required:         req.Required,
+			MinProficiency:   req.MinProficiency,
+			ProficiencyLevel: levels[req.SkillID],
+			Gap:              max(req.MinProficiency-levels[req.SkillID], 0),
+		}
+		if gap.Gap == 0 {
+			report.Met = append(report.Met, gap)
+			continue
+		}
+		if gap.Required {
+			report.Ready = false
+		}
+		report.Gaps = append(report.Gaps, gap)
+	}
+	return report, nil
+}
@@ -2,24 +2,33 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"multi-processing-backend/internal/core"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type SalaryRepository interface {
 	List(ctx context.Context, page, limit int) ([]core.Salary, int64, error)
-	Create(ctx context.Context, u core.Salary) (core.Salary, error)
+	Create(ctx context.Context, u core.Salary, allowOutOfBand bool) (core.Salary, error)
 
 	Get(ctx context.Context, id string) (core.Salary, error)
-	Update(ctx context.Context, id string, update core.SalaryUpdate) (core.Salary, error)
+	Update(ctx context.Context, id string, update core.SalaryUpdate, allowOutOfBand bool) (core.Salary, error)
 	Delete(ctx context.Context, id string) error
+
+	CompaRatios(ctx context.Context) ([]core.CompaRatio, error)
+	ListByUser(ctx context.Context, userID string) ([]core.Salary, error)
+	GetAsOf(ctx context.Context, userID string, date time.Time) (core.Salary, error)
 }
 
 type SalaryService struct {
-	repo SalaryRepository
+	repo  SalaryRepository
+	rates *ExchangeRateService
 }
 
-func NewSalaryService(repo SalaryRepository) *SalaryService {
-	return &SalaryService{repo: repo}
+func NewSalaryService(repo SalaryRepository, rates *ExchangeRateService) *SalaryService {
+	return &SalaryService{repo: repo, rates: rates}
 }
 
 func (s *SalaryService) List(ctx context.Context, page, limit int) ([]core.Salary, int64, error) {
@@ -29,18 +38,162 @@ func (s *SalaryService) List(ctx context.Context, page, limit int) ([]core.Salar
 func (s *SalaryService) Create(
 	ctx context.Context,
 	user core.Salary,
+	allowOutOfBand bool,
 ) (core.Salary, error) {
-	return s.repo.Create(ctx, user)
+	if !user.Amount.IsPositive() {
+		return core.Salary{}, fmt.Errorf("amount must be positive")
+	}
+	if user.Currency == "" {
+		user.Currency = core.PivotCurrency
+	}
+	currency, err := normalizeCurrency(user.Currency)
+	if err != nil {
+		return core.Salary{}, err
+	}
+	user.Currency = currency
+	user.Amount = user.Amount.Round(2)
+	if user.EffectiveDate.IsZero() {
+		user.EffectiveDate = time.Now()
+	}
+	return s.repo.Create(ctx, user, allowOutOfBand)
 }
 
 func (s *SalaryService) Get(ctx context.Context, id string) (core.Salary, error) {
 	return s.repo.Get(ctx, id)
 }
 
-func (s *SalaryService) Update(ctx context.Context, id string, updates core.SalaryUpdate) (core.Salary, error) {
-	return s.repo.Update(ctx, id, updates)
+func (s *SalaryService) Update(
+	ctx context.Context,
+	id string,
+	updates core.SalaryUpdate,
+	allowOutOfBand bool,
+) (core.Salary, error) {
+	if updates.Amount.IsNegative() {
+		return core.Salary{}, fmt.Errorf("amount must be positive")
+	}
+	if updates.Currency != "" {
+		currency, err := normalizeCurrency(updates.Currency)
+		if err != nil {
+			return core.Salary{}, err
+		}
+		updates.Currency = currency
+	}
+	updates.Amount = updates.Amount.Round(2)
+	return s.repo.Update(ctx, id, updates, allowOutOfBand)
 }
 
 func (s *SalaryService) Delete(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
-}
\ No newline at end of file
+}
+
+func (s *SalaryService) CompaRatios(ctx context.Context) ([]core.CompaRatio, error) {
+	return s.repo.CompaRatios(ctx)
+}
+
+// OutOfBand lists the employees whose current salary is below or above
+// their band.
+func (s *SalaryService) OutOfBand(ctx context.Context) ([]core.CompaRatio, error) {
+	ratios, err := s.repo.CompaRatios(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	outside := []core.CompaRatio{}
+	for _, r := range ratios {
+		if r.Status == core.BandBelow || r.Status == core.BandAbove {
+			outside = append(outside, r)
+		}
+	}
+	return outside, nil
+}
+
+// Timeline lists a user's salaries in effective order with the change
+// against the previous entry, plus the salary in effect today. With a
+// currency every entry is converted at the rate of its effective date.
+func (s *SalaryService) Timeline(ctx context.Context, userID, currency string) (core.SalaryTimeline, error) {
+	currency, err := reportingCurrency(currency)
+	if err != nil {
+		return core.SalaryTimeline{}, err
+	}
+
+	salaries, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return core.SalaryTimeline{}, err
+	}
+
+	timeline := core.SalaryTimeline{
+		UserID:   userID,
+		Currency: currency,
+		Changes:  make([]core.SalaryChange, 0, len(salaries)),
+	}
+	today := time.Now()
+
+	for i := range salaries {
+		salaries[i], err = s.convert(ctx, salaries[i], currency, salaries[i].EffectiveDate)
+		if err != nil {
+			return core.SalaryTimeline{}, err
+		}
+	}
+
+	for i, sal := range salaries {
+		change := core.SalaryChange{Salary: sal}
+		if i > 0 && salaries[i-1].Currency == sal.Currency {
+			prev := salaries[i-1].Amount
+			delta := sal.Amount.Sub(prev)
+			change.PreviousAmount = &prev
+			change.Delta = &delta
+			if !prev.IsZero() {
+				percent := delta.Div(prev).Mul(decimal.NewFromInt(100)).Round(2)
+				change.DeltaPercent = &percent
+			}
+		}
+		timeline.Changes = append(timeline.Changes, change)
+
+		if !sal.EffectiveDate.After(today) {
+			current := sal
+			timeline.Current = &current
+		}
+	}
+
+	return timeline, nil
+}
+
+func (s *SalaryService) Current(ctx context.Context, userID, currency string) (core.Salary, error) {
+	return s.AsOf(ctx, userID, time.Now(), currency)
+}
+
+func (s *SalaryService) AsOf(ctx context.Context, userID string, date time.Time, currency string) (core.Salary, error) {
+	currency, err := reportingCurrency(currency)
+	if err != nil {
+		return core.Salary{}, err
+	}
+
+	salary, err := s.repo.GetAsOf(ctx, userID, date)
+	if err != nil {
+		return core.Salary{}, err
+	}
+	return s.convert(ctx, salary, currency, date)
+}
+
+// convert reports sal in currency using the rates of date. An empty
+// currency keeps the salary as stored.
+func (s *SalaryService) convert(ctx context.Context, sal core.Salary, currency string, date time.Time) (core.Salary, error) {
+	if currency == "" || currency == sal.Currency {
+		return sal, nil
+	}
+	amount, err := s.rates.Convert(ctx, sal.Amount, sal.Currency, currency, date)
+	if err != nil {
+		return core.Salary{}, err
+	}
+	sal.Amount = amount
+	sal.Currency = currency
+	return sal, nil
+}
+
+// reportingCurrency validates an optional ?currency= parameter.
+func reportingCurrency(currency string) (string, error) {
+	if currency == "" {
+		return "", nil
+	}
+	return normalizeCurrency(currency)
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"multi-processing-backend/internal/core"
+)
+
+// skillQuery is a parsed skill search such as
+//
+//	Go >= 4 AND (Kubernetes >= 3 OR Terraform) AND NOT "Visual Basic"
+//
+// A bare skill name matches anyone holding the skill at any level. Names
+// with spaces may be quoted or written as consecutive words.
+type skillQuery interface {
+	// eval reports whether levels, keyed by the lower-cased name used in
+	// the query, satisfy the query and how strongly.
+	eval(levels map[string]int) (bool, int)
+	names(into map[string]string)
+}
+
+type skillTerm struct {
+	name  string
+	op    string
+	level int
+}
+
+type skillAnd struct{ left, right skillQuery }
+type skillOr struct{ left, right skillQuery }
+type skillNot struct{ inner skillQuery }
+
+func (t skillTerm) eval(levels map[string]int) (bool, int) {
+	level, ok := levels[strings.ToLower(t.name)]
+	if !ok {
+		return false, 0
+	}
+
+	matched := true
+	switch t.op {
+	case ">=":
+		matched = level >= t.level
+	case ">":
+		matched = level > t.level
+	case "<=":
+		matched = level <= t.level
+	case "<":
+		matched = level < t.level
+	case "=":
+		matched = level == t.level
+	}
+	if !matched {
+		return false, 0
+	}
+	return true, level
+}
+
+func (t skillTerm) names(into map[string]string) {
+	into[strings.ToLower(t.name)] = t.name
+}
+
+func (q skillAnd) eval(levels map[string]int) (bool, int) {
+	lok, lscore := q.left.eval(levels)
+	if !lok {
+		return false, 0
+	}
+	rok, rscore := q.right.eval(levels)
+	if !rok {
+		return false, 0
+	}
+	return true, lscore + rscore
+}
+
+func (q skillAnd) names(into map[string]string) {
+	q.left.names(into)
+	q.right.names(into)
+}
+
+// eval for OR adds up every side that matched, so holding both
+// alternatives ranks above holding one.
+func (q skillOr) eval(levels map[string]int) (bool, int) {
+	lok, lscore := q.left.eval(levels)
+	rok, rscore := q.right.eval(levels)
+	return lok || rok, lscore + rscore
+}
+
+func (q skillOr) names(into map[string]string) {
+	q.left.names(into)
+	q.right.names(into)
+}
+
+func (q skillNot) eval(levels map[string]int) (bool, int) {
+	ok, _ := q.inner.eval(levels)
+	return !ok, 0
+}
+
+func (q skillNot) names(into map[string]string) {
+	q.inner.names(into)
+}
+
+type skillToken struct {
+	kind  string // "word", "quoted", "op", "(", ")"
+	value string
+}
+
+func tokenizeSkillQuery(input string) ([]skillToken, error) {
+	var tokens []skillToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, skillToken{kind: string(r)})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("%w: unterminated quote", core.ErrInvalidSkillQuery)
+			}
+			tokens = append(tokens, skillToken{kind: "quoted", value: string(runes[i+1 : end])})
+			i = end + 1
+		case r == '>' || r == '<' || r == '=':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+				i++
+			}
+			if op == "==" {
+				op = "="
+			}
+			tokens = append(tokens, skillToken{kind: "op", value: op})
+			i++
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"<>=`, runes[end]) {
+				end++
+			}
+			tokens = append(tokens, skillToken{kind: "word", value: string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type skillQueryParser struct {
+	tokens []skillToken
+	pos    int
+}
+
+func parseSkillQuery(input string) (skillQuery, error) {
+	tokens, err := tokenizeSkillQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: query is empty", core.ErrInvalidSkillQuery)
+	}
+
+	p := &skillQueryParser{tokens: tokens}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", core.ErrInvalidSkillQuery, p.tokens[p.pos].describe())
+	}
+	return q, nil
+}
+
+func (t skillToken) describe() string {
+	if t.value != "" {
+		return t.value
+	}
+	return t.kind
+}
+
+func (p *skillQueryParser) peekKeyword(keyword string) bool {
+	if p.pos >= len(p.tokens) {
+		return false
+	}
+	t := p.tokens[p.pos]
+	return t.kind == "word" && strings.EqualFold(t.value, keyword)
+}
+
+func (p *skillQueryParser) parseOr() (skillQuery, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = skillOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *skillQueryParser) parseAnd() (skillQuery, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("AND") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = skillAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *skillQueryParser) parseUnary() (skillQuery, error) {
+	if p.peekKeyword("NOT") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return skillNot{inner: inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *skillQueryParser) parsePrimary() (skillQuery, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected end of query", core.ErrInvalidSkillQuery)
+	}
+
+	t := p.tokens[p.pos]
+	switch t.kind {
+	case "(":
+		p.pos++
+		q, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != ")" {
+			return nil, fmt.Errorf("%w: missing )", core.ErrInvalidSkillQuery)
+		}
+		p.pos++
+		return q, nil
+	case "quoted":
+		p.pos++
+		return p.parseComparison(t.value)
+	case "word":
+		var words []string
+		for p.pos < len(p.tokens) && p.tokens[p.pos].kind == "word" &&
+			!p.peekKeyword("AND") && !p.peekKeyword("OR") && !p.peekKeyword("NOT") {
+			words = append(words, p.tokens[p.pos].value)
+			p.pos++
+		}
+		if len(words) > 0 {
+			return p.parseComparison(strings.Join(words, " "))
+		}
+	}
+	return nil, fmt.Errorf("%w: unexpected %q", core.ErrInvalidSkillQuery, t.describe())
+}
+
+func (p *skillQueryParser) parseComparison(name string) (skillQuery, error) {
+	term := skillTerm{name: strings.TrimSpace(name)}
+	if term.name == "" {
+		return nil, fmt.Errorf("%w: empty skill name", core.ErrInvalidSkillQuery)
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "op" {
+		return term, nil
+	}
+
+	term.op = p.tokens[p.pos].value
+	p.pos++
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "word" {
+		return nil, fmt.Errorf("%w: %s %s needs a level", core.ErrInvalidSkillQuery, term.name, term.op)
+	}
+	level, err := strconv.Atoi(p.tokens[p.pos].value)
+	if err != nil || level < 1 || level > 5 {
+		return nil, fmt.Errorf("%w: level for %s must be between 1 and 5", core.ErrInvalidSkillQuery, term.name)
+	}
+	p.pos++
+	term.level = level
+	return term, nil
+}
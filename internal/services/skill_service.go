@@ -2,13 +2,29 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"multi-processing-backend/internal/core"
+	"sort"
+	"strings"
 )
 
 type SkillRepository interface {
 	List(ctx context.Context) ([]core.Skill, int64, error)
 	Create(ctx context.Context, u core.Skill) (core.Skill, error)
-	AddSkillByUserId(ctx context.Context, skill_id, user_id string) error
+	AddSkillByUserId(ctx context.Context, skill_id, user_id string, input core.UserSkillUpdate) error
+	UpdateUserSkill(ctx context.Context, skillID, userID string, update core.UserSkillUpdate) (core.SkillWithDetails, error)
+	ResolveSkillNames(ctx context.Context, names []string) (map[string]core.Skill, error)
+	SkillSearchCandidates(ctx context.Context, skillIDs, departmentIDs []string, holdersOnly bool) ([]core.SkillSearchResult, error)
+
+	ListAliases(ctx context.Context, skillID string) ([]core.SkillAlias, error)
+	AddAlias(ctx context.Context, skillID, alias string) (core.SkillAlias, error)
+	DeleteAlias(ctx context.Context, skillID, alias string) error
+	Merge(ctx context.Context, targetID string, sourceIDs []string) (core.SkillMergeResult, error)
+
+	ListCategories(ctx context.Context) ([]core.SkillCategory, error)
+	CreateCategory(ctx context.Context, in core.SkillCategoryCreate) (core.SkillCategory, error)
+	UpdateCategory(ctx context.Context, id string, update core.SkillCategoryUpdate) (core.SkillCategory, error)
+	DeleteCategory(ctx context.Context, id string) error
 	Get(ctx context.Context, id string) (core.Skill, error)
 	GetByUserId(ctx context.Context, id string) ([]core.SkillWithDetails, error)
 	Update(ctx context.Context, id string, update core.SkillUpdate) (core.Skill, error)
@@ -32,14 +48,125 @@ func (s *SkillService) Create(
 	ctx context.Context,
 	skill core.Skill,
 ) (core.Skill, error) {
+	skill.Name = strings.TrimSpace(skill.Name)
+	if skill.Name == "" {
+		return core.Skill{}, fmt.Errorf("name must not be empty")
+	}
 	return s.repo.Create(ctx, skill)
 }
 
 func (s *SkillService) AddSkillByUserId(
 	ctx context.Context,
 	skill_id, user_id string,
+	input core.UserSkillUpdate,
 ) error {
-	return s.repo.AddSkillByUserId(ctx, skill_id, user_id)
+	if err := validateProficiency(input.ProficiencyLevel); err != nil {
+		return err
+	}
+	return s.repo.AddSkillByUserId(ctx, skill_id, user_id, input)
+}
+
+func (s *SkillService) UpdateUserSkill(
+	ctx context.Context,
+	skillID, userID string,
+	update core.UserSkillUpdate,
+) (core.SkillWithDetails, error) {
+	if err := validateProficiency(update.ProficiencyLevel); err != nil {
+		return core.SkillWithDetails{}, err
+	}
+	return s.repo.UpdateUserSkill(ctx, skillID, userID, update)
+}
+
+// Search finds employees matching a boolean skill query, strongest match
+// first. See skillQuery for the syntax.
+func (s *SkillService) Search(
+	ctx context.Context,
+	query string,
+	departmentIDs []string,
+	limit int,
+) ([]core.SkillSearchResult, error) {
+	q, err := parseSkillQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	q.names(names)
+	lower := make([]string, 0, len(names))
+	for name := range names {
+		lower = append(lower, name)
+	}
+
+	resolved, err := s.repo.ResolveSkillNames(ctx, lower)
+	if err != nil {
+		return nil, err
+	}
+	var unknown []string
+	skillIDs := make([]string, 0, len(resolved))
+	for _, name := range lower {
+		skill, ok := resolved[name]
+		if !ok {
+			unknown = append(unknown, names[name])
+			continue
+		}
+		skillIDs = append(skillIDs, skill.ID)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: unknown skill %s", core.ErrInvalidSkillQuery, strings.Join(unknown, ", "))
+	}
+
+	// Someone holding none of the skills can only match when the query
+	// matches no skills at all, as NOT Go does; otherwise they are left out
+	// in SQL.
+	matchesNone, _ := q.eval(map[string]int{})
+	candidates, err := s.repo.SkillSearchCandidates(ctx, skillIDs, departmentIDs, !matchesNone)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []core.SkillSearchResult{}
+	for _, c := range candidates {
+		held := make(map[string]int, len(c.Skills))
+		for _, skill := range c.Skills {
+			held[skill.SkillID] = skill.ProficiencyLevel
+		}
+		// Terms may use an alias, so levels are keyed by the name as
+		// written in the query.
+		levels := make(map[string]int, len(resolved))
+		for name, skill := range resolved {
+			if level, ok := held[skill.ID]; ok {
+				levels[name] = level
+			}
+		}
+		ok, score := q.eval(levels)
+		if !ok {
+			continue
+		}
+		c.Score = score
+		results = append(results, c)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].LastName != results[j].LastName {
+			return results[i].LastName < results[j].LastName
+		}
+		return results[i].FirstName < results[j].FirstName
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func validateProficiency(level *int) error {
+	if level != nil && (*level < 1 || *level > 5) {
+		return fmt.Errorf("proficiency_level must be between 1 and 5")
+	}
+	return nil
 }
 
 func (s *SkillService) DeleteSkillByUserId(
@@ -58,9 +185,99 @@ func (s *SkillService) GetByUserId(ctx context.Context, id string) ([]core.Skill
 }
 
 func (s *SkillService) Update(ctx context.Context, id string, updates core.SkillUpdate) (core.Skill, error) {
+	if updates.Name != nil {
+		name := strings.TrimSpace(*updates.Name)
+		if name == "" {
+			return core.Skill{}, fmt.Errorf("name must not be empty")
+		}
+		updates.Name = &name
+	}
 	return s.repo.Update(ctx, id, updates)
 }
 
 func (s *SkillService) Delete(ctx context.Context, id string) error {
 	return s.repo.Delete(ctx, id)
 }
+
+func (s *SkillService) ListAliases(ctx context.Context, skillID string) ([]core.SkillAlias, error) {
+	return s.repo.ListAliases(ctx, skillID)
+}
+
+func (s *SkillService) AddAlias(ctx context.Context, skillID, alias string) (core.SkillAlias, error) {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return core.SkillAlias{}, fmt.Errorf("alias must not be empty")
+	}
+	return s.repo.AddAlias(ctx, skillID, alias)
+}
+
+func (s *SkillService) DeleteAlias(ctx context.Context, skillID, alias string) error {
+	return s.repo.DeleteAlias(ctx, skillID, alias)
+}
+
+// Merge folds the source skills into targetID. See SkillRepository.Merge.
+func (s *SkillService) Merge(ctx context.Context, targetID string, sourceIDs []string) (core.SkillMergeResult, error) {
+	seen := make(map[string]bool, len(sourceIDs))
+	unique := make([]string, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return core.SkillMergeResult{}, fmt.Errorf("source_ids must not include the target skill")
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return s.repo.Merge(ctx, targetID, unique)
+}
+
+// CategoryTree returns the skill categories as a forest of top-level
+// categories, children sorted by name.
+func (s *SkillService) CategoryTree(ctx context.Context) ([]*core.SkillCategory, error) {
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*core.SkillCategory, len(categories))
+	for i := range categories {
+		nodes[categories[i].ID] = &categories[i]
+	}
+
+	roots := []*core.SkillCategory{}
+	for i := range categories {
+		node := &categories[i]
+		if parent, ok := nodes[node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots, nil
+}
+
+func (s *SkillService) CreateCategory(ctx context.Context, in core.SkillCategoryCreate) (core.SkillCategory, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		return core.SkillCategory{}, fmt.Errorf("name must not be empty")
+	}
+	return s.repo.CreateCategory(ctx, in)
+}
+
+func (s *SkillService) UpdateCategory(ctx context.Context, id string, update core.SkillCategoryUpdate) (core.SkillCategory, error) {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return core.SkillCategory{}, fmt.Errorf("name must not be empty")
+		}
+		update.Name = &name
+	}
+	if update.ParentID != nil && *update.ParentID == id {
+		return core.SkillCategory{}, core.ErrCategoryCycle
+	}
+	return s.repo.UpdateCategory(ctx, id, update)
+}
+
+func (s *SkillService) DeleteCategory(ctx context.Context, id string) error {
+	return s.repo.DeleteCategory(ctx, id)
+}
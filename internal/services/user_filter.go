@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"multi-processing-backend/internal/core"
+)
+
+// normalizeUserFilter checks the ranges of f and brings its values into
+// the form they are stored in.
+func normalizeUserFilter(f *core.UserFilter) error {
+	switch f.Status {
+	case "", core.EmploymentAll, core.EmploymentActive, core.EmploymentOnLeave, core.EmploymentTerminated:
+	default:
+		return fmt.Errorf("status must be one of active, on_leave, terminated or all")
+	}
+
+	f.Search = collapseSpaces(f.Search)
+	f.Department = collapseSpaces(f.Department)
+	f.Position = collapseSpaces(f.Position)
+	f.City = collapseSpaces(f.City)
+	f.Skill = collapseSpaces(f.Skill)
+
+	if f.MinLevel != nil && f.MaxLevel != nil && *f.MinLevel > *f.MaxLevel {
+		return fmt.Errorf("min_level must not be above max_level")
+	}
+
+	if f.HiredFrom != nil {
+		from := dateOnly(*f.HiredFrom)
+		f.HiredFrom = &from
+	}
+	if f.HiredTo != nil {
+		to := dateOnly(*f.HiredTo)
+		f.HiredTo = &to
+	}
+	if f.HiredFrom != nil && f.HiredTo != nil && f.HiredFrom.After(*f.HiredTo) {
+		return fmt.Errorf("hired_from must not be after hired_to")
+	}
+
+	if (f.MinAge != nil && *f.MinAge < 0) || (f.MaxAge != nil && *f.MaxAge < 0) {
+		return fmt.Errorf("ages must not be negative")
+	}
+	if f.MinAge != nil && f.MaxAge != nil && *f.MinAge > *f.MaxAge {
+		return fmt.Errorf("min_age must not be above max_age")
+	}
+
+	if f.Country != "" {
+		country, ok := normalizeCountry(f.Country)
+		if !ok {
+			return fmt.Errorf("country must be an ISO 3166 country code or name")
+		}
+		f.Country = country
+	}
+
+	if f.MinProficiency != 0 {
+		if f.Skill == "" {
+			return fmt.Errorf("min_proficiency must come with a skill")
+		}
+		if f.MinProficiency < 1 || f.MinProficiency > 5 {
+			return fmt.Errorf("min_proficiency must be between 1 and 5")
+		}
+	}
+
+	if (f.MinSalary != nil && f.MinSalary.IsNegative()) || (f.MaxSalary != nil && f.MaxSalary.IsNegative()) {
+		return fmt.Errorf("salaries must not be negative")
+	}
+	if f.MinSalary != nil && f.MaxSalary != nil && f.MinSalary.GreaterThan(*f.MaxSalary) {
+		return fmt.Errorf("min_salary must not be above max_salary")
+	}
+	if f.SalaryCurrency == "" {
+		f.SalaryCurrency = core.PivotCurrency
+	}
+	currency, err := normalizeCurrency(f.SalaryCurrency)
+	if err != nil {
+		return err
+	}
+	f.SalaryCurrency = currency
+
+	for _, s := range f.Sort {
+		if !slices.Contains(core.UserSortFields, s.Field) {
+			return fmt.Errorf("sort must name one of %s", strings.Join(core.UserSortFields, ", "))
+		}
+	}
+	return nil
+}
+
+// checkSalaryFilterAccess refuses filtering or sorting by salary without
+// salary access, as the matches would reveal what people earn.
+func checkSalaryFilterAccess(ctx context.Context, f core.UserFilter) error {
+	if core.HasSalaryAccess(ctx) {
+		return nil
+	}
+	if f.MinSalary != nil || f.MaxSalary != nil {
+		return fmt.Errorf("%w: min_salary, max_salary", core.ErrSalaryForbidden)
+	}
+	if slices.ContainsFunc(f.Sort, func(s core.SortField) bool { return s.Field == "salary" }) {
+		return fmt.Errorf("%w: sort by salary", core.ErrSalaryForbidden)
+	}
+	return nil
+}
+
+// checkSalaryIncludeAccess refuses including current salaries without
+// salary access.
+func checkSalaryIncludeAccess(ctx context.Context, includes core.UserIncludes) error {
+	if includes.Salary && !core.HasSalaryAccess(ctx) {
+		return fmt.Errorf("%w: include=salary", core.ErrSalaryForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"multi-processing-backend/internal/core"
+
+	"github.com/shopspring/decimal"
+)
+
+const maxImportRows = 5000
+
+// csvImportColumns are the columns an import CSV may have, in the order of
+// the template. skills holds "name:level" pairs separated by semicolons.
+var csvImportColumns = []string{
+	"email", "first_name", "last_name", "phone", "date_of_birth", "hire_date", "department", "position",
+	"address_type", "street", "city", "zip_code", "country",
+	"salary_amount", "salary_currency", "salary_effective_date", "skills",
+}
+
+var csvRequiredColumns = []string{
+	"email", "first_name", "last_name", "date_of_birth", "hire_date", "department", "position",
+}
+
+// importLine is a row read from an import file, or the reason it could not
+// be read.
+type importLine struct {
+	line int
+	row  core.UserImportRow
+	err  error
+}
+
+// Import creates employees from a CSV or JSON Lines file. Every row is
+// checked and reported on; rows are only written, all in one transaction,
+// when none of them has a problem and dryRun is not set.
+func (s *UserService) Import(
+	ctx context.Context,
+	r io.Reader,
+	format string,
+	dryRun bool,
+) (core.UserImportReport, error) {
+	var lines []importLine
+	var err error
+	switch format {
+	case core.ImportFormatCSV:
+		lines, err = readImportCSV(r)
+	case core.ImportFormatJSONL:
+		lines, err = readImportJSONL(r)
+	default:
+		return core.UserImportReport{}, fmt.Errorf("format must be csv or jsonl")
+	}
+	if err != nil {
+		return core.UserImportReport{}, err
+	}
+	if len(lines) == 0 {
+		return core.UserImportReport{}, fmt.Errorf("file must contain at least one row")
+	}
+	if len(lines) > maxImportRows {
+		return core.UserImportReport{}, fmt.Errorf("file must not contain more than %d rows", maxImportRows)
+	}
+
+	report := core.UserImportReport{
+		DryRun: dryRun,
+		Total:  len(lines),
+		Rows:   make([]core.UserImportResult, len(lines)),
+	}
+	firstUse := map[string]int{}
+	var valid []core.UserImport
+	var validIdx []int
+
+	for i, l := range lines {
+		report.Rows[i] = core.UserImportResult{
+			Line:   l.line,
+			Email:  strings.TrimSpace(l.row.Email),
+			Status: core.ImportInvalid,
+		}
+		if l.err != nil {
+			report.Rows[i].Errors = []core.FieldError{{Field: "row", Message: l.err.Error()}}
+			continue
+		}
+
+		imp, verr := validateImportRow(l.row)
+		imp.Line = l.line
+		if imp.Salary != nil && !core.HasSalaryAccess(ctx) {
+			verr.Add("salary", core.ErrSalaryForbidden.Error())
+		}
+		if key := strings.ToLower(imp.User.Email); key != "" {
+			if first, ok := firstUse[key]; ok {
+				verr.Add("email", fmt.Sprintf("is also used on line %d", first))
+			} else {
+				firstUse[key] = l.line
+			}
+		}
+		if len(verr.Fields) > 0 {
+			report.Rows[i].Errors = verr.Fields
+			continue
+		}
+		valid = append(valid, imp)
+		validIdx = append(validIdx, i)
+	}
+
+	staticInvalid := len(lines) - len(valid)
+	if len(valid) > 0 {
+		results, err := s.repo.Import(ctx, valid, !dryRun && staticInvalid == 0)
+		if err != nil {
+			return core.UserImportReport{}, err
+		}
+		for j, res := range results {
+			report.Rows[validIdx[j]] = res
+		}
+	}
+
+	for _, res := range report.Rows {
+		switch res.Status {
+		case core.ImportInvalid:
+			report.Invalid++
+		case core.ImportCreated:
+			report.Valid++
+			report.Applied = true
+		default:
+			report.Valid++
+		}
+	}
+	return report, nil
+}
+
+// validateImportRow checks a row on its own and converts it for the
+// repository. Names are only resolved against the database later.
+func validateImportRow(row core.UserImportRow) (core.UserImport, *core.ValidationError) {
+	verr := &core.ValidationError{}
+	imp := core.UserImport{
+		Department: collapseSpaces(row.Department),
+		Position:   collapseSpaces(row.Position),
+	}
+	u := &imp.User
+
+	u.Email = strings.TrimSpace(row.Email)
+	if u.Email == "" {
+		verr.Add("email", "must not be empty")
+	} else if addr, err := mail.ParseAddress(u.Email); err != nil || addr.Address != u.Email {
+		verr.Add("email", "must be a plain email address like jane.doe@example.com")
+	}
+	u.FirstName = collapseSpaces(row.FirstName)
+	if u.FirstName == "" {
+		verr.Add("first_name", "must not be empty")
+	}
+	u.LastName = collapseSpaces(row.LastName)
+	if u.LastName == "" {
+		verr.Add("last_name", "must not be empty")
+	}
+	u.Phone = strings.TrimSpace(row.Phone)
+	if imp.Department == "" {
+		verr.Add("department", "must not be empty")
+	}
+	if imp.Position == "" {
+		verr.Add("position", "must not be empty")
+	}
+
+	var err error
+	if u.DateOfBirth, err = parseImportDate(row.DateOfBirth, true); err != nil {
+		verr.Add("date_of_birth", err.Error())
+	} else if !u.DateOfBirth.Before(dateOnly(time.Now())) {
+		verr.Add("date_of_birth", "must be in the past")
+	}
+	if u.HireDate, err = parseImportDate(row.HireDate, true); err != nil {
+		verr.Add("hire_date", err.Error())
+	} else if !u.DateOfBirth.IsZero() && !u.HireDate.After(u.DateOfBirth) {
+		verr.Add("hire_date", "must be after date_of_birth")
+	}
+
+	if row.Address != nil {
+		addr, err := NormalizeAddress(*row.Address)
+		var addrErr *core.ValidationError
+		switch {
+		case errors.As(err, &addrErr):
+			for _, f := range addrErr.Fields {
+				verr.Add("address."+f.Field, f.Message)
+			}
+		case err != nil:
+			verr.Add("address", err.Error())
+		default:
+			imp.Address = &addr
+		}
+	}
+
+	if row.Salary != nil {
+		sal := core.Salary{Amount: row.Salary.Amount.Round(2), Currency: row.Salary.Currency}
+		if !sal.Amount.IsPositive() {
+			verr.Add("salary.amount", "must be positive")
+		}
+		if strings.TrimSpace(sal.Currency) == "" {
+			sal.Currency = core.PivotCurrency
+		}
+		if sal.Currency, err = normalizeCurrency(sal.Currency); err != nil {
+			verr.Add("salary.currency", strings.TrimPrefix(err.Error(), "currency "))
+		}
+		if sal.EffectiveDate, err = parseImportDate(row.Salary.EffectiveDate, false); err != nil {
+			verr.Add("salary.effective_date", err.Error())
+		} else if sal.EffectiveDate.IsZero() {
+			sal.EffectiveDate = u.HireDate
+		} else if sal.EffectiveDate.Before(u.HireDate) {
+			verr.Add("salary.effective_date", "must not be before hire_date")
+		}
+		imp.Salary = &sal
+	}
+
+	for _, sk := range row.Skills {
+		sk.Name = collapseSpaces(sk.Name)
+		if sk.Level == 0 {
+			sk.Level = 1
+		}
+		switch {
+		case sk.Name == "":
+			verr.Add("skills", "must not contain empty skill names")
+		case sk.Level < 1 || sk.Level > 5:
+			verr.Add("skills", fmt.Sprintf("level of %s must be between 1 and 5", sk.Name))
+		default:
+			imp.Skills = append(imp.Skills, sk)
+		}
+	}
+
+	return imp, verr
+}
+
+func parseImportDate(s string, required bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		if required {
+			return time.Time{}, fmt.Errorf("must not be empty")
+		}
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be a date like 2024-03-01")
+	}
+	return t, nil
+}
+
+// readImportCSV reads a CSV file with a header row naming some of
+// csvImportColumns. Records with the wrong number of fields are reported
+// on their own line; any other syntax error rejects the file.
+func readImportCSV(r io.Reader) ([]importLine, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file must be valid CSV: %w", err)
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(csvImportColumns, name) {
+			return nil, fmt.Errorf("header must only name the columns %s; %q is unknown",
+				strings.Join(csvImportColumns, ", "), name)
+		}
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("header must not name %q twice", name)
+		}
+		index[name] = i
+	}
+	for _, name := range csvRequiredColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("header must include the columns %s", strings.Join(csvRequiredColumns, ", "))
+		}
+	}
+
+	var lines []importLine
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("file must be valid CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			lines = append(lines, importLine{
+				line: line,
+				err:  fmt.Errorf("has %d fields, the header has %d", len(record), len(header)),
+			})
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := index[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		l := importLine{line: line, row: core.UserImportRow{
+			Email:       field("email"),
+			FirstName:   field("first_name"),
+			LastName:    field("last_name"),
+			Phone:       field("phone"),
+			DateOfBirth: field("date_of_birth"),
+			HireDate:    field("hire_date"),
+			Department:  field("department"),
+			Position:    field("position"),
+		}}
+
+		if field("street")+field("city")+field("zip_code")+field("country") != "" {
+			l.row.Address = &core.Address{
+				Type:    field("address_type"),
+				Street:  field("street"),
+				City:    field("city"),
+				ZipCode: field("zip_code"),
+				Country: field("country"),
+			}
+		}
+		if amount := field("salary_amount"); amount != "" {
+			parsed, err := decimal.NewFromString(amount)
+			if err != nil {
+				l.err = fmt.Errorf("salary_amount must be a number like 52000.00")
+			}
+			l.row.Salary = &core.UserImportSalary{
+				Amount:        parsed,
+				Currency:      field("salary_currency"),
+				EffectiveDate: field("salary_effective_date"),
+			}
+		}
+		if skills := field("skills"); skills != "" && l.err == nil {
+			l.row.Skills, l.err = parseSkillList(skills)
+		}
+		lines = append(lines, l)
+	}
+}
+
+// parseSkillList reads "Go:4; PostgreSQL:3; Docker". A skill without a
+// level gets level 1, as when it is added through the API.
+func parseSkillList(s string) ([]core.UserImportSkill, error) {
+	var skills []core.UserImportSkill
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, level, found := strings.Cut(part, ":")
+		sk := core.UserImportSkill{Name: strings.TrimSpace(name)}
+		if found {
+			n, err := strconv.Atoi(strings.TrimSpace(level))
+			if err != nil {
+				return nil, fmt.Errorf("skills must look like Go:4; PostgreSQL:3, got %q", part)
+			}
+			sk.Level = n
+		}
+		skills = append(skills, sk)
+	}
+	return skills, nil
+}
+
+// readImportJSONL reads one JSON object per line. Blank lines are skipped
+// and a line that does not decode is reported on its own.
+func readImportJSONL(r io.Reader) ([]importLine, error) {
+	br := bufio.NewReader(r)
+	var lines []importLine
+	for n := 1; ; n++ {
+		raw, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 {
+			l := importLine{line: n}
+			dec := json.NewDecoder(bytes.NewReader(trimmed))
+			dec.DisallowUnknownFields()
+			if derr := dec.Decode(&l.row); derr != nil {
+				l.err = fmt.Errorf("must be a JSON object describing one employee: %v", derr)
+			}
+			lines = append(lines, l)
+		}
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+	}
+}
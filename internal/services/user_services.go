@@ -2,33 +2,69 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"multi-processing-backend/internal/core"
 )
 
 type UserRepository interface {
-	List(ctx context.Context, page, limit int, searchName, departmentName string) ([]core.UserWithDetails, int64, error)
+	List(ctx context.Context, page, limit int, filter core.UserFilter, includes core.UserIncludes) ([]core.UserWithDetails, int64, error)
 	Create(ctx context.Context, u core.User) (core.User, error)
 
-	Get(ctx context.Context, id string) (core.UserWithDetails, error)
+	Get(ctx context.Context, id string, includes core.UserIncludes) (core.UserWithDetails, error)
 	Update(ctx context.Context, id string, update core.UserUpdate) (core.User, error)
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id, reassignTo string) error
+
+	SetManager(ctx context.Context, id, managerID string) (core.User, error)
+	GetDirectReports(ctx context.Context, id string) ([]core.OrgMember, error)
+	GetSubtree(ctx context.Context, id string) ([]core.OrgMember, error)
+	GetManagementChain(ctx context.Context, id string) ([]core.OrgMember, error)
+	GetOrgMembers(ctx context.Context, rootID string) ([]core.OrgMember, error)
+
+	Offboard(ctx context.Context, id string, in core.Offboarding) (core.User, error)
+	Rehire(ctx context.Context, id string, in core.Rehire) (core.User, error)
+	SetEmploymentStatus(ctx context.Context, id, status string) (core.User, error)
+	ListEmploymentPeriods(ctx context.Context, id string) ([]core.EmploymentPeriod, error)
+
+	Promote(ctx context.Context, id string, p core.Promotion) (core.PromotionResult, error)
+	ListPositionAssignments(ctx context.Context, id string) ([]core.PositionAssignment, error)
+	TimeInRole(ctx context.Context, departmentID, userID string) ([]core.TimeInRole, error)
+
+	Import(ctx context.Context, rows []core.UserImport, commit bool) ([]core.UserImportResult, error)
+}
+
+// UserAddressReader lists the addresses of a user.
+type UserAddressReader interface {
+	ListByUser(ctx context.Context, userID string) ([]core.Address, error)
 }
 
 type UserService struct {
-	repo UserRepository
+	repo      UserRepository
+	addresses UserAddressReader
 }
 
-func NewUserService(repo UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo UserRepository, addresses UserAddressReader) *UserService {
+	return &UserService{repo: repo, addresses: addresses}
 }
 
 func (s *UserService) List(
 	ctx context.Context, 
 	page, limit int,
-	searchName, departmentName string, 
+	filter core.UserFilter,
+	includes core.UserIncludes,
 ) ([]core.UserWithDetails, int64, error) {
-	return s.repo.List(ctx, page, limit, searchName, departmentName)
+	if err := normalizeUserFilter(&filter); err != nil {
+		return nil, 0, err
+	}
+	if err := checkSalaryFilterAccess(ctx, filter); err != nil {
+		return nil, 0, err
+	}
+	if err := checkSalaryIncludeAccess(ctx, includes); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(ctx, page, limit, filter, includes)
 }
 
 func (s *UserService) Create(
@@ -38,14 +74,156 @@ func (s *UserService) Create(
 	return s.repo.Create(ctx, user)
 }
 
-func (s *UserService) Get(ctx context.Context, id string) (core.UserWithDetails, error) {
-	return s.repo.Get(ctx, id)
+func (s *UserService) Get(ctx context.Context, id string, includes core.UserIncludes) (core.UserWithDetails, error) {
+	if err := checkSalaryIncludeAccess(ctx, includes); err != nil {
+		return core.UserWithDetails{}, err
+	}
+	return s.repo.Get(ctx, id, includes)
 }
 
 func (s *UserService) Update(ctx context.Context, id string, updates core.UserUpdate) (core.User, error) {
 	return s.repo.Update(ctx, id, updates)
 }
 
-func (s *UserService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+func (s *UserService) Delete(ctx context.Context, id, reassignTo string) error {
+	return s.repo.Delete(ctx, id, reassignTo)
+}
+
+func (s *UserService) SetManager(ctx context.Context, id, managerID string) (core.User, error) {
+	if id == managerID {
+		return core.User{}, core.ErrManagerCycle
+	}
+	return s.repo.SetManager(ctx, id, managerID)
+}
+
+func (s *UserService) GetDirectReports(ctx context.Context, id string) ([]core.OrgMember, error) {
+	return s.repo.GetDirectReports(ctx, id)
+}
+
+func (s *UserService) GetSubtree(ctx context.Context, id string) ([]core.OrgMember, error) {
+	return s.repo.GetSubtree(ctx, id)
+}
+
+func (s *UserService) GetManagementChain(ctx context.Context, id string) ([]core.OrgMember, error) {
+	return s.repo.GetManagementChain(ctx, id)
+}
+
+// GetOrgChart nests the flat member list under each manager. Without a root
+// every top-level employee starts its own tree.
+func (s *UserService) GetOrgChart(ctx context.Context, rootID string) ([]*core.OrgChartNode, error) {
+	members, err := s.repo.GetOrgMembers(ctx, rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*core.OrgChartNode, len(members))
+	roots := []*core.OrgChartNode{}
+	// members arrive ordered by depth, so a manager is always seen before
+	// its reports.
+	for _, m := range members {
+		node := &core.OrgChartNode{OrgMember: m, Reports: []*core.OrgChartNode{}}
+		nodes[m.ID] = node
+
+		if parent, ok := nodes[m.ManagerID]; ok {
+			parent.Reports = append(parent.Reports, node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+	return roots, nil
+}
+
+// Offboard ends the employment of a user without deleting any of their
+// data. The termination date may not lie in the future.
+func (s *UserService) Offboard(ctx context.Context, id string, in core.Offboarding) (core.User, error) {
+	in.Reason = strings.TrimSpace(in.Reason)
+	if in.Reason == "" {
+		return core.User{}, fmt.Errorf("reason must not be empty")
+	}
+	in.TerminationDate = dateOnly(in.TerminationDate)
+	if in.TerminationDate.After(time.Now()) {
+		return core.User{}, fmt.Errorf("termination_date must not be in the future")
+	}
+	if in.ReassignTo == id {
+		return core.User{}, core.ErrManagerCycle
+	}
+	return s.repo.Offboard(ctx, id, in)
+}
+
+// Rehire reactivates a terminated user on their existing record.
+func (s *UserService) Rehire(ctx context.Context, id string, in core.Rehire) (core.User, error) {
+	if in.ManagerID == id {
+		return core.User{}, core.ErrManagerCycle
+	}
+	in.HireDate = dateOnly(in.HireDate)
+	return s.repo.Rehire(ctx, id, in)
+}
+
+// SetEmploymentStatus moves a current employee between active and on
+// leave. Terminations go through Offboard and Rehire.
+func (s *UserService) SetEmploymentStatus(ctx context.Context, id, status string) (core.User, error) {
+	if status != core.EmploymentActive && status != core.EmploymentOnLeave {
+		return core.User{}, fmt.Errorf("status must be active or on_leave")
+	}
+	return s.repo.SetEmploymentStatus(ctx, id, status)
+}
+
+func (s *UserService) ListEmploymentPeriods(ctx context.Context, id string) ([]core.EmploymentPeriod, error) {
+	return s.repo.ListEmploymentPeriods(ctx, id)
+}
+
+// Promote moves a user to a new position with a new salary. The move
+// cannot be dated in the future, so users.position_id always reflects the
+// open assignment.
+func (s *UserService) Promote(ctx context.Context, id string, p core.Promotion) (core.PromotionResult, error) {
+	if !core.HasSalaryAccess(ctx) {
+		return core.PromotionResult{}, core.ErrSalaryForbidden
+	}
+	p.EffectiveDate = dateOnly(p.EffectiveDate)
+	if p.EffectiveDate.After(time.Now()) {
+		return core.PromotionResult{}, fmt.Errorf("effective_date must not be in the future")
+	}
+	if !p.Amount.IsPositive() {
+		return core.PromotionResult{}, fmt.Errorf("amount must be positive")
+	}
+	if p.Currency == "" {
+		p.Currency = core.PivotCurrency
+	}
+	currency, err := normalizeCurrency(p.Currency)
+	if err != nil {
+		return core.PromotionResult{}, err
+	}
+	p.Currency = currency
+	p.Amount = p.Amount.Round(2)
+	p.Note = strings.TrimSpace(p.Note)
+	return s.repo.Promote(ctx, id, p)
+}
+
+// PositionTimeline returns the position history of a user together with
+// the time spent in the current role.
+func (s *UserService) PositionTimeline(ctx context.Context, id string) (core.PositionTimeline, error) {
+	assignments, err := s.repo.ListPositionAssignments(ctx, id)
+	if err != nil {
+		return core.PositionTimeline{}, err
+	}
+	current, err := s.repo.TimeInRole(ctx, "", id)
+	if err != nil {
+		return core.PositionTimeline{}, err
+	}
+
+	timeline := core.PositionTimeline{UserID: id, Assignments: assignments}
+	if len(current) > 0 {
+		timeline.Current = &current[0]
+	}
+	return timeline, nil
+}
+
+// TimeInRole lists current staff by how long they have held their
+// position, longest first.
+func (s *UserService) TimeInRole(ctx context.Context, departmentID string) ([]core.TimeInRole, error) {
+	return s.repo.TimeInRole(ctx, departmentID, "")
+}
+
+func (s *UserService) ListAddresses(ctx context.Context, id string) ([]core.Address, error) {
+	return s.addresses.ListByUser(ctx, id)
 }
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS forum_reminders(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    channel_id UUID NOT NULL REFERENCES forum_channels(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES forum_users(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    remind_at TIMESTAMPTZ NOT NULL,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_forum_reminders_due ON forum_reminders(remind_at) WHERE delivered_at IS NULL;
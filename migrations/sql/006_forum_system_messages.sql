@@ -0,0 +1,11 @@
+ALTER TABLE forum_messages
+ADD COLUMN IF NOT EXISTS payload JSONB;
+
+CREATE TABLE IF NOT EXISTS pinned_messages(
+    message_id UUID PRIMARY KEY REFERENCES forum_messages(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES forum_channels(id) ON DELETE CASCADE,
+    pinned_by UUID REFERENCES forum_users(id) ON DELETE SET NULL,
+    pinned_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pinned_messages_channel ON pinned_messages(channel_id, pinned_at DESC);
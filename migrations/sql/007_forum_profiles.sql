@@ -0,0 +1,7 @@
+ALTER TABLE forum_users
+ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS pronouns TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC',
+ADD COLUMN IF NOT EXISTS status_text TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS status_emoji TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS status_expires_at TIMESTAMPTZ;
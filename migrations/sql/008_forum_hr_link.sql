@@ -0,0 +1,17 @@
+ALTER TABLE forum_users
+ADD COLUMN IF NOT EXISTS hr_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT true,
+ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_forum_users_hr_user_id
+    ON forum_users(hr_user_id)
+    WHERE hr_user_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS forum_email_verifications(
+    user_id UUID PRIMARY KEY REFERENCES forum_users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
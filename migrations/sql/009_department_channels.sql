@@ -0,0 +1,2 @@
+ALTER TABLE forum_channels
+ADD COLUMN IF NOT EXISTS department_id UUID UNIQUE REFERENCES departments(id) ON DELETE CASCADE;
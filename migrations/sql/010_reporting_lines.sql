@@ -0,0 +1,13 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS manager_id UUID REFERENCES users(id) ON DELETE RESTRICT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'users_manager_not_self'
+    ) THEN
+        ALTER TABLE users ADD CONSTRAINT users_manager_not_self CHECK (manager_id <> id);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_manager_id ON users(manager_id);
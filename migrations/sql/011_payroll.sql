@@ -0,0 +1,87 @@
+CREATE TABLE IF NOT EXISTS payroll_runs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    period_type TEXT NOT NULL CHECK (period_type IN ('month', 'quarter')),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'approved', 'locked')),
+    total DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    employee_count INTEGER NOT NULL DEFAULT 0,
+    approved_at TIMESTAMPTZ,
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (period_end >= period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payroll_runs_period ON payroll_runs(period_start DESC);
+
+-- Lines are a snapshot: names and departments are copied and user_id has no
+-- foreign key, so later HR changes never alter an approved run.
+CREATE TABLE IF NOT EXISTS payroll_run_lines(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    employee_name TEXT NOT NULL,
+    department_id UUID,
+    department_name TEXT NOT NULL DEFAULT '',
+    days_worked INTEGER NOT NULL,
+    period_days INTEGER NOT NULL,
+    amount DECIMAL(12, 2) NOT NULL,
+    segments JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_payroll_run_lines_run ON payroll_run_lines(run_id);
+
+CREATE OR REPLACE FUNCTION payroll_runs_guard() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.status <> 'draft' THEN
+            RAISE EXCEPTION 'payroll run % is %, only drafts can be deleted', OLD.id, OLD.status;
+        END IF;
+        RETURN OLD;
+    END IF;
+
+    IF OLD.status = 'locked' THEN
+        RAISE EXCEPTION 'payroll run % is locked', OLD.id;
+    END IF;
+    IF OLD.status = 'approved' AND (
+        NEW.status NOT IN ('approved', 'locked')
+        OR NEW.total <> OLD.total
+        OR NEW.employee_count <> OLD.employee_count
+        OR NEW.period_start <> OLD.period_start
+        OR NEW.period_end <> OLD.period_end
+    ) THEN
+        RAISE EXCEPTION 'payroll run % is approved and can only be locked', OLD.id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_payroll_runs_guard ON payroll_runs;
+CREATE TRIGGER trg_payroll_runs_guard
+    BEFORE UPDATE OR DELETE ON payroll_runs
+    FOR EACH ROW EXECUTE FUNCTION payroll_runs_guard();
+
+CREATE OR REPLACE FUNCTION payroll_run_lines_guard() RETURNS trigger AS $$
+DECLARE
+    run_status TEXT;
+BEGIN
+    SELECT status INTO run_status
+    FROM payroll_runs
+    WHERE id = COALESCE(NEW.run_id, OLD.run_id);
+
+    IF run_status IS NOT NULL AND run_status <> 'draft' THEN
+        RAISE EXCEPTION 'payroll run % is %, its lines are immutable', COALESCE(NEW.run_id, OLD.run_id), run_status;
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_payroll_run_lines_guard ON payroll_run_lines;
+CREATE TRIGGER trg_payroll_run_lines_guard
+    BEFORE INSERT OR UPDATE OR DELETE ON payroll_run_lines
+    FOR EACH ROW EXECUTE FUNCTION payroll_run_lines_guard();
@@ -0,0 +1,15 @@
+ALTER TABLE salaries
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE payroll_runs
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR' CHECK (currency ~ '^[A-Z]{3}$');
+
+-- Rates are quoted against EUR: one euro buys rate units of currency. The
+-- rate in effect on a day is the latest one dated on or before it.
+CREATE TABLE IF NOT EXISTS exchange_rates(
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$' AND currency <> 'EUR'),
+    rate_date DATE NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (currency, rate_date)
+);
@@ -0,0 +1,31 @@
+-- A band applies to a position at a given level. Employees are matched on
+-- their position and the level that position currently has.
+CREATE TABLE IF NOT EXISTS salary_bands(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    position_id UUID NOT NULL REFERENCES positions(id) ON DELETE CASCADE,
+    level INTEGER NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'EUR' CHECK (currency ~ '^[A-Z]{3}$'),
+    min_amount DECIMAL(14, 2) NOT NULL CHECK (min_amount > 0),
+    mid_amount DECIMAL(14, 2) NOT NULL,
+    max_amount DECIMAL(14, 2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (position_id, level),
+    CHECK (min_amount <= mid_amount AND mid_amount <= max_amount)
+);
+
+-- Set when a salary was accepted outside its band on purpose.
+ALTER TABLE salaries
+    ADD COLUMN IF NOT EXISTS out_of_band BOOLEAN NOT NULL DEFAULT false;
+
+-- exchange_rate_on mirrors ExchangeRateRepository.RateOn for queries that
+-- convert in SQL. It returns NULL when no rate is known.
+CREATE OR REPLACE FUNCTION exchange_rate_on(code TEXT, on_date DATE) RETURNS NUMERIC AS $$
+    SELECT CASE WHEN code = 'EUR' THEN 1::numeric ELSE (
+        SELECT rate
+        FROM exchange_rates
+        WHERE currency = code AND rate_date <= on_date
+        ORDER BY rate_date DESC
+        LIMIT 1
+    ) END
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS skill_categories(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL CHECK (btrim(name) <> ''),
+    parent_id UUID REFERENCES skill_categories(id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skill_categories_name
+    ON skill_categories ((COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid)), LOWER(name));
+
+ALTER TABLE skills
+    ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES skill_categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_skills_category ON skills(category_id);
+
+-- skill_key folds spelling variants so "Go-Lang", "go lang" and "golang"
+-- compare equal.
+CREATE OR REPLACE FUNCTION skill_key(name TEXT) RETURNS TEXT AS $$
+    SELECT regexp_replace(lower(btrim(name)), '[[:space:]._-]+', '', 'g')
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TABLE IF NOT EXISTS skill_aliases(
+    alias_key TEXT PRIMARY KEY,
+    alias TEXT NOT NULL,
+    skill_id UUID NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_skill_aliases_skill ON skill_aliases(skill_id);
+
+-- skills.category stays as the display name of the category so older
+-- clients and the seeder keep working. Setting category_id copies the name;
+-- setting only the text files the skill under a top-level category of that
+-- name, creating it when needed.
+CREATE OR REPLACE FUNCTION skills_sync_category() RETURNS trigger AS $$
+BEGIN
+    IF NEW.category_id IS NOT NULL AND (TG_OP = 'INSERT' OR NEW.category_id IS DISTINCT FROM OLD.category_id) THEN
+        SELECT name INTO NEW.category FROM skill_categories WHERE id = NEW.category_id;
+    ELSIF COALESCE(btrim(NEW.category), '') <> ''
+        AND (NEW.category_id IS NULL OR NEW.category IS DISTINCT FROM OLD.category) THEN
+        SELECT id INTO NEW.category_id
+        FROM skill_categories
+        WHERE parent_id IS NULL AND LOWER(name) = LOWER(btrim(NEW.category));
+
+        IF NEW.category_id IS NULL THEN
+            INSERT INTO skill_categories (name) VALUES (btrim(NEW.category))
+            RETURNING id INTO NEW.category_id;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_skills_sync_category ON skills;
+CREATE TRIGGER trg_skills_sync_category
+    BEFORE INSERT OR UPDATE ON skills
+    FOR EACH ROW EXECUTE FUNCTION skills_sync_category();
+
+UPDATE skills SET category_id = NULL
+WHERE category_id IS NULL AND COALESCE(btrim(category), '') <> '';
@@ -0,0 +1,13 @@
+-- Skills a position asks for. Required skills gate readiness for the
+-- position; the others are nice to have.
+CREATE TABLE IF NOT EXISTS position_skills(
+    position_id UUID NOT NULL REFERENCES positions(id) ON DELETE CASCADE,
+    skill_id UUID NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+    min_proficiency INTEGER NOT NULL CHECK (min_proficiency BETWEEN 1 AND 5),
+    required BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (position_id, skill_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_position_skills_skill ON position_skills(skill_id);
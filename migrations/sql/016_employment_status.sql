@@ -0,0 +1,38 @@
+-- Leavers keep their record, salaries and skills. Terminated employees
+-- carry the date and reason of their last exit until they are rehired.
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS employment_status TEXT NOT NULL DEFAULT 'active',
+ADD COLUMN IF NOT EXISTS termination_date DATE,
+ADD COLUMN IF NOT EXISTS termination_reason TEXT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'users_employment_status_check'
+    ) THEN
+        ALTER TABLE users ADD CONSTRAINT users_employment_status_check
+            CHECK (employment_status IN ('active', 'on_leave', 'terminated'));
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'users_termination_date_check'
+    ) THEN
+        ALTER TABLE users ADD CONSTRAINT users_termination_date_check
+            CHECK ((employment_status = 'terminated') = (termination_date IS NOT NULL));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_employment_status ON users(employment_status);
+
+-- One row per completed employment, written at offboarding, so a rehire
+-- does not lose the earlier stint.
+CREATE TABLE IF NOT EXISTS employment_periods(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hire_date DATE NOT NULL,
+    termination_date DATE NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_employment_periods_user ON employment_periods(user_id, hire_date);
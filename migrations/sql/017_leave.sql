@@ -0,0 +1,54 @@
+-- Leave types with an entitlement are counted against a yearly allowance;
+-- the others (sick leave) are only recorded.
+CREATE TABLE IF NOT EXISTS leave_types(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    has_entitlement BOOLEAN NOT NULL DEFAULT true,
+    default_days NUMERIC(5, 1) NOT NULL DEFAULT 0 CHECK (default_days >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO leave_types (code, name, has_entitlement, default_days) VALUES
+    ('vacation', 'Vacation', true, 25),
+    ('sick', 'Sick leave', false, 0),
+    ('parental', 'Parental leave', true, 0)
+ON CONFLICT (code) DO NOTHING;
+
+-- Overrides the default allowance of a type for one user and year.
+CREATE TABLE IF NOT EXISTS leave_entitlements(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    leave_type_id UUID NOT NULL REFERENCES leave_types(id) ON DELETE CASCADE,
+    year INTEGER NOT NULL,
+    days NUMERIC(5, 1) NOT NULL CHECK (days >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, leave_type_id, year)
+);
+
+-- half_day_start means the first day starts at noon, half_day_end that the
+-- last day ends at noon.
+CREATE TABLE IF NOT EXISTS leave_requests(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    leave_type_id UUID NOT NULL REFERENCES leave_types(id) ON DELETE RESTRICT,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    half_day_start BOOLEAN NOT NULL DEFAULT false,
+    half_day_end BOOLEAN NOT NULL DEFAULT false,
+    days NUMERIC(5, 1) NOT NULL CHECK (days > 0),
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    reason TEXT NOT NULL DEFAULT '',
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decision_note TEXT NOT NULL DEFAULT '',
+    decided_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_date >= start_date),
+    CHECK (EXTRACT(YEAR FROM start_date) = EXTRACT(YEAR FROM end_date))
+);
+
+CREATE INDEX IF NOT EXISTS idx_leave_requests_user ON leave_requests(user_id, start_date);
+CREATE INDEX IF NOT EXISTS idx_leave_requests_dates ON leave_requests(start_date, end_date);
@@ -0,0 +1,65 @@
+ALTER TABLE addresses
+ADD COLUMN IF NOT EXISTS address_type TEXT NOT NULL DEFAULT 'home';
+
+ALTER TABLE addresses ALTER COLUMN is_primary SET DEFAULT false;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'addresses_address_type_check'
+    ) THEN
+        ALTER TABLE addresses ADD CONSTRAINT addresses_address_type_check
+            CHECK (address_type IN ('home', 'mailing', 'work'));
+    END IF;
+END $$;
+
+-- Earlier rows defaulted to primary. Keep the oldest primary of each user,
+-- and promote the oldest address of users left without one.
+UPDATE addresses SET is_primary = false
+WHERE id IN (
+    SELECT id
+    FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at, id) AS n
+        FROM addresses
+        WHERE is_primary
+    ) ranked
+    WHERE n > 1
+);
+
+UPDATE addresses SET is_primary = true
+WHERE id IN (
+    SELECT DISTINCT ON (user_id) id
+    FROM addresses a
+    WHERE NOT EXISTS (SELECT 1 FROM addresses p WHERE p.user_id = a.user_id AND p.is_primary)
+    ORDER BY user_id, created_at, id
+);
+
+-- At most one primary per user...
+CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_one_primary ON addresses(user_id) WHERE is_primary;
+
+-- ...and at least one for anyone with addresses, checked at commit so the
+-- primary can be moved within a transaction.
+CREATE OR REPLACE FUNCTION addresses_require_primary() RETURNS trigger AS $$
+DECLARE
+    owner UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        owner := OLD.user_id;
+    ELSE
+        owner := NEW.user_id;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM addresses WHERE user_id = owner)
+        AND NOT EXISTS (SELECT 1 FROM addresses WHERE user_id = owner AND is_primary) THEN
+        RAISE EXCEPTION 'user % has addresses but no primary address', owner
+            USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_addresses_require_primary ON addresses;
+CREATE CONSTRAINT TRIGGER trg_addresses_require_primary
+    AFTER INSERT OR UPDATE OR DELETE ON addresses
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION addresses_require_primary();
@@ -0,0 +1,27 @@
+-- Countries are stored as ISO 3166-1 alpha-2 codes. Convert the names the
+-- old free-text column collected most; cmd/addresscheck reports the rest.
+ALTER TABLE addresses ALTER COLUMN country SET DEFAULT 'DE';
+
+UPDATE addresses
+SET country = CASE LOWER(TRIM(country))
+        WHEN 'germany' THEN 'DE'
+        WHEN 'deutschland' THEN 'DE'
+        WHEN 'austria' THEN 'AT'
+        WHEN 'österreich' THEN 'AT'
+        WHEN 'switzerland' THEN 'CH'
+        WHEN 'schweiz' THEN 'CH'
+        WHEN 'france' THEN 'FR'
+        WHEN 'netherlands' THEN 'NL'
+        WHEN 'the netherlands' THEN 'NL'
+        WHEN 'united kingdom' THEN 'GB'
+        WHEN 'uk' THEN 'GB'
+        WHEN 'united states' THEN 'US'
+        WHEN 'united states of america' THEN 'US'
+        WHEN 'usa' THEN 'US'
+    END,
+    updated_at = NOW()
+WHERE LOWER(TRIM(country)) IN (
+    'germany', 'deutschland', 'austria', 'österreich', 'switzerland', 'schweiz', 'france',
+    'netherlands', 'the netherlands', 'united kingdom', 'uk', 'united states',
+    'united states of america', 'usa'
+);
@@ -0,0 +1,30 @@
+-- Company locations. Their address is public, unlike the personal
+-- addresses of employees.
+CREATE TABLE IF NOT EXISTS offices(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    street TEXT NOT NULL,
+    city TEXT NOT NULL,
+    zip_code TEXT NOT NULL,
+    country TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    capacity INTEGER NOT NULL CHECK (capacity >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Where an employee works. Remote workers have no office; hybrid workers
+-- list the weekdays they come in.
+CREATE TABLE IF NOT EXISTS user_workplaces(
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    mode TEXT NOT NULL CHECK (mode IN ('office', 'remote', 'hybrid')),
+    office_id UUID REFERENCES offices(id) ON DELETE RESTRICT,
+    office_days TEXT[] NOT NULL DEFAULT '{}'
+        CHECK (office_days <@ ARRAY['mon', 'tue', 'wed', 'thu', 'fri']),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((mode = 'remote') = (office_id IS NULL)),
+    CHECK ((mode = 'hybrid') = (cardinality(office_days) > 0))
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_workplaces_office ON user_workplaces(office_id);
@@ -0,0 +1,29 @@
+-- Effective-dated history of each employee's position and department.
+-- end_date is exclusive: it is the start_date of the next assignment, or
+-- the day after the employment ended. The open assignment has no end_date.
+CREATE TABLE IF NOT EXISTS position_assignments(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    position_id UUID REFERENCES positions(id) ON DELETE SET NULL,
+    department_id UUID REFERENCES departments(id) ON DELETE SET NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    reason TEXT NOT NULL CHECK (reason IN ('hire', 'rehire', 'promotion', 'change')),
+    salary_id UUID REFERENCES salaries(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_date IS NULL OR end_date > start_date)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_position_assignments_open
+    ON position_assignments(user_id) WHERE end_date IS NULL;
+CREATE INDEX IF NOT EXISTS idx_position_assignments_user
+    ON position_assignments(user_id, start_date);
+
+-- Start the history of everyone employed before it was kept.
+INSERT INTO position_assignments (user_id, position_id, department_id, start_date, end_date, reason)
+SELECT u.id, u.position_id, u.department_id, u.hire_date,
+    CASE WHEN u.termination_date IS NOT NULL THEN u.termination_date + 1 END,
+    'hire'
+FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM position_assignments a WHERE a.user_id = u.id);
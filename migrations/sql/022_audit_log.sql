@@ -0,0 +1,122 @@
+-- Append-only record of every change to HR data. Rows are written by
+-- triggers, so cascaded deletes are captured too; there are no foreign
+-- keys so entries outlive the entities they describe. The actor and
+-- request ID come from the audit.actor and audit.request_id settings the
+-- application stamps on a connection when it is acquired. The actor is the
+-- one whose token authenticated the request.
+CREATE TABLE IF NOT EXISTS audit_log(
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    entity TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    before JSONB,
+    after JSONB,
+    changes JSONB NOT NULL DEFAULT '{}'
+);
+
+COMMENT ON COLUMN audit_log.actor IS
+    'Actor whose token authenticated the request, anonymous without a token, or system for background jobs';
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred ON audit_log(occurred_at);
+
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_log_immutable ON audit_log;
+CREATE TRIGGER trg_audit_log_immutable
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+
+DROP TRIGGER IF EXISTS trg_audit_log_no_truncate ON audit_log;
+CREATE TRIGGER trg_audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable();
+
+-- audit_row_change(entity, key_column...) logs one row change. The entity
+-- ID is the id column, or the given key columns joined with ':'. Updates
+-- that only touch updated_at are not logged.
+CREATE OR REPLACE FUNCTION audit_row_change() RETURNS trigger AS $$
+DECLARE
+    old_row JSONB;
+    new_row JSONB;
+    row_data JSONB;
+    diff JSONB := '{}';
+    key_id TEXT;
+    act TEXT;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        old_row := to_jsonb(OLD);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        new_row := to_jsonb(NEW);
+    END IF;
+    row_data := COALESCE(new_row, old_row);
+
+    IF TG_OP = 'UPDATE' THEN
+        act := 'update';
+        SELECT COALESCE(jsonb_object_agg(n.key, jsonb_build_object('from', o.value, 'to', n.value)), '{}')
+        INTO diff
+        FROM jsonb_each(new_row) n
+        JOIN jsonb_each(old_row) o ON o.key = n.key
+        WHERE n.value IS DISTINCT FROM o.value AND n.key <> 'updated_at';
+        IF diff = '{}' THEN
+            RETURN NULL;
+        END IF;
+    ELSIF TG_OP = 'INSERT' THEN
+        act := 'create';
+    ELSE
+        act := 'delete';
+    END IF;
+
+    IF TG_NARGS > 1 THEN
+        SELECT string_agg(row_data ->> TG_ARGV[i], ':' ORDER BY i)
+        INTO key_id
+        FROM generate_series(1, TG_NARGS - 1) AS i;
+    ELSE
+        key_id := row_data ->> 'id';
+    END IF;
+
+    INSERT INTO audit_log (actor, request_id, entity, entity_id, action, before, after, changes)
+    VALUES (
+        COALESCE(NULLIF(current_setting('audit.actor', true), ''), 'system'),
+        COALESCE(current_setting('audit.request_id', true), ''),
+        TG_ARGV[0], key_id, act, old_row, new_row, diff
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    audited TEXT[][] := ARRAY[
+        ['users', 'user', ''],
+        ['departments', 'department', ''],
+        ['positions', 'position', ''],
+        ['salaries', 'salary', ''],
+        ['addresses', 'address', ''],
+        ['skills', 'skill', ''],
+        ['skill_aliases', 'skill_alias', '''alias_key'''],
+        ['skill_categories', 'skill_category', ''],
+        ['user_skills', 'user_skill', '''user_id'', ''skill_id'''],
+        ['position_skills', 'position_skill', '''position_id'', ''skill_id'''],
+        ['salary_bands', 'salary_band', '']
+    ];
+    t TEXT[];
+BEGIN
+    FOREACH t SLICE 1 IN ARRAY audited LOOP
+        EXECUTE format('DROP TRIGGER IF EXISTS trg_audit ON %I', t[1]);
+        EXECUTE format(
+            'CREATE TRIGGER trg_audit AFTER INSERT OR UPDATE OR DELETE ON %I
+                FOR EACH ROW EXECUTE FUNCTION audit_row_change(%L%s)',
+            t[1], t[2], CASE WHEN t[3] = '' THEN '' ELSE ', ' || t[3] END
+        );
+    END LOOP;
+END $$;
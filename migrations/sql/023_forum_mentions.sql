@@ -0,0 +1,11 @@
+-- regexp_quote escapes every character of s that could mean something in a
+-- regular expression, so s matches only itself.
+CREATE OR REPLACE FUNCTION regexp_quote(s TEXT) RETURNS TEXT AS $$
+    SELECT regexp_replace(s, '(\W)', '\\\1', 'g')
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+-- forum_mentions reports whether content mentions @username as a whole word:
+-- "@bob," does, "@bobby", "mail@bob.example" and "@bob.smith" do not.
+CREATE OR REPLACE FUNCTION forum_mentions(content TEXT, username TEXT) RETURNS BOOLEAN AS $$
+    SELECT content ~* ('(^|\W)@' || regexp_quote(username) || '(?!\w|[.-]\w)')
+$$ LANGUAGE sql IMMUTABLE STRICT;